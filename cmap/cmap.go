@@ -7,7 +7,7 @@ package cmap
 
 import (
 	"../common"
-	"bytes"
+	"../core"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -27,6 +27,8 @@ type CMap struct {
 
 	name       string
 	ctype      int
+	registry   string
+	ordering   string
 	codespaces []codespace
 	// use to show the code space length, 0x10, 0x100, 0x1000, 0x10000
 	codeSpan int8
@@ -38,8 +40,60 @@ func (c *CMap) GetCodeMap() map[uint64]string {
 
 // codespace represents a single codespace range used in the CMap.
 type codespace struct {
-	low  uint64
-	high uint64
+	numBytes int
+	low      uint64
+	high     uint64
+}
+
+// contains returns whether the code falls within the codespace range: it has as many bytes as the range
+// and each of its bytes is within the bounds of the corresponding bytes of low and high.
+func (cs codespace) contains(code []byte) bool {
+	if len(code) != cs.numBytes {
+		return false
+	}
+	for i, b := range code {
+		shift := uint(8 * (cs.numBytes - 1 - i))
+		if b < byte(cs.low>>shift) || b > byte(cs.high>>shift) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCode returns the length of the code at the start of src, 0 if no codespace range matches.  Of the
+// codespace ranges the leading bytes fall into, the longest one with a mapping for the code is taken, the
+// longest one otherwise, so that a lead byte which is a complete code in a range of 1 byte and starts a
+// code of 2 bytes in another range is segmented by the bytes that follow it.
+func (cmap *CMap) matchCode(src []byte) int {
+	matched := 0
+	for n := 4; n > 0; n-- {
+		if n > len(src) {
+			continue
+		}
+		for _, cs := range cmap.codespaces {
+			if !cs.contains(src[:n]) {
+				continue
+			}
+			if _, has := cmap.codeMap[bytesToUint64(src[:n])]; has {
+				return n
+			}
+			if matched == 0 {
+				matched = n
+			}
+			break
+		}
+	}
+
+	return matched
+}
+
+// CodeLength returns the length of the character code at the start of src by the codespace ranges of the
+// CMap, 1 if none matches.
+func (cmap *CMap) CodeLength(src []byte) int {
+	if n := cmap.matchCode(src); n > 0 {
+		return n
+	}
+	return 1
 }
 
 // Name returns the name of the CMap.
@@ -52,24 +106,54 @@ func (cmap *CMap) Type() int {
 	return cmap.ctype
 }
 
+// Registry returns the registry of the CIDSystemInfo of the CMap, e.g. Adobe.
+func (cmap *CMap) Registry() string {
+	return cmap.registry
+}
+
+// Ordering returns the ordering of the CIDSystemInfo of the CMap, e.g. GB1.
+func (cmap *CMap) Ordering() string {
+	return cmap.ordering
+}
+
 // CharcodeBytesToUnicode converts a byte array of charcodes to a unicode string representation.
+// Codes that can't be mapped are dropped.
 func (cmap *CMap) CharcodeBytesToUnicode(src []byte, simpleEncoding []uint, flag bool) string {
-	var buf bytes.Buffer
+	return cmap.CharcodeBytesToUnicodeFallback(src, simpleEncoding, flag, nil)
+}
+
+// CharcodeBytesToUnicodeFallback is like CharcodeBytesToUnicode, but the bytes of codes that can't be
+// mapped are passed to unmapped and its result is written in their place. A nil unmapped drops them.
+func (cmap *CMap) CharcodeBytesToUnicodeFallback(src []byte, simpleEncoding []uint, flag bool, unmapped func(code []byte) string) string {
+	buf := core.GetBuffer()
+	defer core.PutBuffer(buf)
 
 	// Maximum number of possible bytes per code.
 	maxLen := 4
 
+	encodingList := make([]string, 0, maxLen)
 	i := 0
 	for i < len(src) {
+		if n := cmap.matchCode(src[i:]); n > 0 {
+			if tgt, has := cmap.codeMap[bytesToUint64(src[i:i+n])]; has {
+				buf.WriteString(tgt)
+			} else if unmapped != nil {
+				buf.WriteString(unmapped(src[i : i+n]))
+			}
+			i += n
+			continue
+		}
+
+		// no codespace range matches, the code is the first mapped one
 		var code uint64
 		var j int
-		encodingList := make([]string, 4)
+		encodingList = encodingList[:0]
 
 		for j = 0; j < maxLen && i+j < len(src); j++ {
 			b := src[i+j]
 
 			if flag {
-				encodingList = append(encodingList, Utf8CodepointToUtf8(simpleEncoding[b]))
+				encodingList = append(encodingList, CodepointToUtf8(simpleEncoding[b]))
 			}
 
 			code <<= 8
@@ -80,6 +164,9 @@ func (cmap *CMap) CharcodeBytesToUnicode(src []byte, simpleEncoding []uint, flag
 				buf.WriteString(tgt)
 				break
 			} else if j == maxLen-1 || i+j == len(src)-1 {
+				if unmapped != nil {
+					buf.WriteString(unmapped(src[i : i+j+1]))
+				}
 				/*if !flag {
 					common.Log.Debug("Error: can't map to unicode, need check, src: 0X%X, 0X%X, 0X%X, 0X%X", code, code>>8, code>>16, code>>24)
 					if i+j-3 > 0 {
@@ -103,13 +190,29 @@ func (cmap *CMap) CharcodeBytesToUnicode(src []byte, simpleEncoding []uint, flag
 
 // CharcodeBytesToUnicode converts a byte array of charcodes to a unicode string representation.
 func (cmap *CMap) CharcodeBytesToCidStr(src []byte) string {
-	var buf bytes.Buffer
+	buf := core.GetBuffer()
+	defer core.PutBuffer(buf)
 
 	// Maximum number of possible bytes per code.
 	maxLen := 4
 
 	i := 0
 	for i < len(src) {
+		if n := cmap.matchCode(src[i:]); n > 0 {
+			if tgt, has := cmap.codeMap[bytesToUint64(src[i:i+n])]; has {
+				//tgt is hex string for codeid
+				if decoded, err := hex.DecodeString(tgt); err == nil {
+					buf.WriteString(string(decoded))
+				}
+			} else {
+				common.Log.Debug("Error: can't map to cid code, need check, src: 0X%X", src[i:i+n])
+				buf.Write(src[i : i+n])
+			}
+			i += n
+			continue
+		}
+
+		// no codespace range matches, the code is the first mapped one
 		var code uint64
 		var j int
 
@@ -246,6 +349,39 @@ func (cmap *CMap) parse() error {
 					return errors.New("CMap type not an integer")
 				}
 				cmap.ctype = int(typeInt.val)
+			} else if n.Name == registry || n.Name == ordering {
+				// CIDSystemInfo entries in the "3 dict dup begin ... end" form of CMap resources.
+				o, err := cmap.parseObject()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					return err
+				}
+				if str, ok := o.(cmapString); ok {
+					if n.Name == registry {
+						cmap.registry = str.String
+					} else {
+						cmap.ordering = str.String
+					}
+				}
+			} else if n.Name == cidsysteminfo {
+				// CIDSystemInfo as a dictionary, as in embedded CMaps.
+				o, err := cmap.parseObject()
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					return err
+				}
+				if dict, ok := o.(cmapDict); ok {
+					if str, ok := dict.Dict[registry].(cmapString); ok {
+						cmap.registry = str.String
+					}
+					if str, ok := dict.Dict[ordering].(cmapString); ok {
+						cmap.ordering = str.String
+					}
+				}
 			}
 		} else {
 			common.Log.Trace("Unhandled object: %T %#v", o, o)
@@ -291,7 +427,7 @@ func (cmap *CMap) parseCodespaceRange() error {
 		low := hexToUint64(hexLow)
 		high := hexToUint64(hexHigh)
 
-		cspace := codespace{low, high}
+		cspace := codespace{len(hexHigh.b), low, high}
 		cmap.codespaces = append(cmap.codespaces, cspace)
 
 		cmap.codeSpan = cmap.codeSpan | int8(math.Pow(2.0, float64(len(hexHigh.b))))
@@ -513,7 +649,7 @@ func (cmap *CMap) parseNotdefrange() error {
 			// in hex format.
 			target := hexToUint64(v)
 			for sc := srcCodeFrom; sc <= srcCodeTo; sc++ {
-				cmap.codeMap[sc] = string(target)
+				cmap.codeMap[sc] = string(rune(target))
 			}
 		case cmapInt:
 			target := uint64(v.val)
@@ -619,7 +755,7 @@ func (cmap *CMap) parseCidrange() error {
 			i := uint64(0)
 			for sc := srcCodeFrom; sc <= srcCodeTo; sc++ {
 				r := target + i
-				cmap.codeMap[sc] = string(r)
+				cmap.codeMap[sc] = string(rune(r))
 				i++
 			}
 		case cmapInt:
@@ -737,7 +873,7 @@ func (cmap *CMap) parseBfrange() error {
 			i := uint64(0)
 			for sc := srcCodeFrom; sc <= srcCodeTo; sc++ {
 				r := target + i
-				cmap.codeMap[sc] = string(r)
+				cmap.codeMap[sc] = string(rune(r))
 				i++
 			}
 		case cmapInt:
@@ -0,0 +1,36 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package cmap
+
+import (
+	"testing"
+)
+
+func TestMixedCodespaceLengths(t *testing.T) {
+	// Shift-JIS like: 41 is a code of 1 byte, 81 starts a code of 2 bytes
+	data := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /Test-H def " +
+		"2 begincodespacerange <00> <80> <8140> <9FFC> endcodespacerange " +
+		"3 beginbfchar <41> <0041> <42> <0042> <8141> <4E2D> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	cmap, err := LoadCmapFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("LoadCmapFromData: %v", err)
+	}
+
+	src := []byte{0x41, 0x81, 0x41, 0x42}
+	expected := []int{1, 2, 1}
+	for i, n := 0, 0; i < len(src); n++ {
+		length := cmap.CodeLength(src[i:])
+		if n >= len(expected) || length != expected[n] {
+			t.Fatalf("code %d at byte %d of length %d", n, i, length)
+		}
+		i += length
+	}
+
+	if text := cmap.CharcodeBytesToUnicode(src, nil, false); text != "A中B" {
+		t.Errorf("got %q", text)
+	}
+}
@@ -26,6 +26,10 @@ const (
 
 	cmapname = "CMapName"
 	cmaptype = "CMapType"
+
+	cidsysteminfo = "CIDSystemInfo"
+	registry      = "Registry"
+	ordering      = "Ordering"
 )
 
 var reNumeric = regexp.MustCompile(`^[\+-.]*([0-9.]+)`)
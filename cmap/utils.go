@@ -10,9 +10,14 @@ import (
 )
 
 func hexToUint64(shex cmapHexString) uint64 {
+	return bytesToUint64(shex.b)
+}
+
+// bytesToUint64 returns the big-endian value of the bytes of a character code.
+func bytesToUint64(b []byte) uint64 {
 	val := uint64(0)
 
-	for _, v := range shex.b {
+	for _, v := range b {
 		val <<= 8
 		val |= uint64(v)
 	}
@@ -36,6 +41,12 @@ func hexToString(shex cmapHexString) string {
 	return buf.String()
 }
 
+// CodepointToUtf8 returns the UTF-8 encoding of a Unicode code point, as held by the simple encoding tables.
+func CodepointToUtf8(codepoint uint) string {
+	return string(rune(codepoint))
+}
+
+// Utf8CodepointToUtf8 returns the bytes of a value packed as UTF-8 (e.g. 0xc3a9 for U+00E9).
 func Utf8CodepointToUtf8(utf8Codepoint uint) string {
 	out := make([]byte, 4)
 	if utf8Codepoint < 0x100 {
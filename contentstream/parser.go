@@ -18,9 +18,14 @@ import (
 	. "../core"
 )
 
+// DefaultMaxOperands is the default number of operands buffered before an operator, more than any
+// operator takes.
+const DefaultMaxOperands = 10000
+
 // Content stream parser.
 type ContentStreamParser struct {
-	reader *bufio.Reader
+	reader      *bufio.Reader
+	maxOperands int
 }
 
 // Create a new instance of the content stream parser from an input content
@@ -31,13 +36,24 @@ func NewContentStreamParser(contentStr string) *ContentStreamParser {
 
 	buffer := bytes.NewBufferString(contentStr + "\n") // Add newline at end to get last operand without EOF error.
 	parser.reader = bufio.NewReader(buffer)
+	parser.maxOperands = DefaultMaxOperands
 
 	return &parser
 }
 
+// SetMaxOperands sets the number of operands buffered before an operator.  The operands of a malformed
+// stream going beyond it are discarded, so that they don't grow without bounds.  A value <= 0 removes
+// the limit.
+func (this *ContentStreamParser) SetMaxOperands(max int) {
+	this.maxOperands = max
+}
+
 // Parses all commands in content stream, returning a list of operation data.
+// Within a compatibility section (BX ... EX) tokens that fail to parse are skipped along with the
+// operation they belong to, as they may be part of operators unknown to this parser.
 func (this *ContentStreamParser) Parse() (*ContentStreamOperations, error) {
 	operations := ContentStreamOperations{}
+	compatibilityDepth := 0
 
 	for {
 		operation := ContentStreamOperation{}
@@ -49,29 +65,76 @@ func (this *ContentStreamParser) Parse() (*ContentStreamOperations, error) {
 					// End of data. Successful exit point.
 					return &operations, nil
 				}
+				if compatibilityDepth > 0 {
+					common.Log.Debug("Skipping unparsable token in compatibility section: %v", err)
+					if err := this.skipToDelimiter(); err != nil {
+						return &operations, nil
+					}
+					operation = ContentStreamOperation{}
+					continue
+				}
 				return &operations, err
 			}
 			if isOperand {
 				operation.Operand = string(*obj.(*PdfObjectString))
+				if operation.Operand == "" && compatibilityDepth > 0 {
+					// Stray delimiter.
+					operation = ContentStreamOperation{}
+					continue
+				}
 				operations = append(operations, &operation)
 				break
 			} else {
+				if this.maxOperands > 0 && len(operation.Params) >= this.maxOperands {
+					common.Log.Debug("Warning: more than %d operands before an operator, discarding them", this.maxOperands)
+					operation.Params = nil
+				}
 				operation.Params = append(operation.Params, obj)
 			}
 		}
 
-		if operation.Operand == "BI" {
+		switch operation.Operand {
+		case "BX":
+			compatibilityDepth++
+		case "EX":
+			if compatibilityDepth > 0 {
+				compatibilityDepth--
+			}
+		case "BI":
 			// Parse an inline image, reads everything between the "BI" and "EI".
 			// The image is stored as the parameter.
 			im, err := this.ParseInlineImage()
 			if err != nil {
-				return &operations, err
+				if compatibilityDepth == 0 {
+					return &operations, err
+				}
+				common.Log.Debug("Skipping unparsable inline image in compatibility section: %v", err)
+				operations = operations[:len(operations)-1]
+				continue
 			}
 			operation.Params = append(operation.Params, im)
 		}
 	}
 }
 
+// Skip the rest of a token that failed to parse: at least one byte, then up to the next white space or
+// delimiter.
+func (this *ContentStreamParser) skipToDelimiter() error {
+	if _, err := this.reader.ReadByte(); err != nil {
+		return err
+	}
+	for {
+		bb, err := this.reader.Peek(1)
+		if err != nil {
+			return err
+		}
+		if IsWhiteSpace(bb[0]) || IsDelimiter(bb[0]) {
+			return nil
+		}
+		this.reader.ReadByte()
+	}
+}
+
 // Skip over any spaces.  Returns the number of spaces skipped and
 // an error if any.
 func (this *ContentStreamParser) skipSpaces() (int, error) {
@@ -375,7 +438,7 @@ func (this *ContentStreamParser) parseArray() (PdfObjectArray, error) {
 	this.reader.ReadByte()
 
 	for {
-		this.skipSpaces()
+		this.skipComments()
 
 		bb, err := this.reader.Peek(1)
 		if err != nil {
@@ -442,7 +505,7 @@ func (this *ContentStreamParser) parseDict() (*PdfObjectDictionary, error) {
 	}
 
 	for {
-		this.skipSpaces()
+		this.skipComments()
 
 		bb, err := this.reader.Peek(2)
 		if err != nil {
@@ -503,7 +566,7 @@ func (this *ContentStreamParser) parseOperand() (PdfObjectString, error) {
 		}
 		if IsDelimiter(bb[0]) {
 			//TODO: bugfix for decodestream failed
-			if bb[0] == ')' || bb[0] == '>' || bb[0] == '}' || bb[0] == ']' {
+			if bb[0] == ')' || bb[0] == '>' || bb[0] == '{' || bb[0] == '}' || bb[0] == ']' {
 				this.reader.ReadBytes(bb[0])
 			}
 			break
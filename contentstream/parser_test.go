@@ -0,0 +1,93 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCompatibilitySection(t *testing.T) {
+	// vendor operators with an operand in braces and an unparsable dictionary
+	content := "BT /F1 12 Tf BX /Vendor {1 2} 3 vnd << 1 2 >> vnd2 EX (Hello) Tj ET"
+	operations, err := NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ops := map[string]*ContentStreamOperation{}
+	for _, op := range *operations {
+		ops[op.Operand] = op
+	}
+	for _, operand := range []string{"BT", "Tf", "BX", "vnd", "EX", "Tj", "ET"} {
+		if ops[operand] == nil {
+			t.Errorf("%s missing", operand)
+		}
+	}
+	if tj := ops["Tj"]; tj != nil && (len(tj.Params) != 1 || tj.Params[0].String() != "Hello") {
+		t.Errorf("Tj params %v", tj.Params)
+	}
+
+	// braces don't stop the parser outside of a compatibility section either
+	if operations, err := NewContentStreamParser("q {1 2} vnd Q").Parse(); err != nil || len(*operations) == 0 ||
+		(*operations)[len(*operations)-1].Operand != "Q" {
+		t.Errorf("braces outside of BX/EX: err: %v", err)
+	}
+
+	// unparsable outside of a compatibility section
+	if _, err := NewContentStreamParser("BT << 1 2 >> vnd ET").Parse(); err == nil {
+		t.Errorf("no error outside of BX/EX")
+	}
+}
+
+func TestComments(t *testing.T) {
+	content := "BT % comment\n/F1 12 Tf\n[(A) % comment ]\n-200 (B)] TJ /P << /MCID 1 % comment >>\n>> BDC EMC ET"
+	operations, err := NewContentStreamParser(content).Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	expected := []string{"BT", "Tf", "TJ", "BDC", "EMC", "ET"}
+	if len(*operations) != len(expected) {
+		t.Fatalf("got %d operations", len(*operations))
+	}
+	for i, op := range *operations {
+		if op.Operand != expected[i] {
+			t.Errorf("operation %d: %s, expected %s", i, op.Operand, expected[i])
+		}
+	}
+	if tj := (*operations)[2]; len(tj.Params) != 1 || tj.Params[0].String() != "[A, -200, B]" {
+		t.Errorf("TJ params %v", tj.Params)
+	}
+	if bdc := (*operations)[3]; len(bdc.Params) != 2 || bdc.Params[1].String() != "Dict(\"MCID\": 1, )" {
+		t.Errorf("BDC params %v", bdc.Params)
+	}
+}
+
+func TestMaxOperands(t *testing.T) {
+	var content bytes.Buffer
+	for i := 0; i < 100000; i++ {
+		content.WriteString("1 ")
+	}
+	content.WriteString("2 3 Td")
+
+	operations, err := NewContentStreamParser(content.String()).Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(*operations) != 1 {
+		t.Fatalf("got %d operations", len(*operations))
+	}
+	// the pending operands discarded every DefaultMaxOperands, the buffer never growing beyond
+	if td := (*operations)[0]; td.Operand != "Td" || len(td.Params) != 2 || cap(td.Params) > 2*DefaultMaxOperands {
+		t.Errorf("Td of %d params, capacity %d", len(td.Params), cap(td.Params))
+	}
+
+	parser := NewContentStreamParser("1 2 3 4 5 6 7 Td")
+	parser.SetMaxOperands(5)
+	if operations, err = parser.Parse(); err != nil || len(*operations) != 1 || len((*operations)[0].Params) != 2 {
+		t.Errorf("limit of 5: got %v, err: %v", operations, err)
+	}
+}
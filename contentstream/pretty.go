@@ -0,0 +1,145 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	. "../core"
+)
+
+// Short descriptions of the content stream operators, used to annotate the pretty printed output.
+var operatorDescriptions = map[string]string{
+	"q":   "save graphics state",
+	"Q":   "restore graphics state",
+	"cm":  "concatenate matrix",
+	"w":   "set line width",
+	"J":   "set line cap",
+	"j":   "set line join",
+	"M":   "set miter limit",
+	"d":   "set dash pattern",
+	"ri":  "set rendering intent",
+	"i":   "set flatness",
+	"gs":  "set graphics state parameters",
+	"m":   "move to",
+	"l":   "line to",
+	"c":   "curve to",
+	"v":   "curve to (v)",
+	"y":   "curve to (y)",
+	"h":   "close subpath",
+	"re":  "rectangle",
+	"S":   "stroke",
+	"s":   "close and stroke",
+	"f":   "fill",
+	"F":   "fill",
+	"f*":  "fill (even-odd)",
+	"B":   "fill and stroke",
+	"B*":  "fill and stroke (even-odd)",
+	"b":   "close, fill and stroke",
+	"b*":  "close, fill and stroke (even-odd)",
+	"n":   "end path",
+	"W":   "clip",
+	"W*":  "clip (even-odd)",
+	"BT":  "begin text object",
+	"ET":  "end text object",
+	"Tc":  "set character spacing",
+	"Tw":  "set word spacing",
+	"Tz":  "set horizontal scaling",
+	"TZ":  "set horizontal scaling",
+	"TL":  "set leading",
+	"Tf":  "set font and size",
+	"Tr":  "set rendering mode",
+	"Ts":  "set rise",
+	"Td":  "move text position",
+	"TD":  "move text position and set leading",
+	"Tm":  "set text matrix",
+	"T*":  "move to next line",
+	"Tj":  "show text",
+	"TJ":  "show text with positioning",
+	"'":   "next line and show text",
+	"\"":  "set spacing, next line and show text",
+	"d0":  "set glyph width",
+	"d1":  "set glyph width and bounding box",
+	"CS":  "set stroking color space",
+	"cs":  "set nonstroking color space",
+	"SC":  "set stroking color",
+	"SCN": "set stroking color",
+	"sc":  "set nonstroking color",
+	"scn": "set nonstroking color",
+	"G":   "set stroking gray",
+	"g":   "set nonstroking gray",
+	"RG":  "set stroking RGB color",
+	"rg":  "set nonstroking RGB color",
+	"K":   "set stroking CMYK color",
+	"k":   "set nonstroking CMYK color",
+	"sh":  "paint shading",
+	"BI":  "inline image",
+	"Do":  "paint XObject",
+	"MP":  "marked content point",
+	"DP":  "marked content point with properties",
+	"BMC": "begin marked content",
+	"BDC": "begin marked content with properties",
+	"EMC": "end marked content",
+	"BX":  "begin compatibility section",
+	"EX":  "end compatibility section",
+}
+
+// PrettyString returns a readable listing of the operations, one operation per line, with each operator
+// annotated by a short description.  Binary inline image data is elided and only its size is shown.
+func (this *ContentStreamOperations) PrettyString() string {
+	var buf bytes.Buffer
+
+	for _, op := range *this {
+		if op == nil {
+			continue
+		}
+
+		if op.Operand == "BI" {
+			buf.WriteString("BI")
+			if len(op.Params) > 0 {
+				if im, ok := op.Params[0].(*ContentStreamInlineImage); ok {
+					buf.WriteString(fmt.Sprintf(" <%d bytes of image data elided>", len(im.stream)))
+				}
+			}
+			buf.WriteString(" EI")
+		} else {
+			for _, param := range op.Params {
+				buf.WriteString(param.DefaultWriteString())
+				buf.WriteString(" ")
+			}
+			buf.WriteString(op.Operand)
+		}
+
+		if desc, has := operatorDescriptions[op.Operand]; has {
+			buf.WriteString("\t% " + desc)
+		} else {
+			buf.WriteString("\t% unknown operator")
+		}
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// DecodeStreamToString decodes a content stream object and returns its operations as a readable,
+// annotated listing (see PrettyString).  Intended for diagnosing extraction problems.
+// If parsing fails midway, the operations parsed so far are returned along with the error.
+func DecodeStreamToString(streamObj *PdfObjectStream) (string, error) {
+	data, err := DecodeStream(streamObj)
+	if err != nil && !errors.Is(err, ErrTruncatedStream) && !errors.Is(err, ErrCorruptStream) {
+		return "", err
+	}
+
+	cstreamParser := NewContentStreamParser(string(data))
+	operations, err := cstreamParser.Parse()
+	if operations == nil {
+		return "", err
+	}
+
+	return operations.PrettyString(), err
+}
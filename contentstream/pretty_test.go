@@ -0,0 +1,41 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package contentstream
+
+import (
+	"strings"
+	"testing"
+
+	. "../core"
+)
+
+func TestDecodeStreamToString(t *testing.T) {
+	content := "BT\n/F1 12 Tf\n72 712 Td\n(Hello) Tj\nET\nBI /W 2 /H 2 /BPC 8 /CS /G ID \x00\xff\x10\x20 EI\n"
+	streamObj, err := MakeStream([]byte(content), NewFlateEncoder())
+	if err != nil {
+		t.Fatalf("MakeStream: %v", err)
+	}
+
+	pretty, err := DecodeStreamToString(streamObj)
+	if err != nil {
+		t.Fatalf("DecodeStreamToString: %v", err)
+	}
+
+	pos := 0
+	for _, op := range []string{"BT", "Tf", "Tj", "ET"} {
+		i := strings.Index(pretty[pos:], op)
+		if i < 0 {
+			t.Fatalf("%s missing after offset %d of:\n%s", op, pos, pretty)
+		}
+		pos += i + len(op)
+	}
+	if !strings.Contains(pretty, "/F1 12 Tf\t% set font") {
+		t.Errorf("Tf not annotated:\n%s", pretty)
+	}
+	if !strings.Contains(pretty, "image data elided") || strings.Contains(pretty, "\xff") {
+		t.Errorf("inline image data not elided:\n%s", pretty)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Buffers larger than this are not kept in the pool, so that a single huge stream does not pin its memory.
+const maxPooledBufferSize = 16 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool of scratch buffers used for stream decoding.
+// The buffer must be given back with PutBuffer when done.
+func GetBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// PutBuffer gives back a buffer obtained with GetBuffer.  The buffer and the slices returned by its Bytes
+// method must not be used afterwards, copy the contents out first (see copyBytes).
+func PutBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// copyBytes returns a copy of the buffer contents, which remains valid after the buffer is put back.
+func copyBytes(buf *bytes.Buffer) []byte {
+	out := make([]byte, buf.Len())
+	copy(out, buf.Bytes())
+	return out
+}
@@ -0,0 +1,147 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// flateData returns the Flate data of the text.
+func flateData(text string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(text))
+	w.Close()
+	return buf.Bytes()
+}
+
+// predictorStream returns a FlateDecode stream of the rows of 4 bytes with the PNG None predictor.
+func predictorStream(rows string) *PdfObjectStream {
+	data := ""
+	for i := 0; i < len(rows); i += 4 {
+		data += "\x00" + rows[i:i+4]
+	}
+	stream := flateStream(flateData(data))
+	params := MakeDict()
+	params.Set("Predictor", MakeInteger(12))
+	params.Set("Columns", MakeInteger(4))
+	stream.Set("DecodeParms", params)
+	return stream
+}
+
+func TestPooledBuffersNotShared(t *testing.T) {
+	// the results of the decoders own their bytes, the buffers being reused by the later decodings
+	plain, err := DecodeStream(flateStream(flateData("BT (first) Tj ET")))
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	predicted, err := DecodeStream(predictorStream("abcdefgh"))
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		DecodeStream(flateStream(flateData(fmt.Sprintf("BT (other %d) Tj ET", i))))
+		DecodeStream(predictorStream("ijklmnop"))
+		buf := GetBuffer()
+		buf.WriteString("overwritten")
+		PutBuffer(buf)
+	}
+	if string(plain) != "BT (first) Tj ET" || string(predicted) != "abcdefgh" {
+		t.Errorf("decoded %q and %q", plain, predicted)
+	}
+
+	if buf := GetBuffer(); buf.Len() != 0 {
+		t.Errorf("buffer not reset: %q", buf.Bytes())
+	}
+}
+
+// documentStreams returns 200 Flate content streams of 80 KB.
+func documentStreams() []*PdfObjectStream {
+	streams := []*PdfObjectStream{}
+	for i := 0; i < 200; i++ {
+		line := fmt.Sprintf("BT /F1 12 Tf 72 %d Td (line %d) Tj ET\n", 700-i, i)
+		content := bytes.Repeat([]byte(line), 2000)
+		streams = append(streams, flateStream(flateData(string(content))))
+	}
+	return streams
+}
+
+// decodePooled decodes the streams with the pooled buffers.
+func decodePooled(streams []*PdfObjectStream) error {
+	encoder := NewFlateEncoder()
+	for _, stream := range streams {
+		if _, err := encoder.DecodeBytes(stream.Stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeUnpooled decodes the streams as DecodeBytes does, the header checked by a zlib reader and the data
+// read by a flate reader, with a buffer allocated for each stream as before the pool.
+func decodeUnpooled(streams []*PdfObjectStream) error {
+	for _, stream := range streams {
+		r, err := zlib.NewReader(bytes.NewReader(stream.Stream))
+		if err != nil {
+			return err
+		}
+		r.Close()
+		fr := flate.NewReader(bytes.NewReader(stream.Stream[2:]))
+		var outBuf bytes.Buffer
+		if _, err := outBuf.ReadFrom(fr); err != nil {
+			return err
+		}
+		fr.Close()
+	}
+	return nil
+}
+
+func TestPooledDecodingAllocations(t *testing.T) {
+	streams := documentStreams()
+	// the bytes and number of allocations of a decoding of the document
+	measure := func(decode func([]*PdfObjectStream) error) (uint64, float64) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		allocs := testing.AllocsPerRun(5, func() {
+			if err := decode(streams); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+		})
+		runtime.ReadMemStats(&after)
+		// AllocsPerRun runs the function once more to warm up
+		return (after.TotalAlloc - before.TotalAlloc) / 6, allocs
+	}
+
+	pooledBytes, pooledAllocs := measure(decodePooled)
+	unpooledBytes, unpooledAllocs := measure(decodeUnpooled)
+	if pooledBytes >= unpooledBytes*3/4 || pooledAllocs >= unpooledAllocs {
+		t.Errorf("pooled %d bytes in %v allocations, unpooled %d bytes in %v allocations", pooledBytes,
+			pooledAllocs, unpooledBytes, unpooledAllocs)
+	}
+}
+
+func BenchmarkDecodeStreams(b *testing.B) {
+	streams := documentStreams()
+	for _, bc := range []struct {
+		name   string
+		decode func([]*PdfObjectStream) error
+	}{{"pooled", decodePooled}, {"unpooled", decodeUnpooled}} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				if err := bc.decode(streams); err != nil {
+					b.Fatalf("decode: %v", err)
+				}
+			}
+		})
+	}
+}
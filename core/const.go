@@ -15,4 +15,11 @@ var (
 	ErrNoCCITTFaxDecode              = errors.New("CCITTFaxDecode encoding is not yet implemented")
 	ErrNoJBIG2Decode                 = errors.New("JBIG2Decode encoding is not yet implemented")
 	ErrNoJPXDecode                   = errors.New("JPXDecode encoding is not yet implemented")
+
+	// ErrTruncatedStream error indicates that the encoded data ended prematurely.  It is returned along
+	// with the data decoded so far.
+	ErrTruncatedStream = errors.New("Truncated stream data")
+	// ErrCorruptStream error indicates that the encoded data is invalid past some point.  It is returned
+	// along with the data decoded before it.
+	ErrCorruptStream = errors.New("Corrupt stream data")
 )
@@ -9,7 +9,9 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"../common"
@@ -63,6 +65,21 @@ type ObjectStreams map[int]ObjectStream
 // TODO (v3): Unexport.
 type ObjectCache map[int]PdfObject
 
+// getCachedObject returns the parsed object with the object number from the cache, if any.  Safe for concurrent use.
+func (parser *PdfParser) getCachedObject(objNumber int) (PdfObject, bool) {
+	parser.cacheLock.RLock()
+	defer parser.cacheLock.RUnlock()
+	obj, ok := parser.ObjCache[objNumber]
+	return obj, ok
+}
+
+// cacheObject stores the parsed object with the object number in the cache.  Safe for concurrent use.
+func (parser *PdfParser) cacheObject(objNumber int, obj PdfObject) {
+	parser.cacheLock.Lock()
+	defer parser.cacheLock.Unlock()
+	parser.ObjCache[objNumber] = obj
+}
+
 // Get an object from an object stream.
 func (parser *PdfParser) lookupObjectViaOS(sobjNumber int, objNum int) (PdfObject, error) {
 	var bufReader *bytes.Reader
@@ -71,6 +88,13 @@ func (parser *PdfParser) lookupObjectViaOS(sobjNumber int, objNum int) (PdfObjec
 
 	objstm, cached = parser.objstms[sobjNumber]
 	if !cached {
+		if parser.objstmLoadInProgress[sobjNumber] {
+			common.Log.Debug("ERROR: Object stream %d cycle", sobjNumber)
+			return nil, errors.New("Object stream cycle")
+		}
+		parser.objstmLoadInProgress[sobjNumber] = true
+		defer delete(parser.objstmLoadInProgress, sobjNumber)
+
 		soi, err := parser.LookupByNumber(sobjNumber)
 		if err != nil {
 			common.Log.Debug("Missing object stream with number %d", sobjNumber)
@@ -107,9 +131,13 @@ func (parser *PdfParser) lookupObjectViaOS(sobjNumber int, objNum int) (PdfObjec
 			return nil, errors.New("Invalid First in stream dictionary")
 		}
 
+		if err := parser.checkObjStmExtends(sobjNumber, sod); err != nil {
+			return nil, err
+		}
+
 		common.Log.Trace("type: %s number of objects: %d", name, *N)
 		ds, err := DecodeStream(so)
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrTruncatedStream) && !errors.Is(err, ErrCorruptStream) {
 			return nil, err
 		}
 
@@ -185,6 +213,34 @@ func (parser *PdfParser) lookupObjectViaOS(sobjNumber int, objNum int) (PdfObjec
 	return &io, nil
 }
 
+// Follow the /Extends chain of the object stream sobjNumber with dictionary sod, returns an error if it
+// loops back on an object stream of the chain.
+func (parser *PdfParser) checkObjStmExtends(sobjNumber int, sod *PdfObjectDictionary) error {
+	visited := map[int64]bool{int64(sobjNumber): true}
+	for sod != nil {
+		extendsRef, ok := sod.Get("Extends").(*PdfObjectReference)
+		if !ok {
+			return nil
+		}
+		if visited[extendsRef.ObjectNumber] {
+			common.Log.Debug("ERROR: Object stream %d Extends cycle at %d", sobjNumber, extendsRef.ObjectNumber)
+			return errors.New("Object stream Extends cycle")
+		}
+		visited[extendsRef.ObjectNumber] = true
+
+		extendsObj, err := parser.LookupByReference(*extendsRef)
+		if err != nil {
+			return err
+		}
+		sod = nil
+		if extends, ok := extendsObj.(*PdfObjectStream); ok {
+			sod = extends.PdfObjectDictionary
+		}
+	}
+
+	return nil
+}
+
 // LookupByNumber looks up a PdfObject by object number.  Returns an error on failure.
 // TODO (v3): Unexport.
 func (parser *PdfParser) LookupByNumber(objNumber int) (PdfObject, error) {
@@ -225,8 +281,10 @@ func getObjectNumber(obj PdfObject) (int64, int64, error) {
 
 // LookupByNumber
 // Repair signals whether to repair if broken.
+// Cached objects are shared by concurrent lookups, an object not cached yet is parsed from the file reader of
+// the parser, which is not synchronized.
 func (parser *PdfParser) lookupByNumber(objNumber int, attemptRepairs bool) (PdfObject, bool, error) {
-	obj, ok := parser.ObjCache[objNumber]
+	obj, ok := parser.getCachedObject(objNumber)
 	if ok {
 		common.Log.Trace("Returning cached object %d", objNumber)
 		return obj, false, nil
@@ -294,7 +352,7 @@ func (parser *PdfParser) lookupByNumber(objNumber int, attemptRepairs bool) (Pdf
 		*/
 
 		common.Log.Trace("Returning obj")
-		parser.ObjCache[objNumber] = obj
+		parser.cacheObject(objNumber, obj)
 		return obj, false, nil
 	} else if xref.xtype == XREF_OBJECT_STREAM {
 		common.Log.Trace("xref from object stream!")
@@ -313,7 +371,7 @@ func (parser *PdfParser) lookupByNumber(objNumber int, attemptRepairs bool) (Pdf
 				return nil, true, err
 			}
 			common.Log.Trace("<Loaded via OS")
-			parser.ObjCache[objNumber] = optr
+			parser.cacheObject(objNumber, optr)
 
 			if parser.crypter != nil {
 				// Mark as decrypted (inside object stream) for caching.
@@ -372,3 +430,35 @@ func printXrefTable(xrefTable XrefTable) {
 		i++
 	}
 }
+
+// DumpXref returns a listing of the cross reference entries loaded, one per line sorted by object number, followed
+// by the trailer dictionary, for debugging.  Uncompressed objects are listed with their file offset, compressed
+// objects with the number of their object stream and their index in it.  Free entries are not retained by the
+// parser and are not listed.
+func (parser *PdfParser) DumpXref() string {
+	objectNumbers := make([]int, 0, len(parser.xrefs))
+	for objectNumber := range parser.xrefs {
+		objectNumbers = append(objectNumbers, objectNumber)
+	}
+	sort.Ints(objectNumbers)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "xref: %d entries\n", len(objectNumbers))
+	for _, objectNumber := range objectNumbers {
+		xref := parser.xrefs[objectNumber]
+		switch xref.xtype {
+		case XREF_TABLE_ENTRY:
+			fmt.Fprintf(&b, "%d %d uncompressed offset %d\n", objectNumber, xref.generation, xref.offset)
+		case XREF_OBJECT_STREAM:
+			fmt.Fprintf(&b, "%d %d compressed objstm %d index %d\n", objectNumber, xref.generation,
+				xref.osObjNumber, xref.osObjIndex)
+		}
+	}
+
+	if parser.trailerDict != nil {
+		fmt.Fprintf(&b, "trailer: %s\n", parser.trailerDict.DefaultWriteString())
+	} else {
+		b.WriteString("trailer: missing\n")
+	}
+	return b.String()
+}
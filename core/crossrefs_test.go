@@ -0,0 +1,193 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// objStmPdf returns a PDF file of a cross reference stream, object 4, with the catalog and the pages
+// compressed in the object stream 3.
+func objStmPdf() []byte {
+	objects := "<< /Type /Catalog /Pages 2 0 R >> << /Type /Pages /Kids [] /Count 0 >>"
+	header := fmt.Sprintf("1 0 2 %d ", strings.Index(objects, " << ")+1)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	objStm := buf.Len()
+	fmt.Fprintf(&buf, "3 0 obj\n<< /Type /ObjStm /N 2 /First %d /Length %d >>\nstream\n%s%s\nendstream\nendobj\n",
+		len(header), len(header+objects), header, objects)
+	xref := buf.Len()
+	entries := []byte{0, 0, 0, 0, 2, 0, 3, 0, 2, 0, 3, 1, 1, byte(objStm >> 8), byte(objStm), 0, 1,
+		byte(xref >> 8), byte(xref), 0}
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /XRef /Size 5 /W [1 2 1] /Root 1 0 R /Length %d >>\nstream\n",
+		len(entries))
+	buf.Write(entries)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xref)
+	return buf.Bytes()
+}
+
+func TestDumpXref(t *testing.T) {
+	pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")
+	dump := openPdf(t, pdf).DumpXref()
+	expected := fmt.Sprintf("xref: 2 entries\n1 0 uncompressed offset %d\n2 0 uncompressed offset %d\ntrailer: ",
+		bytes.Index(pdf, []byte("1 0 obj")), bytes.Index(pdf, []byte("2 0 obj")))
+	if !strings.HasPrefix(dump, expected) || !strings.Contains(dump, "/Root 1 0 R") {
+		t.Errorf("got:\n%s", dump)
+	}
+
+	pdf = objStmPdf()
+	dump = openPdf(t, pdf).DumpXref()
+	for _, entry := range []string{
+		"xref: 4 entries\n",
+		"\n1 0 compressed objstm 3 index 0\n2 0 compressed objstm 3 index 1\n",
+		fmt.Sprintf("\n3 0 uncompressed offset %d\n", bytes.Index(pdf, []byte("3 0 obj"))),
+		fmt.Sprintf("\n4 0 uncompressed offset %d\n", bytes.Index(pdf, []byte("4 0 obj"))),
+	} {
+		if !strings.Contains(dump, entry) {
+			t.Errorf("%q missing from:\n%s", entry, dump)
+		}
+	}
+}
+
+func TestConcurrentCachedLookups(t *testing.T) {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"}
+	for i := 3; i <= 10; i++ {
+		objects = append(objects, fmt.Sprintf("<< /N %d >>", i))
+	}
+	parser := openPdf(t, makePdf("", objects...))
+	// objects not cached yet are parsed from the shared file reader, which is not synchronized
+	for i := 1; i <= len(objects); i++ {
+		if _, err := parser.LookupByNumber(i); err != nil {
+			t.Fatalf("LookupByNumber(%d): %v", i, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				// the catalog is shared by all, the other objects mostly distinct
+				for _, i := range []int{1, 3 + (g+n)%8} {
+					obj, err := parser.LookupByReference(PdfObjectReference{ObjectNumber: int64(i)})
+					if err != nil {
+						errs <- err
+						return
+					}
+					if _, ok := obj.(*PdfIndirectObject); !ok {
+						errs <- fmt.Errorf("object %d is %T", i, obj)
+						return
+					}
+				}
+				parser.cacheObject(100+g, MakeNull())
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestObjStmCycles(t *testing.T) {
+	objects := "<< /N 5 >>"
+	header := "5 0 "
+	objStm := func(extends string) string {
+		return fmt.Sprintf("<< /Type /ObjStm /N 1 /First %d /Length %d %s >>\nstream\n%s%s\nendstream", len(header),
+			len(header+objects), extends, header, objects)
+	}
+	testcases := []struct {
+		name    string
+		objects []string
+		cycle   bool
+	}{
+		{"Extends", []string{objStm("/Extends 4 0 R"), objStm("")}, false},
+		{"Extends cycle", []string{objStm("/Extends 4 0 R"), objStm("/Extends 3 0 R")}, true},
+		{"Extends itself", []string{objStm("/Extends 3 0 R"), objStm("")}, true},
+		// the object stream 3 stored in the object stream 4, itself stored in 3
+		{"stored in itself", []string{objStm(""), objStm("")}, true},
+	}
+
+	for _, tc := range testcases {
+		parser := openPdf(t, makePdf("", append([]string{"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [] /Count 0 >>"}, tc.objects...)...))
+		parser.xrefs[5] = XrefObject{xtype: XREF_OBJECT_STREAM, objectNumber: 5, osObjNumber: 3}
+		if tc.name == "stored in itself" {
+			parser.xrefs[3] = XrefObject{xtype: XREF_OBJECT_STREAM, objectNumber: 3, osObjNumber: 4}
+			parser.xrefs[4] = XrefObject{xtype: XREF_OBJECT_STREAM, objectNumber: 4, osObjNumber: 3}
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := parser.LookupByNumber(5)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if (err != nil) != tc.cycle {
+				t.Errorf("%s: err: %v", tc.name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: lookup not returning", tc.name)
+		}
+	}
+}
+
+func TestXrefStreamWideField(t *testing.T) {
+	for _, width := range []int{9, 8} {
+		// a free entry for the object 0
+		data := strings.Repeat("\x00", 1+width+1)
+		obj, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Type /XRef /Size 1 /W [1 %d 1] /Length %d >>\n"+
+			"stream\n%s\nendstream\nendobj\n", width, len(data), data)).ParseIndirectObject()
+		if err != nil {
+			t.Fatalf("W [1 %d 1]: %v", width, err)
+		}
+		err = openPdf(t, objStmPdf()).readXrefStream(obj.(*PdfObjectStream))
+		if (err != nil) != (width > 8) {
+			t.Errorf("W [1 %d 1]: err: %v", width, err)
+		}
+	}
+}
+
+func TestIncrementalFreedObject(t *testing.T) {
+	pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>", "(three)",
+		"(four)", "(five)")
+	prev := bytes.Index(pdf, []byte("\nxref\n")) + 1
+
+	// the update frees object 5 and redefines object 4
+	var buf bytes.Buffer
+	buf.Write(pdf)
+	offset := buf.Len()
+	buf.WriteString("4 1 obj\n(new four)\nendobj\n")
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 1\n0000000005 65535 f \n4 2\n%010d 00001 n \n0000000000 00001 f \n", offset)
+	fmt.Fprintf(&buf, "trailer\n<< /Size 6 /Root 1 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", prev, xref)
+	parser := openPdf(t, buf.Bytes())
+
+	expected := map[int]string{3: "three", 4: "new four", 5: "null"}
+	for objNum, text := range expected {
+		obj, err := parser.LookupByNumber(objNum)
+		if err != nil {
+			t.Errorf("object %d: %v", objNum, err)
+			continue
+		}
+		if indirect, ok := obj.(*PdfIndirectObject); ok {
+			obj = indirect.PdfObject
+		}
+		if obj == nil || obj.String() != text {
+			t.Errorf("object %d: %v, expected %s", objNum, obj, text)
+		}
+	}
+}
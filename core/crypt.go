@@ -220,7 +220,9 @@ func PdfCryptMakeNew(parser *PdfParser, ed, trailer *PdfObjectDictionary) (PdfCr
 		common.Log.Debug("Using subfilter %s", subfilter)
 	}
 
-	if L, ok := ed.Get("Length").(*PdfObjectInteger); ok {
+	// The key of the V 1 algorithm is 40 bits, Length only applies to the later ones.
+	V, hasV := ed.Get("V").(*PdfObjectInteger)
+	if L, ok := ed.Get("Length").(*PdfObjectInteger); ok && !(hasV && *V == 1) {
 		if (*L % 8) != 0 {
 			common.Log.Debug("ERROR Invalid encryption length")
 			return crypter, errors.New("Invalid encryption length")
@@ -230,8 +232,7 @@ func PdfCryptMakeNew(parser *PdfParser, ed, trailer *PdfObjectDictionary) (PdfCr
 		crypter.Length = 40
 	}
 
-	V, ok := ed.Get("V").(*PdfObjectInteger)
-	if ok {
+	if hasV {
 		if *V >= 1 && *V <= 2 {
 			crypter.V = int(*V)
 			// Default algorithm is V2.
@@ -296,15 +297,29 @@ func PdfCryptMakeNew(parser *PdfParser, ed, trailer *PdfObjectDictionary) (PdfCr
 	// Default: empty ID.
 	// Strictly, if file is encrypted, the ID should always be specified
 	// but clearly not everyone is following the specification.
+	// The ID may be missing from the trailer holding the Encrypt entry but present in another
+	// trailer of the Prev chain.
 	id0 := PdfObjectString("")
-	if idArray, ok := trailer.Get("ID").(*PdfObjectArray); ok && len(*idArray) >= 1 {
-		id0obj, ok := (*idArray)[0].(*PdfObjectString)
+	idObj := trailer.Get("ID")
+	if idObj == nil {
+		idObj = parser.trailerID
+	}
+	idObj, err := parser.Trace(idObj)
+	if err != nil {
+		return crypter, err
+	}
+	if idArray, ok := idObj.(*PdfObjectArray); ok && len(*idArray) >= 1 {
+		id0traced, err := parser.Trace((*idArray)[0])
+		if err != nil {
+			return crypter, err
+		}
+		id0obj, ok := id0traced.(*PdfObjectString)
 		if !ok {
 			return crypter, errors.New("Invalid trailer ID")
 		}
 		id0 = *id0obj
 	} else {
-		common.Log.Debug("Trailer ID array missing or invalid!")
+		common.Log.Debug("Trailer ID array missing or invalid, using an empty ID")
 	}
 	crypter.Id0 = string(id0)
 
@@ -643,7 +658,6 @@ func (crypt *PdfCrypt) Decrypt(obj PdfObject, parentObjNum, parentGenNum int64)
 		genNum := (*so).GenerationNumber
 		common.Log.Trace("Decrypting stream %d %d !", objNum, genNum)
 
-		// TODO: Check for crypt filter (V4).
 		// The Crypt filter shall be the first filter in the Filter array entry.
 
 		dict := so.PdfObjectDictionary
@@ -653,22 +667,29 @@ func (crypt *PdfCrypt) Decrypt(obj PdfObject, parentObjNum, parentGenNum int64)
 			streamFilter = crypt.StreamFilter
 			common.Log.Trace("this.StreamFilter = %s", crypt.StreamFilter)
 
-			if filters, ok := dict.Get("Filter").(*PdfObjectArray); ok {
-				// Crypt filter can only be the first entry.
-				if firstFilter, ok := (*filters)[0].(*PdfObjectName); ok {
-					if *firstFilter == "Crypt" {
-						// Crypt filter overriding the default.
-						// Default option is Identity.
-						streamFilter = "Identity"
+			// Crypt filter can only be the first entry, its decode params are the first ones too.
+			firstFilter := TraceToDirectObject(dict.Get("Filter"))
+			firstParams := TraceToDirectObject(dict.Get("DecodeParms"))
+			if filters, ok := firstFilter.(*PdfObjectArray); ok && len(*filters) > 0 {
+				firstFilter = TraceToDirectObject((*filters)[0])
+				if params, ok := firstParams.(*PdfObjectArray); ok && len(*params) > 0 {
+					firstParams = TraceToDirectObject((*params)[0])
+				}
+			}
 
-						// Check if valid crypt filter specified in the decode params.
-						if decodeParams, ok := dict.Get("DecodeParms").(*PdfObjectDictionary); ok {
-							if filterName, ok := decodeParams.Get("Name").(*PdfObjectName); ok {
-								if _, ok := crypt.CryptFilters[string(*filterName)]; ok {
-									common.Log.Trace("Using stream filter %s", *filterName)
-									streamFilter = string(*filterName)
-								}
-							}
+			if filterName, ok := firstFilter.(*PdfObjectName); ok && *filterName == StreamEncodingFilterNameCrypt {
+				// Crypt filter overriding the default.
+				// Default option is Identity.
+				streamFilter = "Identity"
+
+				// Check if valid crypt filter specified in the decode params.
+				if decodeParams, ok := firstParams.(*PdfObjectDictionary); ok {
+					if filterName, ok := decodeParams.Get("Name").(*PdfObjectName); ok {
+						if _, ok := crypt.CryptFilters[string(*filterName)]; ok {
+							common.Log.Trace("Using stream filter %s", *filterName)
+							streamFilter = string(*filterName)
+						} else {
+							common.Log.Debug("Crypt filter %s not in CF dictionary, using Identity", *filterName)
 						}
 					}
 				}
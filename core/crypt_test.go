@@ -0,0 +1,157 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// cryptStream returns the stream object 1 0 of the data with the /Filter and /DecodeParms entries.
+func cryptStream(t *testing.T, data []byte, filter, decodeParms string) *PdfObjectStream {
+	obj, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Length %d /Filter %s /DecodeParms %s >>\nstream\n%s\n"+
+		"endstream\nendobj\n", len(data), filter, decodeParms, data)).ParseIndirectObject()
+	if err != nil {
+		t.Fatalf("ParseIndirectObject: %v", err)
+	}
+	stream, ok := obj.(*PdfObjectStream)
+	if !ok {
+		t.Fatalf("got %T", obj)
+	}
+	return stream
+}
+
+func TestCryptFilter(t *testing.T) {
+	text := "BT /F1 12 Tf (Hello) Tj ET"
+	encoded, err := NewFlateEncoder().EncodeBytes([]byte(text))
+	if err != nil {
+		t.Fatalf("EncodeBytes: %v", err)
+	}
+
+	// RC4 crypt filters, the streams being left unencrypted by default
+	crypt := &PdfCrypt{
+		V:                4,
+		EncryptionKey:    []byte{1, 2, 3, 4, 5},
+		CryptFilters:     CryptFilters{"StdCF": {Cfm: "V2", Length: 5}},
+		StreamFilter:     "Identity",
+		DecryptedObjects: map[PdfObject]bool{},
+		EncryptedObjects: map[PdfObject]bool{},
+	}
+	okey, err := crypt.makeKey("StdCF", 1, 0, crypt.EncryptionKey)
+	if err != nil {
+		t.Fatalf("makeKey: %v", err)
+	}
+	encrypted, err := crypt.encryptBytes(append([]byte(nil), encoded...), "StdCF", okey)
+	if err != nil {
+		t.Fatalf("encryptBytes: %v", err)
+	}
+
+	testcases := []struct {
+		name, decodeParms string
+		data              []byte
+	}{
+		{"Identity", "[<< /Name /Identity >> null]", encoded},
+		{"named", "[<< /Name /StdCF >> null]", encrypted},
+	}
+	for _, tc := range testcases {
+		for _, streamFilter := range []string{"Identity", "StdCF"} {
+			crypt.StreamFilter = streamFilter
+			stream := cryptStream(t, tc.data, "[/Crypt /FlateDecode]", tc.decodeParms)
+			if err := crypt.Decrypt(stream, 1, 0); err != nil {
+				t.Errorf("%s, %s by default: Decrypt: %v", tc.name, streamFilter, err)
+				continue
+			}
+			// the Crypt filter is skipped, the stream being decrypted when loaded
+			decoded, filters, err := DecodeStreamEx(stream)
+			if err != nil || string(decoded) != text {
+				t.Errorf("%s, %s by default: decoded %q, err: %v", tc.name, streamFilter, decoded, err)
+			}
+			if len(filters) != 1 || filters[0] != StreamEncodingFilterNameFlate {
+				t.Errorf("%s: filters %v", tc.name, filters)
+			}
+		}
+	}
+}
+
+// encryptedObjects returns the objects of a file of the standard security handler, revision 2 with an empty
+// user password, for the ID: the catalog, the pages, the string (Secret) encrypted and the encryption
+// dictionary.
+func encryptedObjects(t *testing.T, id0 string) []string {
+	crypt := &PdfCrypt{V: 1, R: 2, Length: 40, P: -4, Id0: id0, EncryptMetadata: true,
+		CryptFilters: CryptFilters{"Default": {Cfm: "V2", Length: 40}}}
+	o, err := crypt.Alg3([]byte(""), []byte("owner"))
+	if err != nil {
+		t.Fatalf("Alg3: %v", err)
+	}
+	crypt.O = []byte(o)
+	u, key, err := crypt.Alg4([]byte(""))
+	if err != nil {
+		t.Fatalf("Alg4: %v", err)
+	}
+	okey, err := crypt.makeKey("Default", 3, 0, key)
+	if err != nil {
+		t.Fatalf("makeKey: %v", err)
+	}
+	secret, err := crypt.encryptBytes([]byte("Secret"), "Default", okey)
+	if err != nil {
+		t.Fatalf("encryptBytes: %v", err)
+	}
+
+	return []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		fmt.Sprintf("<%X>", secret),
+		fmt.Sprintf("<< /Filter /Standard /V 1 /R 2 /O <%X> /U <%X> /P -4 >>", string(o), string(u)),
+	}
+}
+
+// decryptedSecret returns the string object 3 of the encrypted file, decrypted with the empty password.
+func decryptedSecret(t *testing.T, pdf []byte) string {
+	parser := openPdf(t, pdf)
+	if encrypted, err := parser.IsEncrypted(); err != nil || !encrypted {
+		t.Fatalf("IsEncrypted: %t, err: %v", encrypted, err)
+	}
+	if ok, err := parser.Decrypt([]byte("")); err != nil || !ok {
+		t.Fatalf("Decrypt: %t, err: %v", ok, err)
+	}
+	obj, err := parser.LookupByNumber(3)
+	if err != nil {
+		t.Fatalf("LookupByNumber: %v", err)
+	}
+	indirect, ok := obj.(*PdfIndirectObject)
+	if !ok {
+		t.Fatalf("got %T", obj)
+	}
+	str, ok := indirect.PdfObject.(*PdfObjectString)
+	if !ok {
+		t.Fatalf("object is %T", indirect.PdfObject)
+	}
+	return string(*str)
+}
+
+func TestDecryptWithoutID(t *testing.T) {
+	pdf := makePdf("/Encrypt 4 0 R", encryptedObjects(t, "")...)
+	if secret := decryptedSecret(t, pdf); secret != "Secret" {
+		t.Errorf("no ID: decrypted %q", secret)
+	}
+
+	// the ID is only in the trailer of the previous section, the update rewriting the catalog and adding the
+	// Encrypt entry
+	id := "0123456789abcdef"
+	pdf = makePdf(fmt.Sprintf("/ID [<%X> <%X>]", id, id), encryptedObjects(t, id)...)
+	startxref := bytes.LastIndex(pdf, []byte("startxref\n")) + len("startxref\n")
+	var prev int
+	fmt.Sscanf(string(pdf[startxref:]), "%d", &prev)
+	catalog := len(pdf)
+	pdf = append(pdf, "1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n"...)
+	update := len(pdf)
+	pdf = append(pdf, fmt.Sprintf("xref\n0 2\n0000000000 65535 f \n%010d 00000 n \ntrailer\n"+
+		"<< /Size 5 /Root 1 0 R /Encrypt 4 0 R /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", catalog, prev, update)...)
+	if secret := decryptedSecret(t, pdf); secret != "Secret" {
+		t.Errorf("ID of the previous trailer: decrypted %q", secret)
+	}
+}
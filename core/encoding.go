@@ -19,10 +19,13 @@ package core
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/zlib"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash/adler32"
 	goimage "image"
 	gocolor "image/color"
 	"image/jpeg"
@@ -47,6 +50,7 @@ const (
 	StreamEncodingFilterNameJBIG2     = "JBIG2Decode"
 	StreamEncodingFilterNameJPX       = "JPXDecode"
 	StreamEncodingFilterNameRaw       = "Raw"
+	StreamEncodingFilterNameCrypt     = "Crypt"
 )
 
 const (
@@ -225,29 +229,67 @@ func newFlateEncoderFromStream(streamObj *PdfObjectStream, decodeParams *PdfObje
 	return encoder, nil
 }
 
+// DecodeBytes decodes the Flate data.  If the data is truncated, the bytes decoded so far are returned
+// with ErrTruncatedStream, and if it is corrupt with ErrCorruptStream.  Decoding ends with the last block of
+// the compressed data: the Adler-32 checksum is only verified for logging, and bytes following it, such as
+// an end of line counted in the stream Length, are ignored.
 func (this *FlateEncoder) DecodeBytes(encoded []byte) ([]byte, error) {
 	common.Log.Trace("FlateDecode bytes")
 
-	bufReader := bytes.NewReader(encoded)
-	r, err := zlib.NewReader(bufReader)
+	if len(bytes.TrimSpace(encoded)) == 0 {
+		return []byte{}, nil
+	}
+
+	// The zlib reader validates the header, the compressed data following it is read with a flate reader
+	// which stops at its end.
+	r, err := zlib.NewReader(bytes.NewReader(encoded))
 	if err != nil {
 		common.Log.Debug("Decoding error %v\n", err)
 		common.Log.Debug("Stream (%d) % x", len(encoded), encoded)
+		if err == io.ErrUnexpectedEOF {
+			return []byte{}, ErrTruncatedStream
+		}
 		return nil, err
 	}
-	defer r.Close()
+	r.Close()
+
+	bufReader := bytes.NewReader(encoded[2:])
+	fr := flate.NewReader(bufReader)
+	defer fr.Close()
+
+	outBuf := GetBuffer()
+	defer PutBuffer(outBuf)
+	if _, err := outBuf.ReadFrom(fr); err == io.ErrUnexpectedEOF {
+		common.Log.Debug("Flate data truncated, decoded %d bytes", outBuf.Len())
+		return copyBytes(outBuf), ErrTruncatedStream
+	} else if err != nil {
+		common.Log.Debug("Flate data corrupt, decoded %d bytes, err: %v", outBuf.Len(), err)
+		return copyBytes(outBuf), ErrCorruptStream
+	}
 
-	var outBuf bytes.Buffer
-	outBuf.ReadFrom(r)
+	// bufReader is a ByteReader, so the flate reader hasn't read past the compressed data
+	trailer := encoded[len(encoded)-bufReader.Len():]
+	if len(trailer) < 4 {
+		common.Log.Debug("Flate data checksum missing")
+	} else {
+		if binary.BigEndian.Uint32(trailer) != adler32.Checksum(outBuf.Bytes()) {
+			common.Log.Debug("Flate data checksum mismatch")
+		}
+		if extra := len(bytes.TrimSpace(trailer[4:])); extra > 0 {
+			common.Log.Debug("Ignoring %d bytes after the Flate data", extra)
+		}
+	}
 
 	common.Log.Trace("En: % x\n", encoded)
 	common.Log.Trace("De: % x\n", outBuf.Bytes())
 
-	return outBuf.Bytes(), nil
+	return copyBytes(outBuf), nil
 }
 
 // Decode a FlateEncoded stream object and give back decoded bytes.
-func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error) {
+// If the data is truncated or corrupt, the bytes decoded so far are returned with ErrTruncatedStream or
+// ErrCorruptStream.
+func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) (decoded []byte, err error) {
 	// TODO: Handle more filter bytes and support more values of BitsPerComponent.
 
 	common.Log.Trace("FlateDecode stream")
@@ -257,9 +299,20 @@ func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, erro
 	}
 
 	outData, err := this.DecodeBytes(streamObj.Stream)
-	if err != nil {
+	if err == ErrTruncatedStream || err == ErrCorruptStream {
+		outData = truncateToRows(outData, this.Predictor, this.Columns, this.Colors)
+		decodeErr := err
+		defer func() {
+			if err == nil {
+				err = decodeErr
+			}
+		}()
+	} else if err != nil {
 		return nil, err
 	}
+	if len(outData) == 0 {
+		return []byte{}, nil
+	}
 	common.Log.Trace("En: % x\n", streamObj.Stream)
 	common.Log.Trace("De: % x\n", outData)
 
@@ -287,7 +340,8 @@ func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, erro
 			}
 			common.Log.Trace("inp outData (%d): % x", len(outData), outData)
 
-			pOutBuffer := bytes.NewBuffer(nil)
+			pOutBuffer := GetBuffer()
+			defer PutBuffer(pOutBuffer)
 
 			// 0-255  -255 255 ; 0-255=-255;
 			for i := 0; i < rows; i++ {
@@ -299,7 +353,7 @@ func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, erro
 				}
 				pOutBuffer.Write(rowData)
 			}
-			pOutData := pOutBuffer.Bytes()
+			pOutData := copyBytes(pOutBuffer)
 			common.Log.Trace("POutData (%d): % x", len(pOutData), pOutData)
 			return pOutData, nil
 		} else if this.Predictor >= 10 && this.Predictor <= 15 {
@@ -316,7 +370,8 @@ func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, erro
 				return nil, errors.New("Range check error")
 			}
 
-			pOutBuffer := bytes.NewBuffer(nil)
+			pOutBuffer := GetBuffer()
+			defer PutBuffer(pOutBuffer)
 
 			common.Log.Trace("Predictor columns: %d", this.Columns)
 			common.Log.Trace("Length: %d / %d = %d rows", len(outData), rowLength, rows)
@@ -387,7 +442,7 @@ func (this *FlateEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, erro
 				}
 				pOutBuffer.Write(rowData[1:])
 			}
-			pOutData := pOutBuffer.Bytes()
+			pOutData := copyBytes(pOutBuffer)
 			return pOutData, nil
 		} else {
 			common.Log.Debug("ERROR: Unsupported predictor (%d)", this.Predictor)
@@ -623,8 +678,30 @@ func newLZWEncoderFromStream(streamObj *PdfObjectStream, decodeParams *PdfObject
 	return encoder, nil
 }
 
+// truncateToRows cuts the data of a truncated stream to complete rows, as predictors decode whole rows.
+func truncateToRows(data []byte, predictor int, columns int, colors int) []byte {
+	rowLength := 0
+	if predictor == 2 {
+		rowLength = columns * colors
+	} else if predictor >= 10 && predictor <= 15 {
+		rowLength = columns*colors + 1
+	}
+
+	if rowLength > 0 {
+		return data[:len(data)/rowLength*rowLength]
+	}
+	return data
+}
+
+// DecodeBytes decodes the LZW data.  If the data is truncated, the bytes decoded so far are returned
+// with ErrTruncatedStream.
 func (this *LZWEncoder) DecodeBytes(encoded []byte) ([]byte, error) {
-	var outBuf bytes.Buffer
+	if len(bytes.TrimSpace(encoded)) == 0 {
+		return []byte{}, nil
+	}
+
+	outBuf := GetBuffer()
+	defer PutBuffer(outBuf)
 	bufReader := bytes.NewReader(encoded)
 
 	var r io.ReadCloser
@@ -638,14 +715,18 @@ func (this *LZWEncoder) DecodeBytes(encoded []byte) ([]byte, error) {
 	defer r.Close()
 
 	_, err := outBuf.ReadFrom(r)
-	if err != nil {
+	if err == io.ErrUnexpectedEOF {
+		common.Log.Debug("LZW data truncated, decoded %d bytes", outBuf.Len())
+		return copyBytes(outBuf), ErrTruncatedStream
+	} else if err != nil {
 		return nil, err
 	}
 
-	return outBuf.Bytes(), nil
+	return copyBytes(outBuf), nil
 }
 
-func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error) {
+// If the data is truncated, the bytes decoded so far are returned with ErrTruncatedStream.
+func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) (decoded []byte, err error) {
 	// Revamp this support to handle TIFF predictor (2).
 	// Also handle more filter bytes and check
 	// BitsPerComponent.  Default value is 8, currently we are only
@@ -655,9 +736,19 @@ func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error)
 	common.Log.Trace("Predictor: %d", this.Predictor)
 
 	outData, err := this.DecodeBytes(streamObj.Stream)
-	if err != nil {
+	if err == ErrTruncatedStream {
+		outData = truncateToRows(outData, this.Predictor, this.Columns, this.Colors)
+		defer func() {
+			if err == nil {
+				err = ErrTruncatedStream
+			}
+		}()
+	} else if err != nil {
 		return nil, err
 	}
+	if len(outData) == 0 {
+		return []byte{}, nil
+	}
 
 	common.Log.Trace(" IN: (%d) % x", len(streamObj.Stream), streamObj.Stream)
 	common.Log.Trace("OUT: (%d) % x", len(outData), outData)
@@ -688,7 +779,8 @@ func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error)
 			}
 			common.Log.Trace("inp outData (%d): % x", len(outData), outData)
 
-			pOutBuffer := bytes.NewBuffer(nil)
+			pOutBuffer := GetBuffer()
+			defer PutBuffer(pOutBuffer)
 
 			// 0-255  -255 255 ; 0-255=-255;
 			for i := 0; i < rows; i++ {
@@ -702,7 +794,7 @@ func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error)
 
 				pOutBuffer.Write(rowData)
 			}
-			pOutData := pOutBuffer.Bytes()
+			pOutData := copyBytes(pOutBuffer)
 			common.Log.Trace("POutData (%d): % x", len(pOutData), pOutData)
 			return pOutData, nil
 		} else if this.Predictor >= 10 && this.Predictor <= 15 {
@@ -723,7 +815,8 @@ func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error)
 				return nil, errors.New("Range check error")
 			}
 
-			pOutBuffer := bytes.NewBuffer(nil)
+			pOutBuffer := GetBuffer()
+			defer PutBuffer(pOutBuffer)
 
 			common.Log.Trace("Predictor columns: %d", this.Columns)
 			common.Log.Trace("Length: %d / %d = %d rows", len(outData), rowLength, rows)
@@ -759,7 +852,7 @@ func (this *LZWEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error)
 				}
 				pOutBuffer.Write(rowData[1:])
 			}
-			pOutData := pOutBuffer.Bytes()
+			pOutData := copyBytes(pOutBuffer)
 			return pOutData, nil
 		} else {
 			common.Log.Debug("ERROR: Unsupported predictor (%d)", this.Predictor)
@@ -1562,14 +1655,49 @@ func (this *CCITTFaxEncoder) EncodeBytes(data []byte) ([]byte, error) {
 }
 
 //
-// JBIG2 encoder/decoder (dummy, for now)
+// JBIG2 encoder/decoder, decoding generic regions only
 //
-type JBIG2Encoder struct{}
+type JBIG2Encoder struct {
+	// Decoded data of the JBIG2Globals stream, the segments shared by the pages.
+	Globals []byte
+}
 
 func NewJBIG2Encoder() *JBIG2Encoder {
 	return &JBIG2Encoder{}
 }
 
+// Create a new JBIG2 decoder from a stream object, getting the JBIG2Globals stream from the DecodeParms
+// stream object dictionary entry, or from decodeParams if provided.  The globals stream must have been
+// resolved, a reference to it is ignored.
+func newJBIG2EncoderFromStream(streamObj *PdfObjectStream, decodeParams *PdfObjectDictionary) (*JBIG2Encoder, error) {
+	encoder := NewJBIG2Encoder()
+
+	if decodeParams == nil {
+		obj := TraceToDirectObject(streamObj.PdfObjectDictionary.Get("DecodeParms"))
+		if arr, isArr := obj.(*PdfObjectArray); isArr && len(*arr) == 1 {
+			obj = TraceToDirectObject((*arr)[0])
+		}
+		decodeParams, _ = obj.(*PdfObjectDictionary)
+	}
+	if decodeParams == nil {
+		return encoder, nil
+	}
+
+	switch globals := TraceToDirectObject(decodeParams.Get("JBIG2Globals")).(type) {
+	case nil:
+	case *PdfObjectStream:
+		data, err := DecodeStream(globals)
+		if err != nil {
+			return nil, err
+		}
+		encoder.Globals = data
+	default:
+		common.Log.Debug("Error: JBIG2Globals not a stream (%T), ignored", globals)
+	}
+
+	return encoder, nil
+}
+
 func (this *JBIG2Encoder) GetFilterName() string {
 	return StreamEncodingFilterNameJBIG2
 }
@@ -1583,14 +1711,19 @@ func (this *JBIG2Encoder) MakeStreamDict() *PdfObjectDictionary {
 	return MakeDict()
 }
 
+// DecodeBytes decodes the generic regions of the first page of the JBIG2 embedded stream to 1 bit per
+// pixel, 0 for black, rows padded to a byte.
 func (this *JBIG2Encoder) DecodeBytes(encoded []byte) ([]byte, error) {
-	common.Log.Debug("Error: Attempting to use unsupported encoding %s", this.GetFilterName())
-	return encoded, ErrNoJBIG2Decode
+	decoded, _, _, err := decodeJBIG2(this.Globals, encoded)
+	if err != nil {
+		common.Log.Debug("Error: JBIG2 decoding failed: %v", err)
+		return encoded, err
+	}
+	return decoded, nil
 }
 
 func (this *JBIG2Encoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error) {
-	common.Log.Debug("Error: Attempting to use unsupported encoding %s", this.GetFilterName())
-	return streamObj.Stream, ErrNoJBIG2Decode
+	return this.DecodeBytes(streamObj.Stream)
 }
 
 func (this *JBIG2Encoder) EncodeBytes(data []byte) ([]byte, error) {
@@ -1663,7 +1796,7 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 	// Optional, not always present.
 	var decodeParamsDict *PdfObjectDictionary
 	decodeParamsArray := []PdfObject{}
-	obj := encDict.Get("DecodeParms")
+	obj := TraceToDirectObject(encDict.Get("DecodeParms"))
 	if obj != nil {
 		// If it is a dictionary, assume it applies to all
 		dict, isDict := obj.(*PdfObjectDictionary)
@@ -1685,7 +1818,7 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 		}
 	}
 
-	obj = encDict.Get("Filter")
+	obj = TraceToDirectObject(encDict.Get("Filter"))
 	if obj == nil {
 		return nil, fmt.Errorf("Filter missing")
 	}
@@ -1696,7 +1829,7 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 	}
 
 	for idx, obj := range *array {
-		name, ok := obj.(*PdfObjectName)
+		name, ok := TraceToDirectObject(obj).(*PdfObjectName)
 		if !ok {
 			return nil, fmt.Errorf("Multi filter array element not a name")
 		}
@@ -1710,10 +1843,13 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 			// Only get the dp if provided.  Oftentimes there is no decode params dict
 			// provided.
 			if len(decodeParamsArray) > 0 {
-				if idx >= len(decodeParamsArray) {
-					return nil, fmt.Errorf("Missing elements in decode params array")
+				// Filters past the end of a short array take the defaults.
+				if idx < len(decodeParamsArray) {
+					dp = decodeParamsArray[idx]
+				} else {
+					common.Log.Debug("Missing elements in decode params array, defaults for %s", *name)
+					dp = MakeDict()
 				}
-				dp = decodeParamsArray[idx]
 			}
 		}
 
@@ -1736,10 +1872,16 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 				return nil, err
 			}
 			mencoder.AddEncoder(encoder)
+		} else if *name == StreamEncodingFilterNameRunLength {
+			encoder, err := newRunLengthEncoderFromStream(streamObj, dParams)
+			if err != nil {
+				return nil, err
+			}
+			mencoder.AddEncoder(encoder)
 		} else if *name == StreamEncodingFilterNameASCIIHex {
 			encoder := NewASCIIHexEncoder()
 			mencoder.AddEncoder(encoder)
-		} else if *name == StreamEncodingFilterNameASCII85 {
+		} else if *name == StreamEncodingFilterNameASCII85 || *name == "A85" {
 			encoder := NewASCII85Encoder()
 			mencoder.AddEncoder(encoder)
 		} else if *name == StreamEncodingFilterNameDCT {
@@ -1750,6 +1892,15 @@ func newMultiEncoderFromStream(streamObj *PdfObjectStream) (*MultiEncoder, error
 			mencoder.AddEncoder(encoder)
 			common.Log.Trace("Added DCT encoder...")
 			common.Log.Trace("Multi encoder: %#v", mencoder)
+		} else if *name == StreamEncodingFilterNameJBIG2 {
+			encoder, err := newJBIG2EncoderFromStream(streamObj, dParams)
+			if err != nil {
+				return nil, err
+			}
+			mencoder.AddEncoder(encoder)
+		} else if *name == StreamEncodingFilterNameCrypt {
+			// Decrypted when the stream is loaded, see PdfCrypt.Decrypt.
+			common.Log.Trace("Skipping Crypt filter")
 		} else {
 			common.Log.Error("Unsupported filter %s", *name)
 			return nil, fmt.Errorf("Invalid filter in multi filter array")
@@ -1822,18 +1973,21 @@ func (this *MultiEncoder) MakeStreamDict() *PdfObjectDictionary {
 
 func (this *MultiEncoder) DecodeBytes(encoded []byte) ([]byte, error) {
 	decoded := encoded
-	var err error
+	var err, truncErr error
 	// Apply in forward order.
 	for _, encoder := range this.encoders {
 		common.Log.Trace("Multi Encoder Decode: Applying Filter: %v %T", encoder, encoder)
 
 		decoded, err = encoder.DecodeBytes(decoded)
-		if err != nil {
+		if err == ErrTruncatedStream || err == ErrCorruptStream {
+			// Continue with the data decoded so far.
+			truncErr = err
+		} else if err != nil {
 			return nil, err
 		}
 	}
 
-	return decoded, nil
+	return decoded, truncErr
 }
 
 func (this *MultiEncoder) DecodeStream(streamObj *PdfObjectStream) ([]byte, error) {
@@ -0,0 +1,103 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"testing"
+)
+
+// flushedFlate returns the Flate data of the text, flushed but not closed: a valid prefix of a stream.
+func flushedFlate(t *testing.T, text string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write([]byte(text)); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// flateStream returns a FlateDecode stream object of the encoded data.
+func flateStream(encoded []byte) *PdfObjectStream {
+	dict := MakeDict()
+	dict.Set("Filter", MakeName(StreamEncodingFilterNameFlate))
+	dict.Set("Length", MakeInteger(int64(len(encoded))))
+	return &PdfObjectStream{PdfObjectDictionary: dict, Stream: encoded}
+}
+
+func TestDecodeEmptyStream(t *testing.T) {
+	for _, data := range []string{"", " \r\n"} {
+		decoded, err := DecodeStream(flateStream([]byte(data)))
+		if err != nil || decoded == nil || len(decoded) != 0 {
+			t.Errorf("%q: decoded %q, err: %v", data, decoded, err)
+		}
+	}
+}
+
+func TestFlateTruncatedStream(t *testing.T) {
+	text := "BT /F1 12 Tf (Hello) Tj ET"
+	decoded, err := NewFlateEncoder().DecodeBytes(flushedFlate(t, text))
+	if err != ErrTruncatedStream {
+		t.Errorf("err: %v", err)
+	}
+	if string(decoded) != text {
+		t.Errorf("decoded %q", decoded)
+	}
+
+	// the data decoded so far comes with the soft error
+	decoded, err = DecodeStream(flateStream(flushedFlate(t, text)))
+	if !errors.Is(err, ErrTruncatedStream) || string(decoded) != text {
+		t.Errorf("DecodeStream: decoded %q, err: %v", decoded, err)
+	}
+}
+
+func TestFlateCorruptStream(t *testing.T) {
+	text := "BT /F1 12 Tf (Hello) Tj ET"
+	// a final block of the reserved type 3
+	corrupt := append(flushedFlate(t, text), 0xff, 0xff)
+	decoded, err := NewFlateEncoder().DecodeBytes(corrupt)
+	if err != ErrCorruptStream {
+		t.Errorf("err: %v", err)
+	}
+	if string(decoded) != text {
+		t.Errorf("decoded %q", decoded)
+	}
+
+	// the data decoded before the corruption comes with the soft error
+	decoded, err = DecodeStream(flateStream(corrupt))
+	if !errors.Is(err, ErrCorruptStream) || string(decoded) != text {
+		t.Errorf("DecodeStream: decoded %q, err: %v", decoded, err)
+	}
+}
+
+func TestFlateTrailingBytes(t *testing.T) {
+	text := "BT /F1 12 Tf (Hello) Tj ET"
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(text))
+	w.Close()
+	complete := buf.Bytes()
+
+	testcases := []struct {
+		name    string
+		encoded []byte
+	}{
+		{"stray bytes", append(append([]byte{}, complete...), "\r\n\x00ab"...)},
+		// the final block complete, the Adler-32 checksum missing
+		{"no checksum", complete[:len(complete)-4]},
+	}
+	for _, tc := range testcases {
+		decoded, err := NewFlateEncoder().DecodeBytes(tc.encoded)
+		if err != nil || string(decoded) != text {
+			t.Errorf("%s: decoded %q, err: %v", tc.name, decoded, err)
+		}
+	}
+}
@@ -47,3 +47,17 @@ func (parser *PdfParser) SetFileOffset(offset int64) {
 	parser.rs.Seek(offset, os.SEEK_SET)
 	parser.reader = bufio.NewReader(parser.rs)
 }
+
+// Get the size of the file, leaving the file offset unchanged.
+func (parser *PdfParser) fileSize() (int64, error) {
+	offset, err := parser.rs.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return 0, err
+	}
+	size, err := parser.rs.Seek(0, os.SEEK_END)
+	if err != nil {
+		return 0, err
+	}
+	_, err = parser.rs.Seek(offset, os.SEEK_SET)
+	return size, err
+}
@@ -0,0 +1,567 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"../common"
+)
+
+// JBIG2 decoding of the embedded stream format (ISO 14492 Annex D.3): generic regions coded with the MQ
+// arithmetic coder.  Symbol dictionaries, text, halftone and refinement regions and MMR coding are not
+// supported.
+
+// JBIG2 segment types.
+const (
+	jbig2SymbolDictionary          = 0
+	jbig2IntermediateTextRegion    = 4
+	jbig2ImmediateTextRegion       = 6
+	jbig2ImmediateLosslessText     = 7
+	jbig2PatternDictionary         = 16
+	jbig2IntermediateHalftone      = 20
+	jbig2ImmediateHalftone         = 22
+	jbig2ImmediateLosslessHalftone = 23
+	jbig2IntermediateGeneric       = 36
+	jbig2ImmediateGeneric          = 38
+	jbig2ImmediateLosslessGeneric  = 39
+	jbig2IntermediateRefinement    = 40
+	jbig2ImmediateRefinement       = 42
+	jbig2ImmediateLosslessRefine   = 43
+	jbig2PageInformation           = 48
+	jbig2EndOfPage                 = 49
+	jbig2EndOfStripe               = 50
+	jbig2EndOfFile                 = 51
+)
+
+// Bitmap size limit, protects against malformed segments.
+const jbig2MaxPixels = 1 << 28
+
+// jbig2Bitmap is a bilevel bitmap, a byte per pixel, 1 for black.
+type jbig2Bitmap struct {
+	width, height int
+	pixels        []byte
+}
+
+func newJBIG2Bitmap(width, height int, value byte) (*jbig2Bitmap, error) {
+	if width < 0 || height < 0 || (height > 0 && width > jbig2MaxPixels/height) {
+		return nil, fmt.Errorf("invalid JBIG2 bitmap size %dx%d", width, height)
+	}
+	bitmap := &jbig2Bitmap{width, height, make([]byte, width*height)}
+	if value != 0 {
+		for i := range bitmap.pixels {
+			bitmap.pixels[i] = value
+		}
+	}
+	return bitmap, nil
+}
+
+// get returns the pixel at (x, y), 0 outside of the bitmap.
+func (this *jbig2Bitmap) get(x, y int) byte {
+	if x < 0 || y < 0 || x >= this.width || y >= this.height {
+		return 0
+	}
+	return this.pixels[y*this.width+x]
+}
+
+// compose combines the region bitmap into the bitmap at (x, y) with the combination operator: 0 OR, 1 AND,
+// 2 XOR, 3 XNOR, 4 REPLACE.
+func (this *jbig2Bitmap) compose(region *jbig2Bitmap, x, y int, op int) {
+	for ry := 0; ry < region.height; ry++ {
+		py := y + ry
+		if py < 0 || py >= this.height {
+			continue
+		}
+		for rx := 0; rx < region.width; rx++ {
+			px := x + rx
+			if px < 0 || px >= this.width {
+				continue
+			}
+			dst := &this.pixels[py*this.width+px]
+			src := region.pixels[ry*region.width+rx]
+			switch op {
+			case 1:
+				*dst &= src
+			case 2:
+				*dst ^= src
+			case 3:
+				*dst = 1 ^ (*dst ^ src)
+			case 4:
+				*dst = src
+			default:
+				*dst |= src
+			}
+		}
+	}
+}
+
+// grow extends the height of the bitmap to height, for pages of unknown height.
+func (this *jbig2Bitmap) grow(height int, value byte) error {
+	if height <= this.height {
+		return nil
+	}
+	if this.width > 0 && height > jbig2MaxPixels/this.width {
+		return fmt.Errorf("invalid JBIG2 page height %d", height)
+	}
+	for i := this.width * this.height; i < this.width*height; i++ {
+		this.pixels = append(this.pixels, value)
+	}
+	this.height = height
+	return nil
+}
+
+// pack returns the rows of the bitmap as 1 bit per pixel, rows padded to a byte.  As for the PDF filter
+// output, black is 0 and white 1.
+func (this *jbig2Bitmap) pack() []byte {
+	rowBytes := (this.width + 7) / 8
+	data := make([]byte, rowBytes*this.height)
+	for y := 0; y < this.height; y++ {
+		row := data[y*rowBytes : (y+1)*rowBytes]
+		for i := range row {
+			row[i] = 0xff
+		}
+		for x := 0; x < this.width; x++ {
+			if this.pixels[y*this.width+x] != 0 {
+				row[x/8] &^= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	return data
+}
+
+// jbig2Segment is a segment header with its data.
+type jbig2Segment struct {
+	number     uint32
+	segType    int
+	data       []byte
+	unknownLen bool
+}
+
+// readJBIG2Segments splits the segments of the embedded stream format.
+func readJBIG2Segments(data []byte) ([]*jbig2Segment, error) {
+	segments := []*jbig2Segment{}
+	pos := 0
+	for pos < len(data) {
+		if len(data)-pos < 11 {
+			common.Log.Debug("Warning: JBIG2 trailing %d bytes ignored", len(data)-pos)
+			break
+		}
+		segment := &jbig2Segment{}
+		segment.number = binary.BigEndian.Uint32(data[pos:])
+		flags := data[pos+4]
+		segment.segType = int(flags & 0x3f)
+		pos += 5
+
+		// referred-to segments, short form of up to 4, or long form
+		count := int(data[pos] >> 5)
+		if count == 7 {
+			if len(data)-pos < 4 {
+				return nil, errors.New("JBIG2 segment header truncated")
+			}
+			count = int(binary.BigEndian.Uint32(data[pos:]) & 0x1fffffff)
+			pos += 4 + (count+8)/8
+		} else {
+			pos++
+		}
+		refSize := 1
+		if segment.number > 65536 {
+			refSize = 4
+		} else if segment.number > 256 {
+			refSize = 2
+		}
+		pos += count * refSize
+
+		if flags&0x40 != 0 {
+			pos += 4
+		} else {
+			pos++
+		}
+		if count < 0 || pos+4 > len(data) {
+			return nil, errors.New("JBIG2 segment header truncated")
+		}
+		length := binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+
+		if length == 0xffffffff {
+			// only for immediate generic regions: the data ends with 0xFF 0xAC and the row count
+			segment.unknownLen = true
+			// the marker is searched after the region information, flags and adaptive template pixels
+			start := pos + 17 + 1 + 2
+			if pos+17 < len(data) && (data[pos+17]>>1)&3 == 0 {
+				start += 6
+			}
+			end := -1
+			for i := start; i+6 <= len(data); i++ {
+				if data[i] == 0xff && data[i+1] == 0xac {
+					end = i + 6
+					break
+				}
+			}
+			if end < 0 {
+				return nil, errors.New("JBIG2 segment of unknown length not terminated")
+			}
+			segment.data = data[pos:end]
+			pos = end
+		} else {
+			if uint64(pos)+uint64(length) > uint64(len(data)) {
+				common.Log.Debug("Warning: JBIG2 segment %d truncated", segment.number)
+				length = uint32(len(data) - pos)
+			}
+			segment.data = data[pos : pos+int(length)]
+			pos += int(length)
+		}
+
+		segments = append(segments, segment)
+		if segment.segType == jbig2EndOfFile {
+			break
+		}
+	}
+
+	return segments, nil
+}
+
+// jbig2RegionInfo is the region segment information field.
+type jbig2RegionInfo struct {
+	width, height, x, y int
+	op                  int
+}
+
+func readJBIG2RegionInfo(data []byte) (jbig2RegionInfo, error) {
+	if len(data) < 17 {
+		return jbig2RegionInfo{}, errors.New("JBIG2 region segment truncated")
+	}
+	return jbig2RegionInfo{
+		width:  int(binary.BigEndian.Uint32(data[0:])),
+		height: int(binary.BigEndian.Uint32(data[4:])),
+		x:      int(int32(binary.BigEndian.Uint32(data[8:]))),
+		y:      int(int32(binary.BigEndian.Uint32(data[12:]))),
+		op:     int(data[16] & 0x07),
+	}, nil
+}
+
+// decodeJBIG2 decodes the page of the embedded stream data, the global segments first.  Returns the page
+// bitmap packed as the PDF filter output, and its size.
+func decodeJBIG2(globals, data []byte) ([]byte, int, int, error) {
+	segments := []*jbig2Segment{}
+	if len(globals) > 0 {
+		globalSegments, err := readJBIG2Segments(globals)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		segments = append(segments, globalSegments...)
+	}
+	pageSegments, err := readJBIG2Segments(data)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	segments = append(segments, pageSegments...)
+
+	var page *jbig2Bitmap
+	var pageDefault byte
+	pageOp := 0
+	pageOverride := false
+	unknownHeight := false
+
+	for _, segment := range segments {
+		switch segment.segType {
+		case jbig2PageInformation:
+			if page != nil {
+				// the first page only
+				continue
+			}
+			if len(segment.data) < 19 {
+				return nil, 0, 0, errors.New("JBIG2 page information truncated")
+			}
+			width := int(binary.BigEndian.Uint32(segment.data[0:]))
+			height := binary.BigEndian.Uint32(segment.data[4:])
+			flags := segment.data[16]
+			pageDefault = (flags >> 2) & 1
+			pageOp = int(flags>>3) & 3
+			pageOverride = flags&0x40 != 0
+			if height == 0xffffffff {
+				unknownHeight = true
+				height = 0
+			}
+			page, err = newJBIG2Bitmap(width, int(height), pageDefault)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+		case jbig2ImmediateGeneric, jbig2ImmediateLosslessGeneric:
+			if page == nil {
+				return nil, 0, 0, errors.New("JBIG2 region without page information")
+			}
+			info, err := readJBIG2RegionInfo(segment.data)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			region, err := decodeJBIG2GenericRegion(segment.data[17:], info, segment.unknownLen)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if unknownHeight {
+				if err := page.grow(info.y+region.height, pageDefault); err != nil {
+					return nil, 0, 0, err
+				}
+			}
+			op := pageOp
+			if pageOverride {
+				op = info.op
+			}
+			page.compose(region, info.x, info.y, op)
+		case jbig2EndOfStripe:
+			if page != nil && unknownHeight && len(segment.data) >= 4 {
+				if err := page.grow(int(binary.BigEndian.Uint32(segment.data))+1, pageDefault); err != nil {
+					return nil, 0, 0, err
+				}
+			}
+		case jbig2ImmediateTextRegion, jbig2ImmediateLosslessText, jbig2ImmediateHalftone,
+			jbig2ImmediateLosslessHalftone, jbig2ImmediateRefinement, jbig2ImmediateLosslessRefine:
+			return nil, 0, 0, fmt.Errorf("JBIG2 segment type %d not supported", segment.segType)
+		case jbig2EndOfPage, jbig2EndOfFile:
+		default:
+			// dictionaries and intermediate regions are only used by the unsupported regions
+			common.Log.Trace("JBIG2 segment type %d skipped", segment.segType)
+		}
+	}
+
+	if page == nil {
+		return nil, 0, 0, errors.New("JBIG2 page information missing")
+	}
+	return page.pack(), page.width, page.height, nil
+}
+
+// The pixels of the generic region templates (x, y), the most significant bit of the context first as in
+// 6.2.5.3.  {0, 0} stands for the next adaptive template pixel.
+var jbig2GenericTemplates = [4][][2]int{
+	{{0, 0}, {-1, -2}, {0, -2}, {1, -2}, {0, 0}, {0, 0}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1},
+		{0, 0}, {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}},
+	{{-1, -2}, {0, -2}, {1, -2}, {2, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1}, {0, 0}, {-3, 0},
+		{-2, 0}, {-1, 0}},
+	{{-1, -2}, {0, -2}, {1, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {0, 0}, {-2, 0}, {-1, 0}},
+	{{-3, -1}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {0, 0}, {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}},
+}
+
+// The order of the adaptive template pixels of template 0 in jbig2GenericTemplates: A4, A3, A2, A1.
+var jbig2Template0ATOrder = []int{3, 2, 1, 0}
+
+// The context of the typical prediction bit SLTP of each template.
+var jbig2SLTPContexts = [4]int{0x9b25, 0x0795, 0x00e5, 0x0195}
+
+// decodeJBIG2GenericRegion decodes the generic region segment data following the region information.
+func decodeJBIG2GenericRegion(data []byte, info jbig2RegionInfo, unknownLen bool) (*jbig2Bitmap, error) {
+	if len(data) < 1 {
+		return nil, errors.New("JBIG2 generic region truncated")
+	}
+	flags := data[0]
+	if flags&1 != 0 {
+		return nil, errors.New("JBIG2 MMR generic regions not supported")
+	}
+	template := int(flags>>1) & 3
+	typicalPrediction := flags&0x08 != 0
+
+	atCount := 1
+	if template == 0 {
+		atCount = 4
+	}
+	if len(data) < 1+2*atCount {
+		return nil, errors.New("JBIG2 generic region truncated")
+	}
+	at := make([][2]int, atCount)
+	for i := range at {
+		at[i] = [2]int{int(int8(data[1+2*i])), int(int8(data[2+2*i]))}
+	}
+	data = data[1+2*atCount:]
+
+	height := info.height
+	if unknownLen {
+		// the row count follows the end marker
+		if len(data) < 6 {
+			return nil, errors.New("JBIG2 generic region truncated")
+		}
+		height = int(binary.BigEndian.Uint32(data[len(data)-4:]))
+		data = data[:len(data)-6]
+	}
+
+	// the template pixels with the adaptive ones in place
+	pixels := [][2]int{}
+	atIndex := 0
+	for _, p := range jbig2GenericTemplates[template] {
+		if p == [2]int{0, 0} {
+			i := atIndex
+			if template == 0 {
+				i = jbig2Template0ATOrder[atIndex]
+			}
+			p = at[i]
+			atIndex++
+		}
+		pixels = append(pixels, p)
+	}
+
+	bitmap, err := newJBIG2Bitmap(info.width, height, 0)
+	if err != nil {
+		return nil, err
+	}
+	decoder := newMQDecoder(data)
+	contexts := make([]byte, 1<<uint(len(pixels)))
+
+	ltp := false
+	for y := 0; y < height; y++ {
+		if typicalPrediction {
+			if decoder.decode(contexts, jbig2SLTPContexts[template]) != 0 {
+				ltp = !ltp
+			}
+			if ltp {
+				// same as the row above, white for the first row
+				if y > 0 {
+					copy(bitmap.pixels[y*bitmap.width:(y+1)*bitmap.width], bitmap.pixels[(y-1)*bitmap.width:y*bitmap.width])
+				}
+				continue
+			}
+		}
+		for x := 0; x < bitmap.width; x++ {
+			context := 0
+			for _, p := range pixels {
+				context = context<<1 | int(bitmap.get(x+p[0], y+p[1]))
+			}
+			bitmap.pixels[y*bitmap.width+x] = byte(decoder.decode(contexts, context))
+		}
+	}
+
+	return bitmap, nil
+}
+
+// mqQe is an entry of the probability estimation table of the MQ coder (Table E.1).
+type mqQe struct {
+	qe         uint32
+	nmps, nlps byte
+	switchFlag bool
+}
+
+var mqQeTable = [47]mqQe{
+	{0x5601, 1, 1, true}, {0x3401, 2, 6, false}, {0x1801, 3, 9, false}, {0x0ac1, 4, 12, false},
+	{0x0521, 5, 29, false}, {0x0221, 38, 33, false}, {0x5601, 7, 6, true}, {0x5401, 8, 14, false},
+	{0x4801, 9, 14, false}, {0x3801, 10, 14, false}, {0x3001, 11, 17, false}, {0x2401, 12, 18, false},
+	{0x1c01, 13, 20, false}, {0x1601, 29, 21, false}, {0x5601, 15, 14, true}, {0x5401, 16, 14, false},
+	{0x5101, 17, 15, false}, {0x4801, 18, 16, false}, {0x3801, 19, 17, false}, {0x3401, 20, 18, false},
+	{0x3001, 21, 19, false}, {0x2801, 22, 19, false}, {0x2401, 23, 20, false}, {0x2201, 24, 21, false},
+	{0x1c01, 25, 22, false}, {0x1801, 26, 23, false}, {0x1601, 27, 24, false}, {0x1401, 28, 25, false},
+	{0x1201, 29, 26, false}, {0x1101, 30, 27, false}, {0x0ac1, 31, 28, false}, {0x09c1, 32, 29, false},
+	{0x08a1, 33, 30, false}, {0x0521, 34, 31, false}, {0x0441, 35, 32, false}, {0x02a1, 36, 33, false},
+	{0x0221, 37, 34, false}, {0x0141, 38, 35, false}, {0x0111, 39, 36, false}, {0x0085, 40, 37, false},
+	{0x0049, 41, 38, false}, {0x0025, 42, 39, false}, {0x0015, 43, 40, false}, {0x0009, 44, 41, false},
+	{0x0005, 45, 42, false}, {0x0001, 45, 43, false}, {0x5601, 46, 46, false},
+}
+
+// mqDecoder is the MQ arithmetic decoder of Annex E.3.  A context state is the index in mqQeTable shifted
+// left by one, with the MPS in the low bit.
+type mqDecoder struct {
+	data        []byte
+	pos         int
+	chigh, clow uint32
+	a           uint32
+	ct          int
+}
+
+func newMQDecoder(data []byte) *mqDecoder {
+	this := &mqDecoder{data: data}
+	this.chigh = uint32(this.byteAt(0))
+	this.byteIn()
+	this.chigh = (this.chigh<<7)&0xffff | (this.clow>>9)&0x7f
+	this.clow = (this.clow << 7) & 0xffff
+	this.ct -= 7
+	this.a = 0x8000
+	return this
+}
+
+// byteAt returns the data byte at i, 0xFF past the end of the data.
+func (this *mqDecoder) byteAt(i int) byte {
+	if i < len(this.data) {
+		return this.data[i]
+	}
+	return 0xff
+}
+
+// byteIn is the BYTEIN procedure (E.3.4).
+func (this *mqDecoder) byteIn() {
+	if this.byteAt(this.pos) == 0xff {
+		if this.byteAt(this.pos+1) > 0x8f {
+			this.clow += 0xff00
+			this.ct = 8
+		} else {
+			this.pos++
+			this.clow += uint32(this.byteAt(this.pos)) << 9
+			this.ct = 7
+		}
+	} else {
+		this.pos++
+		this.clow += uint32(this.byteAt(this.pos)) << 8
+		this.ct = 8
+	}
+	if this.clow > 0xffff {
+		this.chigh += this.clow >> 16
+		this.clow &= 0xffff
+	}
+}
+
+// decode returns the next bit decoded in the context, updating its state (DECODE procedure, E.3.2).
+func (this *mqDecoder) decode(contexts []byte, context int) int {
+	index := contexts[context] >> 1
+	mps := int(contexts[context] & 1)
+	qe := mqQeTable[index]
+
+	d := mps
+	a := this.a - qe.qe
+	if this.chigh < qe.qe {
+		// LPS exchange
+		if a < qe.qe {
+			d = mps
+			index = qe.nmps
+		} else {
+			d = 1 ^ mps
+			if qe.switchFlag {
+				mps = d
+			}
+			index = qe.nlps
+		}
+		a = qe.qe
+	} else {
+		this.chigh -= qe.qe
+		if a&0x8000 != 0 {
+			this.a = a
+			return mps
+		}
+		// MPS exchange
+		if a < qe.qe {
+			d = 1 ^ mps
+			if qe.switchFlag {
+				mps = d
+			}
+			index = qe.nlps
+		} else {
+			d = mps
+			index = qe.nmps
+		}
+	}
+
+	// RENORMD
+	for {
+		if this.ct == 0 {
+			this.byteIn()
+		}
+		a <<= 1
+		this.chigh = (this.chigh<<1)&0xffff | (this.clow>>15)&1
+		this.clow = (this.clow << 1) & 0xffff
+		this.ct--
+		if a&0x8000 != 0 {
+			break
+		}
+	}
+	this.a = a
+	contexts[context] = index<<1 | byte(mps)
+	return d
+}
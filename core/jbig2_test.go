@@ -0,0 +1,307 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// mqEncoder is the MQ arithmetic encoder of Annex E.2, producing the test data of the decoder.
+type mqEncoder struct {
+	a, c     uint32
+	ct       int
+	buf      []byte
+	contexts map[int]byte
+}
+
+func newMQEncoder() *mqEncoder {
+	// the first byte is the preceding byte B of the procedures, not output
+	return &mqEncoder{a: 0x8000, ct: 12, buf: []byte{0}, contexts: map[int]byte{}}
+}
+
+// byteOut is the BYTEOUT procedure (E.2.8), with the carry propagated into the last byte.
+func (this *mqEncoder) byteOut() {
+	last := len(this.buf) - 1
+	if this.buf[last] == 0xff {
+		this.buf = append(this.buf, byte(this.c>>20))
+		this.c &= 0xfffff
+		this.ct = 7
+		return
+	}
+	if this.c >= 0x8000000 {
+		this.buf[last]++
+		this.c &= 0x7ffffff
+		if this.buf[last] == 0xff {
+			this.buf = append(this.buf, byte(this.c>>20))
+			this.c &= 0xfffff
+			this.ct = 7
+			return
+		}
+	}
+	this.buf = append(this.buf, byte(this.c>>19))
+	this.c &= 0x7ffff
+	this.ct = 8
+}
+
+func (this *mqEncoder) renorm() {
+	for {
+		this.a <<= 1
+		this.c <<= 1
+		this.ct--
+		if this.ct == 0 {
+			this.byteOut()
+		}
+		if this.a&0x8000 != 0 {
+			break
+		}
+	}
+}
+
+// encode codes the bit in the context (ENCODE procedure, E.2.2).
+func (this *mqEncoder) encode(context, d int) {
+	index := this.contexts[context] >> 1
+	mps := int(this.contexts[context] & 1)
+	qe := mqQeTable[index]
+
+	this.a -= qe.qe
+	if d == mps {
+		if this.a&0x8000 != 0 {
+			this.c += qe.qe
+			return
+		}
+		if this.a < qe.qe {
+			this.a = qe.qe
+		} else {
+			this.c += qe.qe
+		}
+		index = qe.nmps
+	} else {
+		if this.a < qe.qe {
+			this.c += qe.qe
+		} else {
+			this.a = qe.qe
+		}
+		if qe.switchFlag {
+			mps = 1 - mps
+		}
+		index = qe.nlps
+	}
+	this.contexts[context] = index<<1 | byte(mps)
+	this.renorm()
+}
+
+// flush ends the coded data with the 0xFF 0xAC marker (FLUSH procedure, E.2.9).
+func (this *mqEncoder) flush() []byte {
+	t := this.c + this.a
+	this.c |= 0xffff
+	if this.c >= t {
+		this.c -= 0x8000
+	}
+	this.c <<= uint(this.ct)
+	this.byteOut()
+	this.c <<= uint(this.ct)
+	this.byteOut()
+	if this.buf[len(this.buf)-1] != 0xff {
+		this.buf = append(this.buf, 0xff)
+	}
+	return append(this.buf[1:], 0xac)
+}
+
+// makeJBIG2Segment returns a segment of the page 1 without referred-to segments.
+func makeJBIG2Segment(number uint32, segType byte, data []byte, unknownLen bool) []byte {
+	header := make([]byte, 11)
+	binary.BigEndian.PutUint32(header, number)
+	header[4] = segType
+	header[6] = 1
+	length := uint32(len(data))
+	if unknownLen {
+		length = 0xffffffff
+	}
+	binary.BigEndian.PutUint32(header[7:], length)
+	return append(header, data...)
+}
+
+// encodeJBIG2Page returns the page information segment, and the generic region segment of the bitmap
+// (rows of 1 for black) followed by the end of page, coded with the template, the adaptive template pixels
+// at and typical prediction.
+func encodeJBIG2Page(bitmap [][]byte, template int, at [][2]int, typicalPrediction, unknownLen bool) ([]byte,
+	[]byte) {
+	width, height := len(bitmap[0]), len(bitmap)
+
+	pageInfo := make([]byte, 19)
+	binary.BigEndian.PutUint32(pageInfo, uint32(width))
+	binary.BigEndian.PutUint32(pageInfo[4:], uint32(height))
+
+	// the template pixels of Figures 3 to 6, the most significant bit of the context first, the adaptive
+	// pixels being at[0] for A1 to at[3] for A4
+	var pixels [][2]int
+	switch template {
+	case 0:
+		pixels = [][2]int{at[3], {-1, -2}, {0, -2}, {1, -2}, at[2], at[1], {-2, -1}, {-1, -1}, {0, -1}, {1, -1},
+			{2, -1}, at[0], {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}}
+	case 1:
+		pixels = [][2]int{{-1, -2}, {0, -2}, {1, -2}, {2, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1},
+			at[0], {-3, 0}, {-2, 0}, {-1, 0}}
+	case 2:
+		pixels = [][2]int{{-1, -2}, {0, -2}, {1, -2}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, at[0], {-2, 0}, {-1, 0}}
+	case 3:
+		pixels = [][2]int{{-3, -1}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, at[0], {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0}}
+	}
+	get := func(x, y int) int {
+		if x < 0 || x >= width || y < 0 {
+			return 0
+		}
+		return int(bitmap[y][x])
+	}
+
+	encoder := newMQEncoder()
+	ltp := false
+	for y := 0; y < height; y++ {
+		if typicalPrediction {
+			same := y > 0 && bytes.Equal(bitmap[y], bitmap[y-1])
+			if y == 0 {
+				same = bytes.Equal(bitmap[0], make([]byte, width))
+			}
+			sltp := 0
+			if same != ltp {
+				sltp = 1
+				ltp = !ltp
+			}
+			encoder.encode([]int{0x9b25, 0x0795, 0x00e5, 0x0195}[template], sltp)
+			if ltp {
+				continue
+			}
+		}
+		for x := 0; x < width; x++ {
+			context := 0
+			for _, p := range pixels {
+				context = context<<1 | get(x+p[0], y+p[1])
+			}
+			encoder.encode(context, int(bitmap[y][x]))
+		}
+	}
+
+	region := make([]byte, 18)
+	binary.BigEndian.PutUint32(region, uint32(width))
+	binary.BigEndian.PutUint32(region[4:], uint32(height))
+	region[17] = byte(template << 1)
+	if typicalPrediction {
+		region[17] |= 0x08
+	}
+	for _, p := range at {
+		region = append(region, byte(int8(p[0])), byte(int8(p[1])))
+	}
+	region = append(region, encoder.flush()...)
+	if unknownLen {
+		region = append(region, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(region[len(region)-4:], uint32(height))
+	}
+
+	return makeJBIG2Segment(0, jbig2PageInformation, pageInfo, false),
+		append(makeJBIG2Segment(1, jbig2ImmediateGeneric, region, unknownLen),
+			makeJBIG2Segment(2, jbig2EndOfPage, nil, false)...)
+}
+
+func TestMQDecoder(t *testing.T) {
+	// the test sequence of ISO 14492 Annex H.2, coded in a single context
+	encoded := []byte{0x84, 0xc7, 0x3b, 0xfc, 0xe1, 0xa1, 0x43, 0x04, 0x02, 0x20, 0x00, 0x00, 0x41, 0x0d, 0xbb,
+		0x86, 0xf4, 0x31, 0x7f, 0xff, 0x88, 0xff, 0x37, 0x47, 0x1a, 0xdb, 0x6a, 0xdf, 0xff, 0xac}
+	expected := []byte{0x00, 0x02, 0x00, 0x51, 0x00, 0x00, 0x00, 0xc0, 0x03, 0x52, 0x87, 0x2a, 0xaa, 0xaa, 0xaa,
+		0xaa, 0x82, 0xc0, 0x20, 0x00, 0xfc, 0xd7, 0x9e, 0xf6, 0xbf, 0x7f, 0xed, 0x90, 0x4f, 0x46, 0xa3, 0xbf}
+
+	decoder := newMQDecoder(encoded)
+	contexts := make([]byte, 1)
+	decoded := make([]byte, len(expected))
+	for i := 0; i < 8*len(expected); i++ {
+		decoded[i/8] |= byte(decoder.decode(contexts, 0) << uint(7-i%8))
+	}
+	if !bytes.Equal(decoded, expected) {
+		t.Errorf("decoded % x", decoded)
+	}
+
+	// the test encoder codes the sequence alike
+	encoder := newMQEncoder()
+	for i := 0; i < 8*len(expected); i++ {
+		encoder.encode(0, int(expected[i/8]>>uint(7-i%8)&1))
+	}
+	if coded := encoder.flush(); !bytes.Equal(coded, encoded) {
+		t.Errorf("encoded % x", coded)
+	}
+}
+
+func TestJBIG2GenericRegion(t *testing.T) {
+	random := rand.New(rand.NewSource(7))
+	for template := 0; template < 4; template++ {
+		for _, typicalPrediction := range []bool{false, true} {
+			for _, custom := range []bool{false, true} {
+				width, height := 5+random.Intn(60), 3+random.Intn(30)
+				// black pixels and rows repeating the row above, for the typical prediction
+				bitmap := [][]byte{}
+				for y := 0; y < height; y++ {
+					if y > 0 && random.Intn(10) < 3 {
+						bitmap = append(bitmap, bitmap[y-1])
+						continue
+					}
+					row := make([]byte, width)
+					for x := range row {
+						if random.Intn(20) < 7 {
+							row[x] = 1
+						}
+					}
+					bitmap = append(bitmap, row)
+				}
+
+				// the nominal adaptive template pixels, or others
+				at := [][2]int{{3, -1}, {-3, -1}, {2, -2}, {-2, -2}}
+				switch {
+				case template == 0 && custom:
+					at = [][2]int{{5, -2}, {-6, -1}, {0, -3}, {-1, 0}}
+				case custom:
+					at = [][2]int{{-5, -2}}
+				case template == 1:
+					at = [][2]int{{3, -1}}
+				case template != 0:
+					at = [][2]int{{2, -1}}
+				}
+				unknownLen := custom && typicalPrediction
+				pageInfo, region := encodeJBIG2Page(bitmap, template, at, typicalPrediction, unknownLen)
+
+				// the page information in the globals for templates 1 and 3
+				encoder := NewJBIG2Encoder()
+				data := append(append([]byte{}, pageInfo...), region...)
+				if template%2 == 1 {
+					encoder.Globals = pageInfo
+					data = region
+				}
+				name := fmt.Sprintf("template %d, TPGDON %v, custom AT %v", template, typicalPrediction, custom)
+				decoded, err := encoder.DecodeBytes(data)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+
+				// 1 bit per pixel, black as 0, the rows padded to bytes
+				expected := []byte{}
+				for _, row := range bitmap {
+					packed := bytes.Repeat([]byte{0xff}, (width+7)/8)
+					for x, v := range row {
+						if v != 0 {
+							packed[x/8] &^= 0x80 >> uint(x%8)
+						}
+					}
+					expected = append(expected, packed...)
+				}
+				if !bytes.Equal(decoded, expected) {
+					t.Errorf("%s: decoded % x, expected % x", name, decoded, expected)
+				}
+			}
+		}
+	}
+}
@@ -7,15 +7,20 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"math"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 var rePdfVersion = regexp.MustCompile(`%PDF-(\d)\.(\d)`)
 var reStartXref = regexp.MustCompile(`startx?ref\s*(\d+)`)
-var reXrefSubsection = regexp.MustCompile(`(\d+)\s+(\d+)$`)
-var reXrefEntry = regexp.MustCompile(`(\d+)\s+(\d+)\s+([nf])$`)
+// The xref subsection headers and entries are matched at the start of the rest of a line, the end of line
+// markers of entries being sometimes missing.
+var reXrefSubsection = regexp.MustCompile(`^(\d+)\s+(\d+)(\s|$)`)
+var reXrefEntry = regexp.MustCompile(`^(\d+)\s+(\d+)\s+([nf])`)
 var reReference = regexp.MustCompile(`^\s*(\d+)\s+(\d+)\s+R`)
 var reNumeric = regexp.MustCompile(`^[\+-.]*([0-9.]+)`)
 var reExponential = regexp.MustCompile(`^[\+-.]*([0-9.]+)e[\+-.]*([0-9.]+)`)
@@ -32,9 +37,20 @@ type PdfParser struct {
 	//store referenceData
 	xrefs XrefTable
 
+	// Xref section being read, 0 for the newest, and the section of the free entries of the objects not
+	// defined by a newer section.  Entries of older sections than the one freeing an object are ignored.
+	xrefSection int
+	xrefFreed   map[int]int
+
 	//trailer dict
 	trailerDict *PdfObjectDictionary
 
+	// ID of the first trailer having one, following the Prev chain
+	trailerID PdfObject
+
+	// Size of the first trailer having one, following the Prev chain
+	trailerSize PdfObject
+
 	getRoot bool
 
 	getInfo bool
@@ -42,6 +58,9 @@ type PdfParser struct {
 	//root dict
 	rootDict *PdfObjectDictionary
 
+	// whether the objects were scanned for a catalog, the trailer lacking the root
+	rootScanned bool
+
 	//info dict
 	infoDict *PdfObjectDictionary
 
@@ -55,7 +74,14 @@ type PdfParser struct {
 
 	ObjCache ObjectCache // TODO: Unexport (v3).
 
+	// guards ObjCache for concurrent lookups
+	cacheLock sync.RWMutex
+
 	objstms ObjectStreams
+
+	// Tracker of the object streams being loaded.  An object stream stored in another object stream that
+	// leads back to it would otherwise be looked up endlessly.
+	objstmLoadInProgress map[int]bool
 }
 
 // Skip over comments and spaces. Can handle multi-line comments.
@@ -107,6 +133,86 @@ func (parser *PdfParser) skipSpaces() (int, error) {
 	return cnt, nil
 }
 
+// Skip over a regular token, i.e. everything up to the next white space or delimiter, and return it.
+func (parser *PdfParser) skipToken() string {
+	var r bytes.Buffer
+	for {
+		bb, err := parser.reader.Peek(1)
+		if err != nil {
+			break
+		}
+		if r.Len() > 0 && (IsWhiteSpace(bb[0]) || IsDelimiter(bb[0])) {
+			break
+		}
+		b, _ := parser.reader.ReadByte()
+		r.WriteByte(b)
+	}
+	return r.String()
+}
+
+// Skip the end-of-line marker following the stream keyword.
+// The stream keyword shall be followed by CRLF or a bare LF (7.3.8.1), but some writers use a bare CR,
+// and some leave spaces before the EOL. Only the marker is consumed so the stream data stays intact.
+func (parser *PdfParser) skipStreamEOL() {
+	for {
+		bb, err := parser.reader.Peek(1)
+		if err != nil || bb[0] != ' ' {
+			break
+		}
+		parser.reader.ReadByte()
+	}
+
+	bb, err := parser.reader.Peek(2)
+	if err != nil && len(bb) == 0 {
+		return
+	}
+
+	if bb[0] == '\r' {
+		if len(bb) > 1 && bb[1] == '\n' {
+			parser.reader.Discard(2)
+		} else {
+			common.Log.Debug("Warning: stream keyword followed by a bare CR")
+			parser.reader.Discard(1)
+		}
+	} else if bb[0] == '\n' {
+		parser.reader.Discard(1)
+	}
+}
+
+// Replace the references of the stream /Filter and /DecodeParms, and of their array elements, by the objects
+// they refer to, as DecodeStream has no parser to resolve them.  Guarded against loops like the length.
+func (parser *PdfParser) traceStreamFilters(dict *PdfObjectDictionary) {
+	trace := func(obj PdfObject) PdfObject {
+		ref, isRef := obj.(*PdfObjectReference)
+		if !isRef || parser.streamLengthReferenceLookupInProgress[ref.ObjectNumber] {
+			return obj
+		}
+		parser.streamLengthReferenceLookupInProgress[ref.ObjectNumber] = true
+		defer delete(parser.streamLengthReferenceLookupInProgress, ref.ObjectNumber)
+
+		traced, err := parser.Trace(obj)
+		if err != nil {
+			common.Log.Debug("Fail to trace stream filter %s: %v", ref, err)
+			return obj
+		}
+		return traced
+	}
+
+	for _, key := range []PdfObjectName{"Filter", "DecodeParms"} {
+		obj := dict.Get(key)
+		if obj == nil {
+			continue
+		}
+		obj = trace(obj)
+		dict.Set(key, obj)
+		if arr, ok := obj.(*PdfObjectArray); ok {
+			for i := range *arr {
+				(*arr)[i] = trace((*arr)[i])
+			}
+		}
+	}
+}
+
 // Return the closest object following offset from the xrefs table.
 func (parser *PdfParser) xrefNextObjectOffset(offset int64) int64 {
 	nextOffset := int64(0)
@@ -143,6 +249,23 @@ func (parser *PdfParser) traceStreamLength(lengthObj PdfObject) (PdfObject, erro
 		parser.streamLengthReferenceLookupInProgress[lengthRef.ObjectNumber] = false
 	}
 
+	// Some writers store the length as an integral real or as a string.
+	switch length := slo.(type) {
+	case *PdfObjectFloat:
+		if float64(*length) != math.Trunc(float64(*length)) {
+			return nil, errors.New("Stream length needs to be an integer")
+		}
+		common.Log.Debug("Stream length is a real (%s), using it as integer", length)
+		return MakeInteger(int64(*length)), nil
+	case *PdfObjectString:
+		intVal, err := strconv.ParseInt(strings.TrimSpace(string(*length)), 10, 64)
+		if err != nil {
+			return nil, errors.New("Stream length needs to be an integer")
+		}
+		common.Log.Debug("Stream length is a string (%s), using it as integer", length)
+		return MakeInteger(intVal), nil
+	}
+
 	return slo, nil
 }
 
@@ -154,7 +277,8 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 	common.Log.Trace("Reading indirect obj")
 
 	bb, err := parser.reader.Peek(20)
-	if err != nil {
+	if err != nil && (err != io.EOF || len(bb) == 0) {
+		// A short read is fine when the object is located right before EOF.
 		common.Log.Debug("ERROR: Fail to read indirect obj")
 		return &indirect, err
 	}
@@ -192,6 +316,11 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 	for {
 		ch, err := parser.reader.ReadByte()
 		if err != nil {
+			if err == io.EOF && indirect.PdfObject != nil {
+				// Truncated file, the last object is missing its endobj.
+				common.Log.Debug("Warning: reached EOF before endobj of object %d, accepting", indirect.ObjectNumber)
+				return &indirect, nil
+			}
 			return &indirect, err
 		}
 
@@ -216,16 +345,17 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 			}
 		case 'e':
 			{
-				line, err := parser.reader.ReadString('j')
-				if err != nil {
-					return &indirect, err
-				}
-
-				line = strings.TrimSpace(line)
-				if line == "ndobj" {
+				parser.reader.UnreadByte()
+				bb, _ := parser.reader.Peek(6)
+				if string(bb) == "endobj" {
+					parser.reader.Discard(6)
 					common.Log.Trace("Returning indirect!")
 					return &indirect, nil
 				}
+
+				// Not endobj, skip over the unexpected token and keep looking for the terminator.
+				token := parser.skipToken()
+				common.Log.Debug("Warning: unexpected token \"%s\" in object %d, skipping", token, indirect.ObjectNumber)
 			}
 		case 's':
 			{
@@ -238,7 +368,7 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 				common.Log.Trace("should read 5, actual read: %d", n)
 				if string(bb[:5]) == "tream" {
 					//it will skip the real byte when use skipspaces() and it will cause decrypt or decode fail
-					parser.reader.ReadString('\n')
+					parser.skipStreamEOL()
 					dict, ok := indirect.PdfObject.(*PdfObjectDictionary)
 					if !ok {
 						return nil, errors.New("Stream object missing dictionary")
@@ -252,6 +382,7 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 						return nil, err
 					}
 					common.Log.Trace("Stream length: %s", slo)
+					parser.traceStreamFilters(dict)
 
 					pstreamLength, ok := slo.(*PdfObjectInteger)
 					if !ok {
@@ -283,6 +414,19 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 						dict.Set("Length", &streamLength)
 					}
 
+					// Some writers overstate the length past the end of the file, read what is there.
+					pastEOF := false
+					if fileSize, err := parser.fileSize(); err == nil && streamStartOffset+int64(streamLength) > fileSize {
+						newLength := fileSize - streamStartOffset
+						if newLength < 0 {
+							newLength = 0
+						}
+						common.Log.Debug("Warning: stream length %d of object %d going past EOF, truncating to %d",
+							streamLength, indirect.ObjectNumber, newLength)
+						streamLength = PdfObjectInteger(newLength)
+						pastEOF = true
+					}
+
 					common.Log.Trace("stream length: %d", streamLength)
 
 					stream := make([]byte, streamLength)
@@ -292,6 +436,21 @@ func (parser *PdfParser) ParseIndirectObject() (PdfObject, error) {
 						return nil, err
 					}
 
+					if pastEOF {
+						// The data read up to EOF holds the end of the file, cut it at the endstream.
+						if i := bytes.Index(stream, []byte("endstream")); i >= 0 {
+							// Drop the EOL preceding endstream.
+							stream = stream[:i]
+							if bytes.HasSuffix(stream, []byte("\r\n")) {
+								stream = stream[:len(stream)-2]
+							} else if bytes.HasSuffix(stream, []byte("\n")) || bytes.HasSuffix(stream, []byte("\r")) {
+								stream = stream[:len(stream)-1]
+							}
+						}
+						streamLength = PdfObjectInteger(len(stream))
+						dict.Set("Length", &streamLength)
+					}
+
 					streamobj := PdfObjectStream{}
 					streamobj.Stream = stream
 					streamobj.PdfObjectDictionary = indirect.PdfObject.(*PdfObjectDictionary)
@@ -356,12 +515,17 @@ func (parser *PdfParser) readXrefStream(xs *PdfObjectStream) error {
 		if !ok {
 			return errors.New("invalid w integer object type")
 		}
+		// fields are read into an int64
+		if *wVal > 8 {
+			common.Log.Debug("Error: xref stm W value %d wider than 8 bytes", *wVal)
+			return errors.New("xref stm W value wider than 8 bytes")
+		}
 
 		b = append(b, int64(*wVal))
 	}
 
 	ds, err := DecodeStream(xs)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrTruncatedStream) && !errors.Is(err, ErrCorruptStream) {
 		common.Log.Debug("ERROR: Unable to decode stream: %v", err)
 		return err
 	}
@@ -508,8 +672,11 @@ func (parser *PdfParser) readXrefStream(xs *PdfObjectStream) error {
 		common.Log.Trace("%d. p3: % x", objNum, p3)
 
 		common.Log.Trace("%d. xref: %d %d %d", objNum, ftype, n2, n3)
-		if ftype == 0 {
-			common.Log.Trace("- Free object - can probably ignore")
+		if ftype != 0 && parser.isFreedXref(objNum) {
+			common.Log.Trace("- Freed by a newer section - ignore")
+		} else if ftype == 0 {
+			common.Log.Trace("- Free object")
+			parser.freeXref(objNum)
 		} else if ftype == 1 {
 			common.Log.Trace("- In use - uncompressed via offset %b", p2)
 			// Object type 1: Objects that are in use but are not
@@ -546,153 +713,135 @@ func (parser *PdfParser) readXrefStream(xs *PdfObjectStream) error {
 	return nil
 }
 
-// read xref table
-func (parser *PdfParser) readXrefTable(prevLine string) error {
+// freeXref records the free entry of the object in the xref section being read, unless a newer section
+// defines it.  Object 0, the head of the free list, is always free.
+func (parser *PdfParser) freeXref(objNum int) {
+	if objNum == 0 {
+		return
+	}
+	if _, ok := parser.xrefs[objNum]; ok {
+		return
+	}
+	if _, ok := parser.xrefFreed[objNum]; ok {
+		return
+	}
+	parser.xrefFreed[objNum] = parser.xrefSection
+}
+
+// isFreedXref returns whether the object was freed by a newer xref section than the one being read, an
+// incremental update deleting it.  The free entries of a table don't hide the entries of its /XRefStm, which
+// belongs to the same section.
+func (parser *PdfParser) isFreedXref(objNum int) bool {
+	section, ok := parser.xrefFreed[objNum]
+	return ok && section < parser.xrefSection
+}
+
+// read xref table, from after the "xref" keyword up to the "trailer" keyword.  The reader is left at the
+// start of the trailer dictionary.
+func (parser *PdfParser) readXrefTable() error {
 	curObjIdx := -1
 	objCount := 0
 	insideSubsection := false
 
-	//ref^M34 45 ^M111 000 n
-	if len(prevLine) > 3 {
-		splitStrs := strings.Split(prevLine, "\r")
-		for _, s := range splitStrs {
-			s = strings.TrimSpace(s)
-			result1 := reXrefSubsection.FindStringSubmatch(s)
-			if len(result1) == 3 {
-				// Match
-				first, _ := strconv.Atoi(result1[1])
-				second, _ := strconv.Atoi(result1[2])
-				curObjIdx = first
-				objCount = second
-				insideSubsection = true
-				common.Log.Trace("xref subsection: first object: %d objects: %d", curObjIdx, objCount)
-				continue
-			}
-
-			result2 := reXrefEntry.FindStringSubmatch(s)
-			if len(result2) == 4 {
-				if !insideSubsection {
-					common.Log.Debug("Error: Xref invalid format!")
-					return errors.New("Xref invalid format")
-				}
-
-				first, _ := strconv.ParseInt(result2[1], 10, 64)
-				gen, _ := strconv.Atoi(result2[2])
-				third := result2[3]
-
-				if strings.ToLower(third) == "n" && first > 1 {
-					if x, ok := parser.xrefs[curObjIdx]; !ok || gen > x.generation {
-						obj := XrefObject{
-							objectNumber: curObjIdx,
-							xtype:        XREF_TABLE_ENTRY,
-							offset:       first,
-							generation:   gen}
-						parser.xrefs[curObjIdx] = obj
-					}
-				}
-				curObjIdx++
-			}
-		}
-	}
-
 	for {
 		line, err := parser.reader.ReadString('\n')
-		if err != nil {
-			//%%EOF exist the same line with trailer
-			if err == io.EOF {
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, "trailer") {
-					common.Log.Trace("found trailer, %s", line)
-					// sometimes get "trailer<<"
-					if len(line) > 9 {
-						offset := parser.GetFileOffset()
-						parser.SetFileOffset(offset - int64(len(line)) + 6)
-					}
-
-					parser.skipSpaces()
-					break
-				}
-			}
+		if err != nil && (err != io.EOF || len(line) == 0) {
 			return err
 		}
-
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "trailer") {
-			common.Log.Trace("found trailer, %s", line)
-			// sometimes get "trailer<<"
-			if len(line) > 9 {
-				offset := parser.GetFileOffset()
-				parser.SetFileOffset(offset - int64(len(line)) + 6)
-			}
-
-			parser.skipSpaces()
-			break
-		}
+		lineOffset := parser.GetFileOffset() - int64(len(line))
 
 		//like 34 56^M110000 000 n
-		splitStrs := strings.Split(line, "\r")
-		for _, s := range splitStrs {
-			s = strings.TrimSpace(s)
-			result1 := reXrefSubsection.FindStringSubmatch(s)
-			if len(result1) == 3 {
-				// Match
-				first, _ := strconv.Atoi(result1[1])
-				second, _ := strconv.Atoi(result1[2])
-				curObjIdx = first
-				objCount = second
-				insideSubsection = true
-				common.Log.Trace("xref subsection: first object: %d objects: %d", curObjIdx, objCount)
-				continue
-			}
+		partOffset := 0
+		for _, part := range strings.Split(line, "\r") {
+			// Several entries, or the last entry and the trailer keyword, may share a line when the end of
+			// line markers are missing or are single spaces.  The rest of a part matching nothing is skipped.
+			pos := 0
+			for pos < len(part) {
+				s := strings.TrimLeft(part[pos:], " \t\n\f\x00")
+				pos = len(part) - len(s)
+				if s == "" {
+					break
+				}
 
-			result2 := reXrefEntry.FindStringSubmatch(s)
-			if len(result2) == 4 {
-				if !insideSubsection {
-					common.Log.Debug("Error: Xref invalid format!")
-					return errors.New("Xref invalid format")
+				if strings.HasPrefix(s, "trailer") {
+					common.Log.Trace("found trailer, %s", s)
+					// The dictionary may follow the keyword on the same line, with or without white space in
+					// between, e.g. "trailer<<".  Position the reader right after the keyword.
+					keywordEnd := partOffset + pos + len("trailer")
+					parser.SetFileOffset(lineOffset + int64(keywordEnd))
+					parser.skipSpaces()
+					return nil
 				}
 
-				first, _ := strconv.ParseInt(result2[1], 10, 64)
-				gen, _ := strconv.Atoi(result2[2])
-				third := result2[3]
-
-				if strings.ToLower(third) == "n" && first > 1 {
-					// Object in use in the file!  Load it.
-					// Ignore free objects ('f').
-					//
-					// Some malformed writers mark the offset as 0 to
-					// indicate that the object is free, and still mark as 'n'
-					// Fairly safe to assume is free if offset is 0.
-					//
-					// Some malformed writers even seem to have values such as
-					// 1.. Assume null object for those also. That is referring
-					// to within the PDF version in the header clearly.
-					//
-					// Load if not existing or higher generation number than previous.
-					// Usually should not happen, lower generation numbers
-					// would be marked as free.  But can still happen!
-					if x, ok := parser.xrefs[curObjIdx]; !ok || gen > x.generation {
-						obj := XrefObject{
-							objectNumber: curObjIdx,
-							xtype:        XREF_TABLE_ENTRY,
-							offset:       first,
-							generation:   gen}
-						parser.xrefs[curObjIdx] = obj
+				result2 := reXrefEntry.FindStringSubmatch(s)
+				if len(result2) == 4 {
+					if !insideSubsection {
+						common.Log.Debug("Error: Xref invalid format!")
+						return errors.New("Xref invalid format")
+					}
+					pos += len(result2[0])
+
+					first, _ := strconv.ParseInt(result2[1], 10, 64)
+					gen, _ := strconv.Atoi(result2[2])
+					third := result2[3]
+
+					if strings.ToLower(third) == "f" {
+						parser.freeXref(curObjIdx)
+					} else if first > 1 && !parser.isFreedXref(curObjIdx) {
+						// Object in use in the file, not freed by a newer section!  Load it.
+						//
+						// Some malformed writers mark the offset as 0 to
+						// indicate that the object is free, and still mark as 'n'
+						// Fairly safe to assume is free if offset is 0.
+						//
+						// Some malformed writers even seem to have values such as
+						// 1.. Assume null object for those also. That is referring
+						// to within the PDF version in the header clearly.
+						//
+						// Load if not existing or higher generation number than previous.
+						// Usually should not happen, lower generation numbers
+						// would be marked as free.  But can still happen!
+						if x, ok := parser.xrefs[curObjIdx]; !ok || gen > x.generation {
+							obj := XrefObject{
+								objectNumber: curObjIdx,
+								xtype:        XREF_TABLE_ENTRY,
+								offset:       first,
+								generation:   gen}
+							parser.xrefs[curObjIdx] = obj
+						}
 					}
+
+					curObjIdx++
+					continue
 				}
 
-				curObjIdx++
-				continue
-			}
+				result1 := reXrefSubsection.FindStringSubmatch(s)
+				if len(result1) == 4 {
+					// Match
+					pos += len(result1[0])
+					first, _ := strconv.Atoi(result1[1])
+					second, _ := strconv.Atoi(result1[2])
+					curObjIdx = first
+					objCount = second
+					insideSubsection = true
+					common.Log.Trace("xref subsection: first object: %d objects: %d", curObjIdx, objCount)
+					continue
+				}
 
-			if strings.Compare(s, "%%EOF") == 0 {
-				common.Log.Debug("ERROR: end of file - trailer not found - error!")
-				return errors.New("End of file - trailer not found")
+				if strings.HasPrefix(s, "%%EOF") {
+					common.Log.Debug("ERROR: end of file - trailer not found - error!")
+					return errors.New("End of file - trailer not found")
+				}
+				break
 			}
+			partOffset += len(part) + 1
 		}
-	}
 
-	return nil
+		if err != nil {
+			// end of file without trailer
+			return err
+		}
+	}
 }
 
 // Numeric objects.
@@ -715,9 +864,17 @@ func (parser *PdfParser) readXrefTable(prevLine string) error {
 // Nonetheless, we sometimes get numbers with exponential format, so
 // we will support it in the reader (no confusion with other types, so
 // no compromise).
+//
+// Malformed numbers written by some producers are read tolerantly instead of failing the enclosing
+// object.  Repeated leading signs are collapsed and the number is negative if any of them is '-'
+// ("+-.5" is -0.5).  A second period ends the number and the rest of the token is skipped ("1.2.3"
+// is 1.2).  A token that still does not parse (e.g. no digits) is 0.
 func (parser *PdfParser) parseNumber() (PdfObject, error) {
 	isFloat := false
-	allowSigns := true
+	allowSigns := false // Signs after e (exponential), leading signs are handled separately.
+	negative := false
+	hasPeriod := false
+	skipRest := false
 	var r bytes.Buffer
 	for {
 		common.Log.Trace("Parsing number \"%s\"", r.String())
@@ -732,37 +889,73 @@ func (parser *PdfParser) parseNumber() (PdfObject, error) {
 			common.Log.Debug("ERROR %s", err)
 			return nil, err
 		}
-		if allowSigns && (bb[0] == '-' || bb[0] == '+') {
-			// Only appear in the beginning, otherwise serves as a delimiter.
-			b, _ := parser.reader.ReadByte()
-			r.WriteByte(b)
-			allowSigns = false // Only allowed in beginning, and after e (exponential).
-		} else if IsDecimalDigit(bb[0]) {
-			b, _ := parser.reader.ReadByte()
-			r.WriteByte(b)
-		} else if bb[0] == '.' {
-			b, _ := parser.reader.ReadByte()
-			r.WriteByte(b)
-			isFloat = true
-		} else if bb[0] == 'e' {
+
+		ch := bb[0]
+		if (ch == '-' || ch == '+') && r.Len() == 0 && !skipRest {
+			// Leading signs.
+			parser.reader.ReadByte()
+			if ch == '-' {
+				negative = true
+			}
+		} else if (ch == '-' || ch == '+') && allowSigns {
+			// Otherwise serves as a delimiter.
+			parser.reader.ReadByte()
+			r.WriteByte(ch)
+			allowSigns = false
+		} else if IsDecimalDigit(ch) {
+			parser.reader.ReadByte()
+			if !skipRest {
+				r.WriteByte(ch)
+			}
+			allowSigns = false
+		} else if ch == '.' {
+			parser.reader.ReadByte()
+			if hasPeriod || skipRest {
+				skipRest = true
+			} else {
+				r.WriteByte(ch)
+				hasPeriod = true
+				isFloat = true
+			}
+			allowSigns = false
+		} else if ch == 'e' {
 			// Exponential number format.
-			b, _ := parser.reader.ReadByte()
-			r.WriteByte(b)
-			isFloat = true
-			allowSigns = true
+			parser.reader.ReadByte()
+			if !skipRest {
+				r.WriteByte(ch)
+				isFloat = true
+				hasPeriod = true // The exponent is an integer.
+				allowSigns = true
+			}
 		} else {
 			break
 		}
 	}
 
+	numStr := r.String()
+	if negative {
+		numStr = "-" + numStr
+	}
+	if skipRest {
+		common.Log.Debug("Malformed number, using %q", numStr)
+	}
+
 	if isFloat {
-		fVal, err := strconv.ParseFloat(r.String(), 64)
+		fVal, err := strconv.ParseFloat(numStr, 64)
+		if err != nil {
+			common.Log.Debug("Error parsing number %q err=%v. Using 0.0. Output may be incorrect", numStr, err)
+			fVal = 0.0
+		}
 		o := PdfObjectFloat(fVal)
-		return &o, err
+		return &o, nil
 	} else {
-		intVal, err := strconv.ParseInt(r.String(), 10, 64)
+		intVal, err := strconv.ParseInt(numStr, 10, 64)
+		if err != nil {
+			common.Log.Debug("Error parsing integer %q err=%v. Using 0. Output may be incorrect", numStr, err)
+			intVal = 0
+		}
 		o := PdfObjectInteger(intVal)
-		return &o, err
+		return &o, nil
 	}
 }
 
@@ -1024,6 +1217,47 @@ func (parser *PdfParser) parseBool() (PdfObjectBool, error) {
 	return PdfObjectBool(false), errors.New("Unexpected boolean string")
 }
 
+// Longest peek of peekTokens, the tokens of a reference never get close to it.
+const maxTokensPeek = 256
+
+// Peek the next count tokens at the current file position without consuming them.  The peek window
+// grows until the tokens are complete, so that a reference with a long object number such as
+// "1234567 0 R" is not truncated.  Stops early at a delimiter starting a token.
+func (parser *PdfParser) peekTokens(count int) string {
+	for n := 16; ; n *= 2 {
+		if n > maxTokensPeek {
+			n = maxTokensPeek
+		}
+		bb, err := parser.reader.Peek(n)
+		if err != nil || n == maxTokensPeek || tokensComplete(bb, count) {
+			return string(bb)
+		}
+	}
+}
+
+// Whether bb holds count complete tokens, ended by white space or a delimiter, or a token starting
+// with a delimiter before that.
+func tokensComplete(bb []byte, count int) bool {
+	inToken := false
+	for _, b := range bb {
+		if IsWhiteSpace(b) || IsDelimiter(b) {
+			if inToken {
+				count--
+				if count == 0 {
+					return true
+				}
+				inToken = false
+			}
+			if IsDelimiter(b) {
+				return true
+			}
+		} else {
+			inToken = true
+		}
+	}
+	return false
+}
+
 // Detect the signature at the current file position and parse
 // the corresponding object.
 func (parser *PdfParser) parseObject() (PdfObject, error) {
@@ -1084,8 +1318,7 @@ func (parser *PdfParser) parseObject() (PdfObject, error) {
 			common.Log.Trace("->Number or ref?")
 			// Reference or number?
 			// Let's peek farther to find out.
-			bb, _ = parser.reader.Peek(15)
-			peekStr := string(bb)
+			peekStr := parser.peekTokens(3)
 			common.Log.Trace("Peek str: %s", peekStr)
 
 			// Match reference.
@@ -1146,6 +1379,9 @@ func (parser *PdfParser) ParseDict() (*PdfObjectDictionary, error) {
 	var err error
 
 	var keyName PdfObjectName
+	// the last entry set, to recover from a value appended to its key
+	var lastKey PdfObjectName
+	var lastVal PdfObject
 	for {
 		prevCh = currCh
 		currCh, err = parser.reader.ReadByte()
@@ -1158,6 +1394,35 @@ func (parser *PdfParser) ParseDict() (*PdfObjectDictionary, error) {
 			break
 		}
 
+		if readingValue && currCh == '>' {
+			// Key without value, the value may be appended to the key, e.g. "/Key123>>".
+			if bb, _ := parser.reader.Peek(1); len(bb) == 1 && bb[0] == '>' {
+				if newKey, val, ok := splitKeyValue(keyName); ok {
+					common.Log.Debug("Taking care of value appended to key (%s)", keyName)
+					dict.Set(newKey, val)
+				}
+				readingKey = true
+				readingValue = false
+				continue
+			}
+		}
+
+		if readingKey && lastVal != nil && isValueStart(currCh) {
+			// A value where a key is expected: the previous value was taken for the value of a key with
+			// an appended value, e.g. "/Key123 /Next 5" is Key 123 and Next 5.
+			name, isName := lastVal.(*PdfObjectName)
+			newKey, val, ok := splitKeyValue(lastKey)
+			if isName && ok {
+				common.Log.Debug("Taking care of value appended to key (%s)", lastKey)
+				dict.Remove(lastKey)
+				dict.Set(newKey, val)
+				keyName = *name
+				readingKey = false
+				readingValue = true
+				lastVal = nil
+			}
+		}
+
 		// comment
 		if currCh == '%' {
 			// why break with the comments
@@ -1198,6 +1463,8 @@ func (parser *PdfParser) ParseDict() (*PdfObjectDictionary, error) {
 				readingValue = false
 				dict.Set(keyName, val)
 				common.Log.Trace("dict[%s] = %s", keyName, val.String())
+				lastKey = keyName
+				lastVal = val
 			}
 		}
 	}
@@ -1205,6 +1472,53 @@ func (parser *PdfParser) ParseDict() (*PdfObjectDictionary, error) {
 	return dict, nil
 }
 
+// isValueStart returns true if the character starts a non-name object: a number, string, array,
+// dictionary or keyword.
+func isValueStart(ch byte) bool {
+	return IsDecimalDigit(ch) || ch == '+' || ch == '-' || ch == '.' || ch == '(' || ch == '[' || ch == '<' ||
+		ch == 't' || ch == 'f' || ch == 'n'
+}
+
+// splitKeyValue splits a value token that some writers append to a key without a space, e.g.
+// "/Boundsnull", "/Keytrue" or "/Key123".  Returns false if the key does not end with such a token.
+func splitKeyValue(key PdfObjectName) (PdfObjectName, PdfObject, bool) {
+	s := string(key)
+
+	if len(s) > 4 && strings.HasSuffix(s, "null") {
+		return PdfObjectName(s[:len(s)-4]), MakeNull(), true
+	}
+	if len(s) > 4 && strings.HasSuffix(s, "true") {
+		val := PdfObjectBool(true)
+		return PdfObjectName(s[:len(s)-4]), &val, true
+	}
+	if len(s) > 5 && strings.HasSuffix(s, "false") {
+		val := PdfObjectBool(false)
+		return PdfObjectName(s[:len(s)-5]), &val, true
+	}
+
+	i := len(s)
+	for i > 0 && (IsDecimalDigit(s[i-1]) || s[i-1] == '.') {
+		i--
+	}
+	if i < len(s) && i > 0 && (s[i-1] == '-' || s[i-1] == '+') {
+		i--
+	}
+	if i == 0 || i == len(s) {
+		return key, nil, false
+	}
+
+	numStr := s[i:]
+	if strings.Contains(numStr, ".") {
+		if val, err := strconv.ParseFloat(numStr, 64); err == nil {
+			return PdfObjectName(s[:i]), MakeFloat(val), true
+		}
+	} else if val, err := strconv.ParseInt(numStr, 10, 64); err == nil {
+		return PdfObjectName(s[:i]), MakeInteger(val), true
+	}
+
+	return key, nil, false
+}
+
 func findXrefPosition(list []int64, val int64) bool {
 	find := false
 	for i := 0; i < len(list); i++ {
@@ -1325,6 +1639,7 @@ func NewParser(rs io.ReadSeeker) (*PdfParser, error) {
 	parser.rs = rs
 	parser.ObjCache = make(ObjectCache)
 	parser.streamLengthReferenceLookupInProgress = map[int64]bool{}
+	parser.objstmLoadInProgress = map[int]bool{}
 
 	// Start by reading the xrefs (from bottom).
 	err := parser.readReferenceData()
@@ -1350,10 +1665,68 @@ func NewParser(rs io.ReadSeeker) (*PdfParser, error) {
 	return parser, nil
 }
 
+// GetRootDict returns the document catalog, the /Root of the trailer.  When the trailer lacks a valid
+// /Root, the catalog is recovered by scanning the objects for a dictionary of /Type /Catalog.  Nil if none.
 func (parser *PdfParser) GetRootDict() *PdfObjectDictionary {
+	if parser.rootDict == nil && !parser.rootScanned {
+		parser.rootScanned = true
+		parser.rootDict = parser.findCatalog()
+		if parser.rootDict != nil {
+			parser.getRoot = true
+		}
+	}
 	return parser.rootDict
 }
 
+// findCatalog scans the objects of the xref table in object number order for a dictionary of /Type
+// /Catalog, preferring one having /Pages.  Nil if none.
+func (parser *PdfParser) findCatalog() *PdfObjectDictionary {
+	common.Log.Debug("Root missing from the trailer, scanning the objects for a catalog")
+
+	objNumbers := []int{}
+	for objNumber := range parser.xrefs {
+		objNumbers = append(objNumbers, objNumber)
+	}
+	sort.Ints(objNumbers)
+
+	var catalog *PdfObjectDictionary
+	for _, objNumber := range objNumbers {
+		obj, err := parser.LookupByNumber(objNumber)
+		if err != nil {
+			continue
+		}
+		indObj, ok := obj.(*PdfIndirectObject)
+		if !ok {
+			continue
+		}
+		dict, ok := indObj.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			continue
+		}
+		if objType, ok := dict.Get("Type").(*PdfObjectName); !ok || *objType != "Catalog" {
+			continue
+		}
+		if dict.Get("Pages") != nil {
+			common.Log.Debug("Catalog found: object %d", objNumber)
+			return dict
+		}
+		if catalog == nil {
+			catalog = dict
+		}
+	}
+
+	if catalog == nil {
+		common.Log.Debug("Error: no catalog found")
+	}
+	return catalog
+}
+
+// GetPdfVersion returns the major and minor parts of the version in the file header, e.g. 1 and 7
+// for "%PDF-1.7".  0, 0 if the header has no version.
+func (parser *PdfParser) GetPdfVersion() (int, int) {
+	return parser.majorVersion, parser.minorVersion
+}
+
 func (parser *PdfParser) GetCrypter() *PdfCrypt {
 	return parser.crypter
 }
@@ -1364,11 +1737,46 @@ func (parser *PdfParser) GetTrailer() *PdfObjectDictionary {
 	return parser.trailerDict
 }
 
+// GetTrailerSize returns the /Size of the last trailer of the file, the declared number of cross reference
+// entries: one more than the highest object number.
+func (parser *PdfParser) GetTrailerSize() (int, error) {
+	if parser.trailerDict == nil {
+		return 0, errors.New("trailer missing")
+	}
+	sizeObj := parser.trailerSize
+	if sizeObj == nil {
+		sizeObj = parser.trailerDict.Get("Size")
+	}
+	sizeObj, err := parser.Trace(sizeObj)
+	if err != nil {
+		return 0, err
+	}
+	size, ok := sizeObj.(*PdfObjectInteger)
+	if !ok || *size < 0 {
+		return 0, errors.New("trailer Size missing or invalid")
+	}
+	return int(*size), nil
+}
+
+// GetXrefCount returns the number of objects in use loaded from the cross reference tables and streams, and
+// the highest of their object numbers.
+func (parser *PdfParser) GetXrefCount() (int, int) {
+	maxObjectNumber := 0
+	for objectNumber := range parser.xrefs {
+		if objectNumber > maxObjectNumber {
+			maxObjectNumber = objectNumber
+		}
+	}
+	return len(parser.xrefs), maxObjectNumber
+}
+
 func (parser *PdfParser) readReferenceData() error {
 	// use to store multi xref table offsets
 	startXrefPositions := []int64{}
 	parser.xrefs = make(XrefTable)
 	parser.objstms = make(ObjectStreams)
+	parser.xrefFreed = map[int]int{}
+	parser.xrefSection = 0
 
 	numBytes := 32
 	b := make([]byte, numBytes)
@@ -1425,8 +1833,9 @@ func (parser *PdfParser) readReferenceData() error {
 				return errors.New("Invalid xref keyword")
 			}
 
-			//parse xref table
-			if err := parser.readXrefTable(line); err != nil {
+			//parse xref table, from right after the keyword as the entries may follow on its line
+			parser.SetFileOffset(xrefOffset + int64(len("xref")))
+			if err := parser.readXrefTable(); err != nil {
 				common.Log.Debug("Error: parse xref table failed, err: %v", err)
 				return err
 			}
@@ -1441,6 +1850,12 @@ func (parser *PdfParser) readReferenceData() error {
 			if parser.trailerDict == nil {
 				parser.trailerDict = dict
 			}
+			if parser.trailerID == nil {
+				parser.trailerID = dict.Get("ID")
+			}
+			if parser.trailerSize == nil {
+				parser.trailerSize = dict.Get("Size")
+			}
 
 			//get root dict
 			if !parser.getRoot {
@@ -1477,6 +1892,7 @@ func (parser *PdfParser) readReferenceData() error {
 					return errors.New("prev not a PdfObjectInteger")
 				} else {
 					xrefOffset = int64(*xrefPrevObj)
+					parser.xrefSection++
 				}
 			}
 
@@ -1530,11 +1946,19 @@ func (parser *PdfParser) readReferenceData() error {
 				}
 			}
 
+			if parser.trailerID == nil {
+				parser.trailerID = xs.PdfObjectDictionary.Get("ID")
+			}
+			if parser.trailerSize == nil {
+				parser.trailerSize = xs.PdfObjectDictionary.Get("Size")
+			}
+
 			//parse xref table
 			if err := parser.readXrefStream(xs); err != nil {
 				common.Log.Debug("Error: parse xref stream failed, err: %v", err)
 				return err
 			}
+			parser.xrefSection++
 
 			//get root dict
 			if !parser.getRoot {
@@ -0,0 +1,371 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// makeParser returns a parser of the data without loading the cross-reference table, to parse the objects
+// directly.
+func makeParser(data string) *PdfParser {
+	parser := &PdfParser{}
+	parser.rs = bytes.NewReader([]byte(data))
+	parser.reader = bufio.NewReader(parser.rs)
+	parser.ObjCache = make(ObjectCache)
+	parser.streamLengthReferenceLookupInProgress = map[int64]bool{}
+	parser.objstmLoadInProgress = map[int]bool{}
+	return parser
+}
+
+// makePdf returns a PDF file of the objects, numbered from 1, with a cross-reference table and a trailer
+// of /Size, /Root 1 0 R and the trailer entries.
+func makePdf(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, obj := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer,
+		xref)
+	return buf.Bytes()
+}
+
+// openPdf returns a parser of the PDF file.
+func openPdf(t *testing.T, pdf []byte) *PdfParser {
+	parser, err := NewParser(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("NewParser: %v", err)
+	}
+	return parser
+}
+
+func TestParseIndirectObjectWithoutEndobj(t *testing.T) {
+	testcases := []struct {
+		name string
+		data string
+	}{
+		{"EOF", "7 0 obj\n<< /Type /Test /N 42 >>"},
+		{"EOF after newline", "7 0 obj\n<< /Type /Test /N 42 >>\n"},
+		{"comment before endobj", "7 0 obj\n<< /Type /Test /N 42 >>\n% comment\nendobj\n"},
+		{"garbage before endobj", "7 0 obj\n<< /Type /Test /N 42 >>\nextra endobj\n"},
+	}
+
+	for _, tc := range testcases {
+		obj, err := makeParser(tc.data).ParseIndirectObject()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		indirect, ok := obj.(*PdfIndirectObject)
+		if !ok || indirect.ObjectNumber != 7 {
+			t.Errorf("%s: got %T %v", tc.name, obj, obj)
+			continue
+		}
+		dict, ok := indirect.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			t.Errorf("%s: object is %T", tc.name, indirect.PdfObject)
+			continue
+		}
+		if n, ok := dict.Get("N").(*PdfObjectInteger); !ok || *n != 42 {
+			t.Errorf("%s: /N %v", tc.name, dict.Get("N"))
+		}
+	}
+}
+
+func TestLastObjectOfTruncatedFile(t *testing.T) {
+	pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")
+	parser := openPdf(t, pdf)
+
+	// cut the file right before the endobj of the last object, the xref table being read
+	truncated := bytes.Index(pdf, []byte("endobj\nxref"))
+	parser.rs = bytes.NewReader(pdf[:truncated])
+	parser.reader = bufio.NewReader(parser.rs)
+
+	obj, err := parser.LookupByNumber(2)
+	if err != nil {
+		t.Fatalf("LookupByNumber: %v", err)
+	}
+	indirect, ok := obj.(*PdfIndirectObject)
+	if !ok {
+		t.Fatalf("got %T", obj)
+	}
+	dict, ok := indirect.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		t.Fatalf("object is %T", indirect.PdfObject)
+	}
+	if objType, ok := dict.Get("Type").(*PdfObjectName); !ok || *objType != "Pages" {
+		t.Errorf("/Type %v", dict.Get("Type"))
+	}
+}
+
+func TestStreamKeywordEOL(t *testing.T) {
+	data := "\x00\x01BT\r\n(Hello) Tj\rET\n"
+	for _, eol := range []string{"\r\n", "\n", "\r"} {
+		obj, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Length %d >>\nstream%s%s\nendstream\nendobj\n", len(data), eol,
+			data)).ParseIndirectObject()
+		if err != nil {
+			t.Errorf("%q: %v", eol, err)
+			continue
+		}
+		stream, ok := obj.(*PdfObjectStream)
+		if !ok {
+			t.Errorf("%q: got %T", eol, obj)
+			continue
+		}
+		if string(stream.Stream) != data {
+			t.Errorf("%q: stream data %q, expected %q", eol, stream.Stream, data)
+		}
+	}
+}
+
+func TestParseDictAppendedValue(t *testing.T) {
+	testcases := []struct {
+		data     string
+		expected map[PdfObjectName]string
+	}{
+		{"<< /FooBarnull /N 1 >>", map[PdfObjectName]string{"FooBar": "null", "N": "1"}},
+		{"<< /N 1 /FooBarnull >>", map[PdfObjectName]string{"FooBar": "null", "N": "1"}},
+		{"<< /Footrue >>", map[PdfObjectName]string{"Foo": "true"}},
+		{"<< /Foofalse /N 1 >>", map[PdfObjectName]string{"Foo": "false", "N": "1"}},
+		{"<< /Foo123 /N 1 >>", map[PdfObjectName]string{"Foo": "123", "N": "1"}},
+		{"<< /Foo-1.5 >>", map[PdfObjectName]string{"Foo": "-1.500000"}},
+		{"<< /Foo/Bar /N 1 >>", map[PdfObjectName]string{"Foo": "Bar", "N": "1"}},
+		{"<< /Bounds /Foo123 /N 1 >>", map[PdfObjectName]string{"Bounds": "Foo123", "N": "1"}},
+	}
+
+	for _, tc := range testcases {
+		dict, err := makeParser(tc.data).ParseDict()
+		if err != nil {
+			t.Errorf("%s: %v", tc.data, err)
+			continue
+		}
+		if len(dict.Keys()) != len(tc.expected) {
+			t.Errorf("%s: got %s", tc.data, dict)
+			continue
+		}
+		for key, val := range tc.expected {
+			if obj := dict.Get(key); obj == nil || obj.String() != val {
+				t.Errorf("%s: /%s %v, expected %s", tc.data, key, obj, val)
+			}
+		}
+	}
+}
+
+func TestParseMalformedNumbers(t *testing.T) {
+	arr, err := makeParser("[+-.5 1.2.3 .5 -+3 --2 7]").parseArray()
+	if err != nil {
+		t.Fatalf("parseArray: %v", err)
+	}
+
+	expected := []float64{-0.5, 1.2, 0.5, -3, -2, 7}
+	if len(arr) != len(expected) {
+		t.Fatalf("got %s", &arr)
+	}
+	for i, val := range expected {
+		if num, err := GetNumberAsFloat(arr[i]); err != nil || num != val {
+			t.Errorf("element %d: %v, expected %v", i, arr[i], val)
+		}
+	}
+}
+
+func TestStreamLengthPastEOF(t *testing.T) {
+	data := "BT /F1 12 Tf (Hello) Tj ET"
+	testcases := []struct {
+		name, tail string
+	}{
+		{"endstream", "\nendstream\nendobj\n"},
+		{"CRLF endstream", "\r\nendstream\nendobj\n"},
+		// the file cut in the stream data
+		{"truncated", ""},
+	}
+
+	for _, tc := range testcases {
+		obj, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Length %d >>\nstream\n%s%s", len(data)+1000, data,
+			tc.tail)).ParseIndirectObject()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		stream, ok := obj.(*PdfObjectStream)
+		if !ok {
+			t.Errorf("%s: got %T", tc.name, obj)
+			continue
+		}
+		if string(stream.Stream) != data {
+			t.Errorf("%s: stream data %q", tc.name, stream.Stream)
+		}
+		if length, ok := stream.Get("Length").(*PdfObjectInteger); !ok || int(*length) != len(data) {
+			t.Errorf("%s: /Length %v", tc.name, stream.Get("Length"))
+		}
+	}
+}
+
+func TestStreamLengthNotInteger(t *testing.T) {
+	data := "BT /F1 12 Tf (Hello) Tj ET"
+	for _, length := range []string{fmt.Sprintf("%d.0", len(data)), fmt.Sprintf("(%d)", len(data))} {
+		obj, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Length %s >>\nstream\n%s\nendstream\nendobj\n", length,
+			data)).ParseIndirectObject()
+		if err != nil {
+			t.Errorf("%s: %v", length, err)
+			continue
+		}
+		stream, ok := obj.(*PdfObjectStream)
+		if !ok {
+			t.Errorf("%s: got %T", length, obj)
+			continue
+		}
+		if string(stream.Stream) != data {
+			t.Errorf("%s: stream data %q", length, stream.Stream)
+		}
+	}
+
+	if _, err := makeParser(fmt.Sprintf("1 0 obj\n<< /Length (abc) >>\nstream\n%s\nendstream\nendobj\n",
+		data)).ParseIndirectObject(); err == nil {
+		t.Errorf("no error for a non-numeric length")
+	}
+}
+
+func TestParseLongReference(t *testing.T) {
+	arr, err := makeParser("[1234567 0 R 1234567   12 R 12345678901234 5 7 0 R]").parseArray()
+	if err != nil {
+		t.Fatalf("parseArray: %v", err)
+	}
+	if len(arr) != 5 {
+		t.Fatalf("got %s", &arr)
+	}
+
+	references := []PdfObjectReference{{ObjectNumber: 1234567}, {ObjectNumber: 1234567, GenerationNumber: 12}}
+	for i, expected := range references {
+		if ref, ok := arr[i].(*PdfObjectReference); !ok || *ref != expected {
+			t.Errorf("element %d: %v, expected %v", i, arr[i], expected)
+		}
+	}
+	for i, expected := range []float64{12345678901234, 5} {
+		if num, err := GetNumberAsFloat(arr[2+i]); err != nil || num != expected {
+			t.Errorf("element %d: %v, expected %v", 2+i, arr[2+i], expected)
+		}
+	}
+	if ref, ok := arr[4].(*PdfObjectReference); !ok || ref.ObjectNumber != 7 {
+		t.Errorf("element 4: %v", arr[4])
+	}
+}
+
+func TestTrailerKeywordLayouts(t *testing.T) {
+	pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")
+	xref := bytes.Index(pdf, []byte("xref\n"))
+	testcases := []struct {
+		name, old, new string
+		crOnly         bool
+	}{
+		{"no space", "trailer\n<<", "trailer<<", false},
+		{"spaces", "trailer\n<<", "trailer  \t<<", false},
+		// bare CR line endings, the section being a single line to a reader of LF lines
+		{"CR", "trailer\n<<", "trailer\r<<", true},
+		{"CR no space", "trailer\n<<", "trailer<<", true},
+		{"CRLF", "trailer\n<<", "trailer\r\n<<", false},
+	}
+
+	for _, tc := range testcases {
+		section := bytes.Replace(pdf[xref:], []byte(tc.old), []byte(tc.new), 1)
+		if tc.crOnly {
+			section = bytes.Replace(section, []byte("\n"), []byte("\r"), -1)
+		}
+		data := append(append([]byte{}, pdf[:xref]...), section...)
+		parser, err := NewParser(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if root := parser.GetRootDict(); root == nil || root.Get("Pages") == nil {
+			t.Errorf("%s: trailer %v", tc.name, parser.GetTrailer())
+		}
+		if obj, err := parser.LookupByNumber(2); err != nil || obj == nil {
+			t.Errorf("%s: object 2 %v, err: %v", tc.name, obj, err)
+		}
+	}
+}
+
+func TestXrefEntryLayouts(t *testing.T) {
+	pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /Type /Font >>")
+	xref := bytes.Index(pdf, []byte("xref\n"))
+	testcases := []struct {
+		name      string
+		separator string
+		last      string
+	}{
+		{"standard", " \n", " \n"},
+		// the entries on a single line, the last one followed by the trailer keyword
+		{"single spaces", " ", " "},
+		{"no trailing EOL", " ", ""},
+		{"no separators", "", ""},
+		{"CR", " \r", "\r"},
+	}
+
+	for _, tc := range testcases {
+		section := bytes.Replace(pdf[xref:], []byte(" \ntrailer"), []byte(tc.last+"trailer"), 1)
+		section = bytes.Replace(section, []byte(" \n"), []byte(tc.separator), -1)
+		data := append(append([]byte{}, pdf[:xref]...), section...)
+		parser, err := NewParser(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if root := parser.GetRootDict(); root == nil || root.Get("Pages") == nil {
+			t.Errorf("%s: trailer %v", tc.name, parser.GetTrailer())
+		}
+		for objNum := 1; objNum <= 3; objNum++ {
+			if xref, ok := parser.xrefs[objNum]; !ok || xref.xtype != XREF_TABLE_ENTRY {
+				t.Errorf("%s: xref of object %d %v", tc.name, objNum, xref)
+			}
+		}
+		if obj, err := parser.LookupByNumber(3); err != nil || obj == nil {
+			t.Errorf("%s: object 3 %v, err: %v", tc.name, obj, err)
+		}
+	}
+}
+
+func TestRecoverRoot(t *testing.T) {
+	testcases := []struct {
+		name    string
+		objects []string
+		root    int64
+	}{
+		{"catalog", []string{"<< /Type /Pages /Kids [] /Count 0 >>", "<< /Type /Catalog /Pages 1 0 R >>"}, 2},
+		// a catalog with /Pages is preferred
+		{"two catalogs", []string{"<< /Type /Catalog >>", "<< /Type /Pages /Kids [] /Count 0 >>",
+			"<< /Type /Catalog /Pages 2 0 R >>"}, 3},
+	}
+
+	for _, tc := range testcases {
+		pdf := bytes.Replace(makePdf("", tc.objects...), []byte("/Root 1 0 R "), nil, 1)
+		parser := openPdf(t, pdf)
+		root := parser.GetRootDict()
+		if root == nil {
+			t.Errorf("%s: no root", tc.name)
+			continue
+		}
+		obj, err := parser.LookupByNumber(int(tc.root))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if indirect, ok := obj.(*PdfIndirectObject); !ok || indirect.PdfObject != root {
+			t.Errorf("%s: root %v", tc.name, root)
+		}
+	}
+}
@@ -6,6 +6,8 @@
 package core
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 
 	"../common"
@@ -70,9 +72,12 @@ func NewEncoderFromStream(streamObj *PdfObjectStream) (StreamEncoder, error) {
 	} else if *method == StreamEncodingFilterNameCCITTFax {
 		return NewCCITTFaxEncoder(), nil
 	} else if *method == StreamEncodingFilterNameJBIG2 {
-		return NewJBIG2Encoder(), nil
+		return newJBIG2EncoderFromStream(streamObj, nil)
 	} else if *method == StreamEncodingFilterNameJPX {
 		return NewJPXEncoder(), nil
+	} else if *method == StreamEncodingFilterNameCrypt {
+		// Decrypted when the stream is loaded, see PdfCrypt.Decrypt.
+		return NewRawEncoder(), nil
 	} else {
 		common.Log.Debug("ERROR: Unsupported encoding method!")
 		return nil, fmt.Errorf("Unsupported encoding method (%s)", *method)
@@ -80,24 +85,51 @@ func NewEncoderFromStream(streamObj *PdfObjectStream) (StreamEncoder, error) {
 }
 
 // DecodeStream decodes the stream data and returns the decoded data.
-// An error is returned upon failure.
+// An error is returned upon failure.  When the data is truncated or corrupt, the bytes decoded so far are
+// returned along with ErrTruncatedStream or ErrCorruptStream, for callers tolerating partial data.
 func DecodeStream(streamObj *PdfObjectStream) ([]byte, error) {
+	decoded, _, err := DecodeStreamEx(streamObj)
+	return decoded, err
+}
+
+// DecodeStreamEx decodes the stream data like DecodeStream, and also returns the names of the filters
+// applied, in order.  The Crypt filter is not listed as it is applied when the stream is loaded.
+func DecodeStreamEx(streamObj *PdfObjectStream) ([]byte, []string, error) {
 	common.Log.Trace("Decode stream")
 
+	// Nothing to decode for empty streams, whatever the filters.
+	if len(bytes.TrimSpace(streamObj.Stream)) == 0 {
+		return []byte{}, []string{}, nil
+	}
+
 	encoder, err := NewEncoderFromStream(streamObj)
 	if err != nil {
 		common.Log.Debug("Stream decoding failed: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 	common.Log.Trace("Encoder: %#v\n", encoder)
 
+	filters := []string{}
+	if menc, ok := encoder.(*MultiEncoder); ok {
+		for _, enc := range menc.encoders {
+			filters = append(filters, enc.GetFilterName())
+		}
+	} else if _, ok := encoder.(*RawEncoder); !ok {
+		filters = append(filters, encoder.GetFilterName())
+	}
+
 	decoded, err := encoder.DecodeStream(streamObj)
+	if errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptStream) {
+		// A soft error, the data decoded so far is returned with it.
+		common.Log.Debug("Stream data %v, %d bytes decoded", err, len(decoded))
+		return decoded, filters, err
+	}
 	if err != nil {
 		common.Log.Debug("Stream decoding failed: %v", err)
-		return nil, err
+		return nil, filters, err
 	}
 
-	return decoded, nil
+	return decoded, filters, nil
 }
 
 // EncodeStream encodes the stream data using the encoded specified by the stream's dictionary.
@@ -0,0 +1,47 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeStreamExFilters(t *testing.T) {
+	text := "BT /F1 12 Tf (Hello) Tj ET"
+	encoder := NewMultiEncoder()
+	encoder.AddEncoder(NewASCII85Encoder())
+	encoder.AddEncoder(NewFlateEncoder())
+	encoded, err := encoder.EncodeBytes([]byte(text))
+	if err != nil {
+		t.Fatalf("EncodeBytes: %v", err)
+	}
+	dict := MakeDict()
+	dict.Set("Filter", MakeArray(MakeName(StreamEncodingFilterNameASCII85), MakeName(StreamEncodingFilterNameFlate)))
+	dict.Set("Length", MakeInteger(int64(len(encoded))))
+	streamObj := &PdfObjectStream{PdfObjectDictionary: dict, Stream: encoded}
+
+	decoded, filters, err := DecodeStreamEx(streamObj)
+	if err != nil {
+		t.Fatalf("DecodeStreamEx: %v", err)
+	}
+	if string(decoded) != text {
+		t.Errorf("decoded %q", decoded)
+	}
+	expected := []string{StreamEncodingFilterNameASCII85, StreamEncodingFilterNameFlate}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("filters %v, expected %v", filters, expected)
+	}
+
+	// no filter applied to an unfiltered stream
+	streamObj, err = MakeStream([]byte(text), NewRawEncoder())
+	if err != nil {
+		t.Fatalf("MakeStream: %v", err)
+	}
+	if _, filters, err := DecodeStreamEx(streamObj); err != nil || len(filters) != 0 {
+		t.Errorf("raw stream filters %v, err: %v", filters, err)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"sort"
+	"strings"
+
+	"../model"
+)
+
+// ExtractAnnotationTextMarks returns the glyphs shown by the annotation appearances of the page (0 based
+// index), such as filled-in form fields, positioned in points on the page at the annotation rectangles so
+// that they can be merged with the marks of the page content.  The fonts should have been parsed with
+// ParseFonts beforehand, as fonts shared with the pages are reused.
+func ExtractAnnotationTextMarks(reader *model.PdfReader, pageIndex int) ([]TextMark, error) {
+	appearances, err := reader.GetPageAnnotationAppearances(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	userUnit, err := reader.GetPageUserUnit(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	marks := []TextMark{}
+	for _, appearance := range appearances {
+		e := New(string(appearance.Content), appearance.Fonts)
+		e.SetUserUnit(userUnit)
+		appearanceMarks, err := e.extractTextMarks(matrix(appearance.Matrix).mult(e.unitMatrix()))
+		if err != nil {
+			e.log().Debug("Error: annotation appearance partly parsed, err: %v", err)
+		}
+		marks = append(marks, appearanceMarks...)
+	}
+
+	return marks, nil
+}
+
+// ExtractAnnotationText returns the text of the annotation appearances of the page (0 based index), one
+// annotation per line, ordered by position: top to bottom then left to right.  Annotations without text are
+// skipped.
+func ExtractAnnotationText(reader *model.PdfReader, pageIndex int) (string, error) {
+	appearances, err := reader.GetPageAnnotationAppearances(pageIndex)
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(appearances, func(i, j int) bool {
+		a, b := appearances[i].Rect, appearances[j].Rect
+		if a[3] != b[3] {
+			return a[3] > b[3]
+		}
+		return a[0] < b[0]
+	})
+
+	lines := []string{}
+	for _, appearance := range appearances {
+		e := New(string(appearance.Content), appearance.Fonts)
+		text, err := e.ExtractText()
+		if err != nil {
+			e.log().Debug("Error: annotation appearance partly parsed, err: %v", err)
+		}
+		text = strings.TrimSpace(text)
+		if text != "" {
+			lines = append(lines, text)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,47 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+)
+
+func TestAnnotationText(t *testing.T) {
+	// a filled-in text field, its value shown by its normal appearance
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Annots [5 0 R] "+
+			"/Resources << /Font << /F1 "+helveticaFont+" >> >> >>",
+		makeStream("", "BT /F1 12 Tf 72 700 Td (Name:) Tj ET"),
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (name) /V (John Doe) /Rect [100 600 300 620] "+
+			"/AP << /N 6 0 R >> >>",
+		makeStream("/Type /XObject /Subtype /Form /BBox [0 0 200 20] /Resources << /Font << /Helv "+
+			helveticaFont+" >> >>", "/Tx BMC BT /Helv 12 Tf 2 5 Td (John Doe) Tj ET EMC"))
+	reader := openPdf(t, pdf)
+
+	text, err := ExtractAnnotationText(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractAnnotationText: %v", err)
+	}
+	if text != "John Doe" {
+		t.Errorf("text %q", text)
+	}
+
+	// placed at the annotation rectangle
+	marks, err := ExtractAnnotationTextMarks(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractAnnotationTextMarks: %v", err)
+	}
+	if len(marks) != len("John Doe") || marks[0].Text != "J" || marks[0].X != 102 || marks[0].Y != 605 {
+		t.Errorf("marks %+v", marks)
+	}
+
+	// not part of the page content
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "Name:" {
+		t.Errorf("page text %q", text)
+	}
+}
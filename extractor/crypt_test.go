@@ -0,0 +1,155 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"fmt"
+	"strings"
+	"testing"
+
+	"../core"
+	"../model"
+)
+
+// passwordPadding pads the passwords of the standard security handler.
+var passwordPadding = []byte("\x28\xBF\x4E\x5E\x4E\x75\x8A\x41\x64\x00\x4E\x56\xFF\xFA\x01\x08" +
+	"\x2E\x2E\x00\xB6\xD0\x68\x3E\x80\x2F\x0C\xA9\xFE\x64\x53\x69\x7A")
+
+// rc4Bytes returns the data encrypted, or decrypted, with RC4 and the key.
+func rc4Bytes(t *testing.T, key, data []byte) []byte {
+	cipher, err := rc4.NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	out := make([]byte, len(data))
+	cipher.XORKeyStream(out, data)
+	return out
+}
+
+// padPassword returns the password padded or truncated to 32 bytes.
+func padPassword(password string) []byte {
+	return append([]byte(password), passwordPadding...)[:32]
+}
+
+// rc4Pdf returns a page of the content, encrypted by the standard security handler, revision 2 with a 40
+// bit RC4 key, with the user password.  The encryption is computed as the PDF reference describes it,
+// independently of the parser.  The content stream has the filter, and the encryption dictionary the extra
+// entries.
+func rc4Pdf(t *testing.T, userPassword string, content []byte, filter, encryptEntries string) []byte {
+	permissions := int32(-4)
+	id := []byte("0123456789abcdef")
+
+	// O: the padded user password encrypted with the key of the padded owner password
+	ownerKey := md5.Sum(padPassword("owner"))
+	o := rc4Bytes(t, ownerKey[:5], padPassword(userPassword))
+
+	// the file key from the padded user password, O, P and the first ID, U the padding encrypted with it
+	h := md5.New()
+	h.Write(padPassword(userPassword))
+	h.Write(o)
+	p := uint32(permissions)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(id)
+	fileKey := h.Sum(nil)[:5]
+	u := rc4Bytes(t, fileKey, passwordPadding)
+
+	// the key of an object from the file key and the object and generation numbers
+	objectKey := func(objNum int) []byte {
+		sum := md5.Sum(append(append([]byte{}, fileKey...), byte(objNum), byte(objNum>>8), byte(objNum>>16), 0, 0))
+		return sum[:10]
+	}
+
+	stream := rc4Bytes(t, objectKey(4), content)
+	title := rc4Bytes(t, objectKey(6), []byte("Secret title"))
+	return makePdf(fmt.Sprintf("/Encrypt 5 0 R /Info 6 0 R /ID [<%X> <%X>]", id, id),
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 "+helveticaFont+" >> >> >>",
+		makeStream(filter, string(stream)),
+		fmt.Sprintf("<< /Filter /Standard /V 1 /R 2 /O <%X> /U <%X> /P %d %s >>", o, u, permissions,
+			encryptEntries),
+		fmt.Sprintf("<< /Title <%X> >>", title))
+}
+
+func TestRC4Decryption(t *testing.T) {
+	content := "BT /F1 12 Tf 72 712 Td (Hello RC4) Tj ET"
+	flated, err := core.NewFlateEncoder().EncodeBytes([]byte(content))
+	if err != nil {
+		t.Fatalf("EncodeBytes: %v", err)
+	}
+
+	testcases := []struct {
+		name           string
+		content        []byte
+		filter         string
+		encryptEntries string
+	}{
+		{"uncompressed", []byte(content), "", ""},
+		{"Flate", flated, "/Filter /FlateDecode", ""},
+		// Length only applies to V 2 and later
+		{"Length", []byte(content), "", "/Length 41"},
+	}
+	for _, tc := range testcases {
+		reader := openPdf(t, rc4Pdf(t, "", tc.content, tc.filter, tc.encryptEntries))
+		if !reader.IsEncrypted() {
+			t.Errorf("%s: not encrypted", tc.name)
+		}
+		if text := extractText(t, pageExtractor(t, reader, 0)); text != "Hello RC4" {
+			t.Errorf("%s: got %q", tc.name, text)
+		}
+
+		info, err := reader.GetParser().Trace(reader.GetParser().GetTrailer().Get("Info"))
+		if err != nil {
+			t.Errorf("%s: Info: %v", tc.name, err)
+			continue
+		}
+		if dict, ok := info.(*core.PdfObjectDictionary); !ok || dict.Get("Title") == nil ||
+			dict.Get("Title").String() != "Secret title" {
+			t.Errorf("%s: Info %v", tc.name, info)
+		}
+	}
+}
+
+func TestNoDecrypt(t *testing.T) {
+	pdf := rc4Pdf(t, "secret", []byte("BT /F1 12 Tf 72 712 Td (Hello RC4) Tj ET"), "", "")
+	if _, err := model.NewPdfReader(bytes.NewReader(pdf)); err == nil {
+		t.Errorf("NewPdfReader: no error with a user password")
+	}
+
+	reader, err := model.NewPdfReaderNoDecrypt(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("NewPdfReaderNoDecrypt: %v", err)
+	}
+	if !reader.IsEncrypted() {
+		t.Errorf("not encrypted")
+	}
+	if method := reader.GetEncryptionMethod(); !strings.HasPrefix(method, "Standard - RC4: 40 bits") {
+		t.Errorf("method %q", method)
+	}
+	if _, err := reader.GetPageContent(0); err != model.ErrNeedPassword {
+		t.Errorf("GetPageContent: %v", err)
+	}
+	if err := reader.ParseFonts(); err != model.ErrNeedPassword {
+		t.Errorf("ParseFonts: %v", err)
+	}
+
+	if ok, err := reader.Decrypt([]byte("wrong")); ok || err != nil {
+		t.Errorf("wrong password: %v, err: %v", ok, err)
+	}
+	if ok, err := reader.Decrypt([]byte("secret")); !ok || err != nil {
+		t.Fatalf("Decrypt: %v, err: %v", ok, err)
+	}
+	if err := reader.ParseFonts(); err != nil {
+		t.Fatalf("ParseFonts: %v", err)
+	}
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "Hello RC4" {
+		t.Errorf("got %q", text)
+	}
+}
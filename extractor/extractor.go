@@ -5,19 +5,240 @@
 
 package extractor
 
-import "../model"
+import (
+	"../common"
+	"../core"
+	"../model"
+)
 
 // Extractor stores and offers functionality for extracting content from PDF pages.
 type Extractor struct {
 	contents     string
 	fontNamesMap model.FontsByNames
+
+	// Output the CID strings instead of unicode text.
+	outputCids bool
+	// Charset to transcode the extracted text to, UTF-8 if empty.
+	outputCharset string
+	// Unicode encoding form of the extracted text, when not transcoded to a charset.
+	outputEncoding OutputEncoding
+	// What to output for character codes that can't be mapped to unicode.
+	unmappedMode UnmappedMode
+	// Characters removed from the output, none if empty.
+	stripChars string
+	// What to output for the private use characters.
+	puaMode PUAMode
+
+	// Glyph displacements (wx, wy) declared by d0/d1 in the CharProcs of Type3 fonts.
+	type3Widths map[*model.Font]map[byte][2]float64
+
+	// Output a separator between text objects starting at a lower baseline.
+	paragraphBreaks    bool
+	paragraphSeparator string
+
+	// Output a single newline at every move to another baseline.
+	baselineNewlines bool
+
+	// Names of the /Properties resources of the optional content whose text is skipped.
+	hiddenProperties map[string]bool
+
+	// Markers (open, close) wrapped around superscript and subscript runs.
+	scriptMarkers      bool
+	superscriptMarkers [2]string
+	subscriptMarkers   [2]string
+
+	// Text marks of glyphs of a smaller effective font size are not output.
+	minFontSize float64
+
+	// A horizontal gap between glyphs wider than this fraction of the font size separates words.
+	wordGapRatio float64
+
+	// Size of a user space unit in points, the /UserUnit of the page, 0 for 1.0.
+	userUnit float64
+
+	// Reader and resources of the content stream, in which the form XObjects drawn by Do are looked up.  The
+	// forms are not drawn if reader is nil.
+	reader    *model.PdfReader
+	resources *core.PdfObjectDictionary
+	// Nesting depth of the form XObject of the content stream, 0 for a page.
+	formDepth int
+
+	// Logger of the extractor, common.Log if nil.
+	logger common.Logger
 }
 
+// UnmappedMode specifies what is output for character codes that can't be mapped to unicode.
+type UnmappedMode int
+
+const (
+	// UnmappedReplace outputs U+FFFD for each unmapped code, so the output is always valid UTF-8.
+	UnmappedReplace UnmappedMode = iota
+	// UnmappedDrop omits unmapped codes from the output.
+	UnmappedDrop
+	// UnmappedRaw outputs the raw bytes of unmapped codes.
+	UnmappedRaw
+)
+
+// OutputEncoding specifies the Unicode encoding form of the extracted text, for tools expecting a byte order
+// mark or UTF-16.
+type OutputEncoding int
+
+const (
+	// OutputUTF8 outputs UTF-8 without a byte order mark.
+	OutputUTF8 OutputEncoding = iota
+	// OutputUTF8BOM outputs UTF-8 prefixed with the byte order mark EF BB BF.
+	OutputUTF8BOM
+	// OutputUTF16LE outputs UTF-16 little-endian prefixed with the byte order mark FF FE.
+	OutputUTF16LE
+	// OutputUTF16BE outputs UTF-16 big-endian prefixed with the byte order mark FE FF.
+	OutputUTF16BE
+)
+
+// PUAMode specifies what is output for the characters of the Private Use Areas (U+E000 to U+F8FF and the
+// planes 15 and 16), which symbolic fonts and their ToUnicode CMaps often map to.
+type PUAMode int
+
+const (
+	// PUAKeep outputs the private use characters as is.
+	PUAKeep PUAMode = iota
+	// PUADrop omits the private use characters from the output.
+	PUADrop
+	// PUAReplace outputs U+FFFD for each private use character.
+	PUAReplace
+)
+
+// DefaultStripChars are the invisible format characters polluting search indexes: soft hyphen, zero width
+// space, zero width non-joiner, zero width joiner and byte order mark (zero width no-break space).
+const DefaultStripChars = "\u00AD\u200B\u200C\u200D\uFEFF"
+
+// DefaultWordGapRatio is the default word gap ratio, see SetWordGapRatio.
+const DefaultWordGapRatio = 0.2
+
 // New returns an Extractor instance for extracting content from the input PDF page.
 func New(contents string, f model.FontsByNames) *Extractor {
 	e := &Extractor{}
 	e.contents = contents
 	e.fontNamesMap = f
+	e.paragraphSeparator = "\n\n"
+	e.wordGapRatio = DefaultWordGapRatio
 
 	return e
 }
+
+// SetOutputCids sets whether the raw CID strings are extracted instead of unicode text.
+// For fonts with a predefined CMap the character codes are converted to CIDs, otherwise the
+// character codes are output unchanged.
+func (e *Extractor) SetOutputCids(flag bool) {
+	e.outputCids = flag
+}
+
+// SetOutputCharset sets the charset the extracted text is transcoded to, e.g. "gbk".
+// The charset names are those of the WHATWG Encoding Standard. An empty charset means UTF-8.
+func (e *Extractor) SetOutputCharset(charset string) {
+	e.outputCharset = charset
+}
+
+// SetOutputEncoding sets the Unicode encoding form of the text returned by ExtractText, the bytes of the
+// returned string being those of the encoding.  The default is OutputUTF8.  Ignored when an output charset is
+// set with SetOutputCharset.
+func (e *Extractor) SetOutputEncoding(encoding OutputEncoding) {
+	e.outputEncoding = encoding
+}
+
+// SetUnmappedMode sets what is output for character codes that can't be mapped to unicode.
+// The default is UnmappedReplace.
+func (e *Extractor) SetUnmappedMode(mode UnmappedMode) {
+	e.unmappedMode = mode
+}
+
+// SetStripChars sets the characters removed from the extracted text, e.g. DefaultStripChars.  None are
+// removed by default.  The CID strings output with SetOutputCids are not affected.
+func (e *Extractor) SetStripChars(chars string) {
+	e.stripChars = chars
+}
+
+// SetPUAMode sets what is output for the private use characters, meaningless to search.  The default is
+// PUAKeep.  The CID strings output with SetOutputCids are not affected.
+func (e *Extractor) SetPUAMode(mode PUAMode) {
+	e.puaMode = mode
+}
+
+// SetParagraphBreaks sets whether text objects (BT ... ET) are taken as paragraphs: the paragraph separator
+// is output when a text object starts at a baseline sufficiently lower than the end of the previous one.
+// Off by default.
+func (e *Extractor) SetParagraphBreaks(flag bool) {
+	e.paragraphBreaks = flag
+}
+
+// SetParagraphSeparator sets the separator output between paragraphs, a blank line by default.
+func (e *Extractor) SetParagraphSeparator(separator string) {
+	e.paragraphSeparator = separator
+}
+
+// SetBaselineNewlines sets whether ExtractText outputs exactly one newline at each move of the text
+// position to another baseline by T*, ', ", Td, TD or Tm.  By default a Tm move starting to the right of
+// the estimated end of the previous line outputs a second one.  Off by default.
+func (e *Extractor) SetBaselineNewlines(flag bool) {
+	e.baselineNewlines = flag
+}
+
+// SetHiddenProperties sets the names of the /Properties resources of the hidden optional content (layers),
+// e.g. from PdfReader.GetPageHiddenProperties.  The text of the marked content /OC /name BDC ... EMC of these
+// names is skipped.  All the text is extracted by default.
+func (e *Extractor) SetHiddenProperties(names map[string]bool) {
+	e.hiddenProperties = names
+}
+
+// SetScriptMarkers sets the markers ExtractText wraps around the runs of superscript and subscript text,
+// e.g. "^{", "}", "_{", "}", so that x with a raised 2 is output as x^{2}.  The runs are detected from the
+// offset of their baseline, by Ts or a small Td, to the normal text of the line.  No markers are output by
+// default.
+func (e *Extractor) SetScriptMarkers(superOpen, superClose, subOpen, subClose string) {
+	e.scriptMarkers = true
+	e.superscriptMarkers = [2]string{superOpen, superClose}
+	e.subscriptMarkers = [2]string{subOpen, subClose}
+}
+
+// SetMinFontSize sets the minimum effective font size, in points including the scaling of the text matrix,
+// the CTM and the user unit, of the text ExtractText and ExtractTextMarks return, e.g. to extract headings
+// only.  0, the default, returns all the text.
+func (e *Extractor) SetMinFontSize(size float64) {
+	e.minFontSize = size
+}
+
+// SetWordGapRatio sets the fraction of the font size above which a horizontal gap between glyphs separates
+// words, rather than being kerning: a space is inserted in the gap by ExtractPositionedText, and in the TJ
+// displacements by ExtractText, and the lines of ExtractMarkdown are split into words there.  Raise it for
+// tightly kerned text split by spurious spaces, lower it for words run together.  DefaultWordGapRatio by
+// default.
+func (e *Extractor) SetWordGapRatio(ratio float64) {
+	e.wordGapRatio = ratio
+}
+
+// SetUserUnit sets the /UserUnit of the page, e.g. from PdfReader.GetPageUserUnit, by which the positions,
+// widths and font sizes of the text marks are scaled from user space units to points (1/72 inch).  1.0 by
+// default.
+func (e *Extractor) SetUserUnit(unit float64) {
+	e.userUnit = unit
+}
+
+// SetFormXObjects sets the reader and the resources of the content stream, e.g. those of the page from
+// GetPageResources, so that ExtractTextMarks and ExtractGlyphs draw the form XObjects shown by Do: the glyphs
+// of a form are transformed by its /Matrix, and those whose origin falls outside of its /BBox are clipped.
+// The forms are not drawn by default.
+func (e *Extractor) SetFormXObjects(reader *model.PdfReader, resources *core.PdfObjectDictionary) {
+	e.reader = reader
+	e.resources = resources
+}
+
+// SetLogger sets the logger of the extractor, the global common.Log is used if nil.
+func (e *Extractor) SetLogger(logger common.Logger) {
+	e.logger = logger
+}
+
+func (e *Extractor) log() common.Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return common.Log
+}
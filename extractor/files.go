@@ -0,0 +1,86 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"../model"
+)
+
+// ExtractFile extracts the text of the PDF file at path, the pages joined with DefaultPageSeparator.
+func ExtractFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	reader, err := model.NewPdfReader(f)
+	if err != nil {
+		return "", err
+	}
+	if err := reader.ParseFonts(); err != nil {
+		return "", err
+	}
+
+	return JoinPageTexts(reader, DefaultPageSeparator)
+}
+
+// ExtractFiles extracts the text of the PDF files with ExtractFile, concurrency files at a time (at least
+// one).  Returns the text of the files extracted and the error of the others, keyed by path: a file that
+// fails doesn't stop the others.
+func ExtractFiles(paths []string, concurrency int) (map[string]string, map[string]error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	texts := map[string]string{}
+	errs := map[string]error{}
+	var mutex sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				text, err := extractFileSafe(path)
+
+				mutex.Lock()
+				if err != nil {
+					errs[path] = err
+				} else {
+					texts[path] = text
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	return texts, errs
+}
+
+// extractFileSafe is ExtractFile returning a panic of the parsing of a malformed file as an error, which
+// would otherwise end the whole batch.
+func extractFileSafe(path string) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("extraction of %s failed: %v", path, r)
+		}
+	}()
+
+	return ExtractFile(path)
+}
@@ -0,0 +1,56 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	expected := map[string]string{}
+	paths := []string{}
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("good%d.pdf", i))
+		text := fmt.Sprintf("File %d", i)
+		pdf := pagePdf(fmt.Sprintf("BT /F1 12 Tf 72 712 Td (%s) Tj ET", text), helveticaFont, "")
+		if err := ioutil.WriteFile(path, pdf, 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		expected[path] = text
+		paths = append(paths, path)
+	}
+	corrupt := filepath.Join(dir, "corrupt.pdf")
+	if err := ioutil.WriteFile(corrupt, []byte("%PDF-1.4\nnot a pdf\n"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	missing := filepath.Join(dir, "missing.pdf")
+	paths = append(paths, corrupt, missing)
+
+	texts, errs := ExtractFiles(paths, 2)
+	if len(texts) != len(expected) {
+		t.Errorf("got %d texts", len(texts))
+	}
+	for path, text := range expected {
+		if texts[path] != text || errs[path] != nil {
+			t.Errorf("%s: got %q, err: %v", path, texts[path], errs[path])
+		}
+	}
+	for _, path := range []string{corrupt, missing} {
+		if _, ok := texts[path]; ok || errs[path] == nil {
+			t.Errorf("%s: no error", path)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"../model"
+)
+
+func init() {
+	model.SetCMapResourceDir("../resources/")
+}
+
+// Fonts of the page resources of the fixtures.
+const (
+	helveticaFont = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+	// Type0 font of the predefined UniGB-UCS2-H CMap, the codes being UCS-2.
+	cjkFont = "<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H " +
+		"/DescendantFonts [<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 5 >> /DW 1000 >>] >>"
+)
+
+// makePdf returns a PDF file of the objects, numbered from 1, with a cross-reference table and a trailer
+// of /Size, /Root 1 0 R and the trailer entries.
+func makePdf(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, obj := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer,
+		xref)
+	return buf.Bytes()
+}
+
+// makeStream returns a stream object of the data, with the entries of dict besides /Length.
+func makeStream(dict, data string) string {
+	return fmt.Sprintf("<< /Length %d %s >>\nstream\n%s\nendstream", len(data), dict, data)
+}
+
+// pagePdf returns a PDF file of a page of the content, the font F1 and the objects, numbered from 5 on,
+// and with the entries of resources added to the page resources.
+func pagePdf(content, font, resources string, objects ...string) []byte {
+	return makePdf("", append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R " +
+			"/Resources << /Font << /F1 " + font + " >> " + resources + " >> >>",
+		makeStream("", content),
+	}, objects...)...)
+}
+
+// pagesPdf returns a PDF file of a page of each content, the pages sharing the font F1, object 3, and being
+// followed by their content from object 4 on.
+func pagesPdf(font string, contents ...string) []byte {
+	kids := ""
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", "", font}
+	for i, content := range contents {
+		kids += fmt.Sprintf("%d 0 R ", 4+2*i)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] "+
+			"/Contents %d 0 R /Resources << /Font << /F1 3 0 R >> >> >>", 5+2*i), makeStream("", content))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(contents))
+	return makePdf("", objects...)
+}
+
+// openPdf returns a reader of the PDF file, with its fonts parsed.
+func openPdf(t *testing.T, pdf []byte) *model.PdfReader {
+	reader, err := model.NewPdfReader(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("NewPdfReader: %v", err)
+	}
+	if err := reader.ParseFonts(); err != nil {
+		t.Fatalf("ParseFonts: %v", err)
+	}
+	return reader
+}
+
+// pageExtractor returns an extractor of the page (0 based index) of the reader.
+func pageExtractor(t *testing.T, reader *model.PdfReader, pageIndex int) *Extractor {
+	content, err := reader.GetPageContent(pageIndex)
+	if err != nil {
+		t.Fatalf("GetPageContent: %v", err)
+	}
+	return New(string(content), reader.GetFontsForPages()[pageIndex])
+}
+
+// contentExtractor returns an extractor of a page of the content and the font F1.
+func contentExtractor(t *testing.T, content, font string) *Extractor {
+	return pageExtractor(t, openPdf(t, pagePdf(content, font, "")), 0)
+}
+
+// extractText returns the text extracted by e, failing on errors.
+func extractText(t *testing.T, e *Extractor) string {
+	text, err := e.ExtractText()
+	if err != nil {
+		t.Fatalf("ExtractText: %v", err)
+	}
+	return text
+}
@@ -0,0 +1,48 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"../contentstream"
+	"../core"
+)
+
+// hiddenContent follows the nesting of the marked content sequences to tell whether the operators are in
+// hidden optional content: a sequence /OC /name BDC with name in properties, or nested in one.
+type hiddenContent struct {
+	properties map[string]bool
+	stack      []bool // whether each enclosing sequence is hidden
+	depth      int    // number of hidden sequences in stack
+}
+
+// process updates the nesting with the marked content operators BMC, BDC and EMC.
+func (h *hiddenContent) process(op *contentstream.ContentStreamOperation) {
+	switch op.Operand {
+	case "BMC", "BDC":
+		hidden := false
+		if op.Operand == "BDC" && len(op.Params) == 2 && len(h.properties) > 0 {
+			tag, _ := op.Params[0].(*core.PdfObjectName)
+			name, _ := op.Params[1].(*core.PdfObjectName)
+			hidden = tag != nil && *tag == "OC" && name != nil && h.properties[string(*name)]
+		}
+		h.stack = append(h.stack, hidden)
+		if hidden {
+			h.depth++
+		}
+	case "EMC":
+		if len(h.stack) > 0 {
+			if h.stack[len(h.stack)-1] {
+				h.depth--
+			}
+			h.stack = h.stack[:len(h.stack)-1]
+		}
+	}
+}
+
+// hidden returns whether the current operator is in hidden optional content.
+func (h *hiddenContent) hidden() bool {
+	return h.depth > 0
+}
@@ -0,0 +1,51 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHiddenLayers(t *testing.T) {
+	// a watermark layer off by default, nested marked content and a visible layer
+	content := "BT /F1 12 Tf 72 700 Td (Visible one) Tj ET " +
+		"/OC /W BDC BT /F1 48 Tf 1 0 0 1 150 400 Tm (DRAFT) Tj /Span BMC ( nested) Tj EMC ET EMC " +
+		"/OC /N BDC BT /F1 12 Tf 72 686 Td (Shown layer) Tj ET EMC " +
+		"BT /F1 12 Tf 72 672 Td (Visible two) Tj ET"
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R /OCProperties << /OCGs [5 0 R 6 0 R] /D << /OFF [5 0 R] >> >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << "+
+			"/Font << /F1 "+helveticaFont+" >> /Properties << /W 5 0 R /N 6 0 R >> >> >>",
+		makeStream("", content),
+		"<< /Type /OCG /Name (Watermark) >>",
+		"<< /Type /OCG /Name (Notes) >>")
+	reader := openPdf(t, pdf)
+
+	hidden, err := reader.GetPageHiddenProperties(0)
+	if err != nil {
+		t.Fatalf("GetPageHiddenProperties: %v", err)
+	}
+	e := pageExtractor(t, reader, 0)
+	e.SetHiddenProperties(hidden)
+	if text := extractText(t, e); text != "Visible one\nShown layer\nVisible two" {
+		t.Errorf("got %q", text)
+	}
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	for _, mark := range marks {
+		if mark.Text == "D" {
+			t.Errorf("hidden mark %v", mark)
+		}
+	}
+
+	if text := extractText(t, pageExtractor(t, reader, 0)); !strings.Contains(text, "DRAFT nested") {
+		t.Errorf("by default: got %q", text)
+	}
+}
@@ -0,0 +1,181 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"../common"
+	"../model"
+)
+
+const (
+	// Lines of a font size larger than the body size by this ratio are headings.
+	headingSizeRatio = 1.15
+	// Font sizes are clustered to multiples of this, in user space units.
+	fontSizeStep = 0.5
+	// A gap between baselines larger than this fraction of the font size separates paragraphs.
+	paragraphGapRatio = 1.5
+	// Markdown has six heading levels.
+	maxHeadingLevel = 6
+)
+
+// markdownLine is a line of words on a baseline, in content stream order.
+type markdownLine struct {
+	text     string
+	y        float64 // baseline
+	fontSize float64 // largest font size of the words, clustered
+}
+
+// markdownLines groups the words into lines, a line break being a word leaving the baseline of the
+// previous one.
+func markdownLines(words []textWord) []markdownLine {
+	lines := []markdownLine{}
+	for i, word := range words {
+		size := clusterFontSize(word.fontSize)
+		last := len(lines) - 1
+		if i > 0 && math.Abs(word.y-words[i-1].y) <= rowToleranceRatio*word.fontSize {
+			lines[last].text += " " + word.text
+			lines[last].fontSize = math.Max(lines[last].fontSize, size)
+			continue
+		}
+		lines = append(lines, markdownLine{word.text, word.y, size})
+	}
+
+	return lines
+}
+
+// clusterFontSize rounds the font size to a multiple of fontSizeStep.
+func clusterFontSize(size float64) float64 {
+	return math.Round(size/fontSizeStep) * fontSizeStep
+}
+
+// headingLevels returns the heading level of the font sizes of the lines: the body size is the size of the
+// most characters, and the sizes larger than it by headingSizeRatio are levels 1 (the largest) to 6.
+// Smaller sizes aren't in the map.
+func headingLevels(lines []markdownLine) map[float64]int {
+	counts := map[float64]int{}
+	for _, line := range lines {
+		counts[line.fontSize] += len([]rune(line.text))
+	}
+
+	bodySize, bodyCount := 0.0, -1
+	for size, count := range counts {
+		if count > bodyCount || (count == bodyCount && size < bodySize) {
+			bodySize, bodyCount = size, count
+		}
+	}
+
+	sizes := []float64{}
+	for size := range counts {
+		if size > bodySize*headingSizeRatio {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	levels := map[float64]int{}
+	for i, size := range sizes {
+		levels[size] = int(math.Min(float64(i+1), maxHeadingLevel))
+	}
+	return levels
+}
+
+// markdownFromLines writes the lines as Markdown: the lines of a heading size are headings of their level,
+// consecutive lines of the same level being joined, and the other lines are joined into paragraphs
+// separated by blank lines.  A paragraph ends at a baseline gap larger than paragraphGapRatio of the font
+// size, or at a move up such as a new column.
+func markdownFromLines(lines []markdownLine, levels map[float64]int) string {
+	blocks := []string{}
+	var block []string
+	blockLevel := -1
+
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		text := strings.Join(block, " ")
+		if blockLevel > 0 {
+			text = strings.Repeat("#", blockLevel) + " " + text
+		}
+		blocks = append(blocks, text)
+		block = nil
+	}
+
+	for i, line := range lines {
+		level := levels[line.fontSize]
+		if i > 0 {
+			prev := lines[i-1]
+			gap := prev.y - line.y
+			if level != blockLevel || gap < 0 || gap > paragraphGapRatio*math.Max(line.fontSize, prev.fontSize) {
+				flush()
+			}
+		}
+		blockLevel = level
+		block = append(block, strings.TrimSpace(line.text))
+	}
+	flush()
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// ExtractMarkdown returns the text of the content stream as Markdown.  Headings are inferred from the font
+// sizes: the lines larger than the body text are headings, the largest size being #, the next ##, and so
+// on.  The other lines are joined into paragraphs.  Heuristic, suits simple single-column layouts.
+func (e *Extractor) ExtractMarkdown() (string, error) {
+	marks, err := e.ExtractTextMarks()
+	lines := markdownLines(textWords(marks, e.wordGapRatio))
+	return markdownFromLines(lines, headingLevels(lines)), err
+}
+
+// ExtractMarkdown returns the text of the document as Markdown, as Extractor.ExtractMarkdown does for a
+// page, the heading levels being inferred from the font sizes of the whole document.  The pages are
+// separated by blank lines.  The fonts must have been parsed with ParseFonts.
+func ExtractMarkdown(reader *model.PdfReader) (string, error) {
+	fontsForPages := reader.GetFontsForPages()
+	pageCount := len(reader.GetPageList())
+	if len(fontsForPages) < pageCount {
+		return "", errors.New("fonts not parsed")
+	}
+
+	pages := [][]markdownLine{}
+	all := []markdownLine{}
+	for i := 0; i < pageCount; i++ {
+		content, err := reader.GetPageContent(i)
+		if err != nil {
+			common.Log.Debug("Error: decode content of page %d failed, err: %v", i, err)
+			continue
+		}
+
+		e := New(string(content), fontsForPages[i])
+		marks, err := e.ExtractTextMarks()
+		if err != nil {
+			e.log().Debug("Error: content stream of page %d partly parsed, err: %v", i, err)
+		}
+		lines := markdownLines(textWords(marks, e.wordGapRatio))
+		pages = append(pages, lines)
+		all = append(all, lines...)
+	}
+
+	levels := headingLevels(all)
+	var buf bytes.Buffer
+	for _, lines := range pages {
+		text := markdownFromLines(lines, levels)
+		if text == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n\n")
+		}
+		buf.WriteString(text)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,38 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+)
+
+func TestExtractMarkdown(t *testing.T) {
+	// a 24pt title, 16pt sections and 11pt body lines, two of them a paragraph
+	content := "BT /F1 24 Tf 72 740 Td (Document Title) Tj /F1 16 Tf 0 -40 Td (First Section) Tj " +
+		"/F1 11 Tf 0 -24 Td (The body text of the first) Tj 0 -13 Td (section on two lines.) Tj " +
+		"/F1 16 Tf 0 -36 Td (Second Section) Tj /F1 11 Tf 0 -24 Td (More body text.) Tj ET"
+	expected := "# Document Title\n\n## First Section\n\nThe body text of the first section on two lines.\n\n" +
+		"## Second Section\n\nMore body text."
+
+	markdown, err := contentExtractor(t, content, helveticaFont).ExtractMarkdown()
+	if err != nil {
+		t.Fatalf("ExtractMarkdown: %v", err)
+	}
+	if markdown != expected {
+		t.Errorf("got %q, expected %q", markdown, expected)
+	}
+
+	// the levels of a document over its pages, the title being on the first page only
+	reader := openPdf(t, pagesPdf(helveticaFont,
+		"BT /F1 24 Tf 72 740 Td (Title) Tj /F1 11 Tf 0 -30 Td (Body.) Tj ET",
+		"BT /F1 16 Tf 72 740 Td (Section) Tj /F1 11 Tf 0 -30 Td (More body.) Tj ET"))
+	if markdown, err = ExtractMarkdown(reader); err != nil {
+		t.Fatalf("ExtractMarkdown of the document: %v", err)
+	}
+	if expected := "# Title\n\nBody.\n\n## Section\n\nMore body."; markdown != expected {
+		t.Errorf("document: got %q, expected %q", markdown, expected)
+	}
+}
@@ -0,0 +1,495 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"math"
+
+	"../contentstream"
+	"../core"
+	"../model"
+)
+
+// TextMark is a glyph shown on the page, with its position.
+type TextMark struct {
+	Text     string
+	X, Y     float64 // origin of the glyph on the baseline, in points: user space scaled by the user unit
+	Width    float64 // advance of the glyph along the baseline, in points
+	FontSize float64 // font size, in points
+	MCID     int     // marked-content identifier of the glyph, -1 outside of marked content having one
+}
+
+// Glyph is a text mark along with the character code it was decoded from.
+type Glyph struct {
+	TextMark
+	Code     []byte // character code, the CID for fonts with a predefined CMap
+	FontName string // resource name of the font, e.g. F1
+	Runes    []rune // the runes of Text the code decoded to
+}
+
+// matrix is a transformation matrix [a b c d e f] as in the PDF operators.
+type matrix [6]float64
+
+var identityMatrix = matrix{1, 0, 0, 1, 0, 0}
+
+const (
+	// Form XObject nesting limit, protects against forms drawing themselves.
+	maxFormDepth = 16
+	// Glyph origins this close to the BBox of a form, in form space units, are not clipped.
+	formClipTolerance = 0.01
+)
+
+// mult returns the product m x n, i.e. the transformation by m followed by n.
+func (m matrix) mult(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// transform returns the point (x, y) transformed by m.
+func (m matrix) transform(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// inverse returns the inverse of m, false if m is singular.
+func (m matrix) inverse() (matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return matrix{}, false
+	}
+	return matrix{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det, (m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// getMatrix returns the matrix of the 6 numeric params, false if they are not.
+func getMatrix(params []core.PdfObject) (matrix, bool) {
+	var m matrix
+	if len(params) != 6 {
+		return m, false
+	}
+	for i := 0; i < 6; i++ {
+		v, err := core.GetNumberAsFloat(params[i])
+		if err != nil {
+			return m, false
+		}
+		m[i] = v
+	}
+	return m, true
+}
+
+// getNumbers returns the numeric params, false if there are not n of them.
+func getNumbers(params []core.PdfObject, n int) ([]float64, bool) {
+	if len(params) != n {
+		return nil, false
+	}
+	numbers := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v, err := core.GetNumberAsFloat(params[i])
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = v
+	}
+	return numbers, true
+}
+
+// getMCID returns the /MCID of the inline property list of the BDC params, false if there is none.  Property
+// lists named in the /Properties resources are not resolved.
+func getMCID(params []core.PdfObject) (int, bool) {
+	if len(params) != 2 {
+		return 0, false
+	}
+	props, ok := params[1].(*core.PdfObjectDictionary)
+	if !ok {
+		return 0, false
+	}
+	mcid, ok := props.Get("MCID").(*core.PdfObjectInteger)
+	if !ok {
+		return 0, false
+	}
+	return int(*mcid), true
+}
+
+// textState holds the text state parameters and matrices while processing a content stream.
+type textState struct {
+	ctm                      matrix
+	tm, tlm                  matrix
+	font                     *model.Font
+	fontName                 string
+	fontSize                 float64
+	charSpacing, wordSpacing float64
+	hScaling                 float64 // Tz / 100
+	leading                  float64
+	rise                     float64
+	mcid                     int // not part of the graphics state, kept across Q
+}
+
+// ExtractTextMarks processes the content stream and returns the glyphs shown, in content stream order,
+// positioned by the text state (Tm, Td, TD, T*, Tc, Tw, Tz, TL, Ts) and the CTM.  Glyph widths are taken
+// from the font widths, glyphs of unknown width are taken as half an em wide.
+func (e *Extractor) ExtractTextMarks() ([]TextMark, error) {
+	return e.extractTextMarks(e.unitMatrix())
+}
+
+// ExtractGlyphs processes the content stream like ExtractTextMarks and returns the glyphs shown with their
+// character code and font resource name, to analyze the mappings of the fonts.
+func (e *Extractor) ExtractGlyphs() ([]Glyph, error) {
+	return e.extractGlyphs(e.unitMatrix())
+}
+
+// unitMatrix returns the scaling of user space to points by the user unit.
+func (e *Extractor) unitMatrix() matrix {
+	if e.userUnit <= 0 {
+		return identityMatrix
+	}
+	return matrix{e.userUnit, 0, 0, e.userUnit, 0, 0}
+}
+
+// extractTextMarks returns the marks of the content stream, starting with the CTM ctm.
+func (e *Extractor) extractTextMarks(ctm matrix) ([]TextMark, error) {
+	glyphs, err := e.extractGlyphs(ctm)
+	marks := make([]TextMark, len(glyphs))
+	for i := range glyphs {
+		marks[i] = glyphs[i].TextMark
+	}
+	return marks, err
+}
+
+// extractGlyphs returns the glyphs of the content stream, starting with the CTM ctm.
+func (e *Extractor) extractGlyphs(ctm matrix) ([]Glyph, error) {
+	marks := []Glyph{}
+
+	operations, err := contentstream.NewContentStreamParser(e.contents).Parse()
+	if operations == nil {
+		return marks, err
+	}
+
+	ts := textState{ctm: ctm, tm: identityMatrix, tlm: identityMatrix, hScaling: 1, mcid: -1}
+	stack := []textState{}
+	// MCIDs of the enclosing marked content sequences
+	mcidStack := []int{}
+	content := hiddenContent{properties: e.hiddenProperties}
+	// shows the string, the glyphs of hidden optional content only advancing the text matrix
+	show := func(str *core.PdfObjectString) {
+		n := len(marks)
+		marks = e.showText(&ts, []byte(*str), marks)
+		if content.hidden() {
+			marks = marks[:n]
+		}
+	}
+
+	nextLine := func(tx, ty float64) {
+		ts.tlm = matrix{1, 0, 0, 1, tx, ty}.mult(ts.tlm)
+		ts.tm = ts.tlm
+	}
+
+	for _, op := range *operations {
+		content.process(op)
+		switch op.Operand {
+		case "q":
+			stack = append(stack, ts)
+		case "Q":
+			if len(stack) > 0 {
+				// the text matrices are not part of the graphics state
+				tm, tlm, mcid := ts.tm, ts.tlm, ts.mcid
+				ts = stack[len(stack)-1]
+				ts.tm, ts.tlm, ts.mcid = tm, tlm, mcid
+				stack = stack[:len(stack)-1]
+			}
+		case "BMC", "BDC":
+			mcidStack = append(mcidStack, ts.mcid)
+			if op.Operand == "BDC" {
+				if mcid, ok := getMCID(op.Params); ok {
+					ts.mcid = mcid
+				}
+			}
+		case "EMC":
+			if len(mcidStack) > 0 {
+				ts.mcid = mcidStack[len(mcidStack)-1]
+				mcidStack = mcidStack[:len(mcidStack)-1]
+			}
+		case "cm":
+			if m, ok := getMatrix(op.Params); ok {
+				ts.ctm = m.mult(ts.ctm)
+			}
+		case "BT":
+			ts.tm, ts.tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(op.Params) != 2 {
+				continue
+			}
+			if fontName, ok := op.Params[0].(*core.PdfObjectName); ok {
+				ts.fontName = string(*fontName)
+				ts.font = e.fontNamesMap[*fontName]
+				if ts.font == nil {
+					e.log().Debug("Error: can't find Tf font by name %s", *fontName)
+				}
+			}
+			if size, err := core.GetNumberAsFloat(op.Params[1]); err == nil {
+				ts.fontSize = size
+			}
+		case "Tc":
+			if v, ok := getNumbers(op.Params, 1); ok {
+				ts.charSpacing = v[0]
+			}
+		case "Tw":
+			if v, ok := getNumbers(op.Params, 1); ok {
+				ts.wordSpacing = v[0]
+			}
+		case "Tz":
+			if v, ok := getNumbers(op.Params, 1); ok {
+				ts.hScaling = v[0] / 100
+			}
+		case "TL":
+			if v, ok := getNumbers(op.Params, 1); ok {
+				ts.leading = v[0]
+			}
+		case "Ts":
+			if v, ok := getNumbers(op.Params, 1); ok {
+				ts.rise = v[0]
+			}
+		case "Td":
+			if v, ok := getNumbers(op.Params, 2); ok {
+				nextLine(v[0], v[1])
+			}
+		case "TD":
+			if v, ok := getNumbers(op.Params, 2); ok {
+				ts.leading = -v[1]
+				nextLine(v[0], v[1])
+			}
+		case "Tm":
+			if m, ok := getMatrix(op.Params); ok {
+				ts.tm, ts.tlm = m, m
+			}
+		case "T*":
+			nextLine(0, -ts.leading)
+		case "Tj":
+			if len(op.Params) == 1 {
+				if str, ok := op.Params[0].(*core.PdfObjectString); ok {
+					show(str)
+				}
+			}
+		case "'":
+			nextLine(0, -ts.leading)
+			if len(op.Params) == 1 {
+				if str, ok := op.Params[0].(*core.PdfObjectString); ok {
+					show(str)
+				}
+			}
+		case "\"":
+			if len(op.Params) != 3 {
+				continue
+			}
+			if v, ok := getNumbers(op.Params[:2], 2); ok {
+				ts.wordSpacing, ts.charSpacing = v[0], v[1]
+			}
+			nextLine(0, -ts.leading)
+			if str, ok := op.Params[2].(*core.PdfObjectString); ok {
+				show(str)
+			}
+		case "TJ":
+			if len(op.Params) != 1 {
+				continue
+			}
+			arr, ok := op.Params[0].(*core.PdfObjectArray)
+			if !ok {
+				continue
+			}
+			for _, obj := range *arr {
+				if str, ok := obj.(*core.PdfObjectString); ok {
+					show(str)
+				} else if v, err := core.GetNumberAsFloat(obj); err == nil {
+					ts.tm = matrix{1, 0, 0, 1, -v / 1000 * ts.fontSize * ts.hScaling, 0}.mult(ts.tm)
+				}
+			}
+		case "Do":
+			if len(op.Params) != 1 || e.reader == nil || content.hidden() {
+				continue
+			}
+			if name, ok := op.Params[0].(*core.PdfObjectName); ok {
+				marks = append(marks, e.drawForm(string(*name), ts)...)
+			}
+		}
+	}
+
+	return marks, err
+}
+
+// drawForm returns the glyphs of the form XObject of the name drawn with the text state ts: transformed by
+// the form /Matrix, without those whose origin is outside of the form /BBox, and in the marked content of
+// the Do when outside of marked content of the form.  Nil if the name isn't a form.
+func (e *Extractor) drawForm(name string, ts textState) []Glyph {
+	if e.formDepth >= maxFormDepth {
+		e.log().Debug("Error: form XObjects nested too deep, %s skipped", name)
+		return nil
+	}
+	form, err := e.reader.GetFormXObject(e.resources, name)
+	if err != nil {
+		e.log().Debug("Error: load form XObject %s failed, err: %v", name, err)
+		return nil
+	}
+	if form == nil {
+		return nil
+	}
+
+	// the options of the extractor apply to the form, the optional content of its /Properties isn't resolved
+	fe := *e
+	fe.contents = string(form.Content)
+	fe.fontNamesMap = form.Fonts
+	fe.resources = form.Resources
+	fe.hiddenProperties = nil
+	fe.formDepth++
+
+	ctm := matrix(form.Matrix).mult(ts.ctm)
+	glyphs, err := fe.extractGlyphs(ctm)
+	if err != nil {
+		e.log().Debug("Error: form XObject %s partly parsed, err: %v", name, err)
+	}
+
+	inverse, invertible := ctm.inverse()
+	bbox := form.BBox
+	visible := glyphs[:0]
+	for _, glyph := range glyphs {
+		if invertible {
+			x, y := inverse.transform(glyph.X, glyph.Y)
+			if x < bbox[0]-formClipTolerance || x > bbox[2]+formClipTolerance ||
+				y < bbox[1]-formClipTolerance || y > bbox[3]+formClipTolerance {
+				continue
+			}
+		}
+		if glyph.MCID == -1 {
+			glyph.MCID = ts.mcid
+		}
+		visible = append(visible, glyph)
+	}
+
+	return visible
+}
+
+// showText appends the glyphs of the shown string to marks and advances the text matrix.
+func (e *Extractor) showText(ts *textState, data []byte, marks []Glyph) []Glyph {
+	font := ts.font
+	codes, charcodes := e.glyphCodes(font, data)
+
+	// the glyph origin in text space, moved by the translation of the FontMatrix of Type3 fonts
+	originX, originY := 0.0, ts.rise
+	if font != nil && font.GetFontType() == "Type3" {
+		fontMatrix := type3Matrix(font)
+		originX += fontMatrix[4] * ts.fontSize * ts.hScaling
+		originY += fontMatrix[5] * ts.fontSize
+	}
+
+	for k, code := range codes {
+		trm := ts.tm.mult(ts.ctm)
+		x, y := trm.transform(originX, originY)
+		w0 := e.glyphWidth(font, code)
+		tx := w0*ts.fontSize + ts.charSpacing
+		if len(code) == 1 && code[0] == ' ' {
+			tx += ts.wordSpacing
+		}
+		tx *= ts.hScaling
+		ts.tm = matrix{1, 0, 0, 1, tx, 0}.mult(ts.tm)
+
+		fontSize := ts.fontSize * math.Hypot(trm[2], trm[3])
+		if fontSize < e.minFontSize {
+			continue
+		}
+
+		ex, ey := ts.tm.mult(ts.ctm).transform(originX, originY)
+		text := ""
+		if charcodes != nil {
+			text = e.decodeCodes(font, charcodes[k])
+		} else {
+			text = e.decodeCids(font, code)
+		}
+		marks = append(marks, Glyph{
+			TextMark: TextMark{
+				Text:     text,
+				X:        x,
+				Y:        y,
+				Width:    math.Hypot(ex-x, ey-y),
+				FontSize: fontSize,
+				MCID:     ts.mcid,
+			},
+			Code:     append([]byte(nil), code...),
+			FontName: ts.fontName,
+			Runes:    []rune(text),
+		})
+	}
+
+	return marks
+}
+
+// glyphCodes splits the shown string into the codes of its glyphs, the CIDs for fonts with a predefined CMap.
+// The character codes of the glyphs are returned too when their text is decoded from them rather than from
+// the CIDs, for fonts that also have a ToUnicode CMap, nil otherwise.
+func (e *Extractor) glyphCodes(font *model.Font, data []byte) (codes, charcodes [][]byte) {
+	if e.prefersToUnicode(font) {
+		for i := 0; i < len(data); {
+			end := i + font.GetCidCmap().CodeLength(data[i:])
+			if end > len(data) {
+				end = len(data)
+			}
+			charcode := data[i:end]
+			code := e.charcodesToCids(font, charcode)
+			if len(code) == 0 {
+				code = charcode
+			}
+			codes = append(codes, code)
+			charcodes = append(charcodes, charcode)
+			i = end
+		}
+		return codes, charcodes
+	}
+
+	codeLen := 1
+	if font != nil && font.IsMultibyte() {
+		codeLen = 2
+	}
+	data = e.charcodesToCids(font, data)
+	for i := 0; i < len(data); i += codeLen {
+		end := i + codeLen
+		if end > len(data) {
+			end = len(data)
+		}
+		codes = append(codes, data[i:end])
+	}
+	return codes, nil
+}
+
+// glyphWidth returns the width of the glyph of the code in text space units per unit of font size.
+func (e *Extractor) glyphWidth(font *model.Font, code []byte) float64 {
+	if font == nil {
+		return 0.5
+	}
+
+	if font.GetFontType() == "Type3" {
+		if advance, ok := e.type3Advance(font, code); ok {
+			return advance
+		}
+	}
+
+	width := 0.0
+	if font.IsMultibyte() {
+		cid := uint(0)
+		for _, b := range code {
+			cid = cid<<8 | uint(b)
+		}
+		width = font.GetCidWidth(cid)
+	} else {
+		width = font.GetCharWidth(uint(code[0]))
+	}
+
+	if width <= 0 {
+		return 0.5
+	}
+	return width / 1000
+}
@@ -0,0 +1,189 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUserUnitMarks(t *testing.T) {
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /UserUnit 2.0 /Contents 4 0 R "+
+			"/Resources << /Font << /F1 "+helveticaFont+" >> >> >>",
+		makeStream("", "BT /F1 10 Tf 100 700 Td (AB) Tj ET"))
+	reader := openPdf(t, pdf)
+	userUnit, err := reader.GetPageUserUnit(0)
+	if err != nil {
+		t.Fatalf("GetPageUserUnit: %v", err)
+	}
+
+	e := pageExtractor(t, reader, 0)
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	e.SetUserUnit(userUnit)
+	scaled, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+
+	if len(marks) != 2 || len(scaled) != 2 {
+		t.Fatalf("got %d and %d marks", len(marks), len(scaled))
+	}
+	if scaled[0].X != 200 || scaled[0].Y != 1400 || scaled[0].FontSize != 20 {
+		t.Errorf("mark at (%v, %v) of size %v", scaled[0].X, scaled[0].Y, scaled[0].FontSize)
+	}
+	for i := range marks {
+		if scaled[i].X != 2*marks[i].X || scaled[i].Width != 2*marks[i].Width {
+			t.Errorf("mark %d: %+v not double %+v", i, scaled[i], marks[i])
+		}
+	}
+	glyphs, err := e.ExtractGlyphs()
+	if err != nil {
+		t.Fatalf("ExtractGlyphs: %v", err)
+	}
+	if len(glyphs) != 2 || glyphs[1].TextMark != scaled[1] {
+		t.Errorf("glyphs %+v not at the marks %+v", glyphs, scaled)
+	}
+
+	positioned, err := e.ExtractPositionedText()
+	if err != nil {
+		t.Fatalf("ExtractPositionedText: %v", err)
+	}
+	if box, ok := positioned.CharBox(1); !ok || box != (TextBox{scaled[1].X, scaled[1].Y,
+		scaled[1].X + scaled[1].Width, scaled[1].Y + scaled[1].FontSize}) {
+		t.Errorf("CharBox %+v", box)
+	}
+}
+
+func TestMinFontSize(t *testing.T) {
+	content := "BT /F1 24 Tf 72 700 Td (Title) Tj ET\n" +
+		"BT /F1 10 Tf 72 650 Td (Body) Tj ET\n" +
+		// 12 points scaled to 24 by the CTM
+		"q 2 0 0 2 0 0 cm BT /F1 12 Tf 36 300 Td (Scaled) Tj ET Q"
+	e := contentExtractor(t, content, helveticaFont)
+	e.SetMinFontSize(18)
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+
+	text := ""
+	for _, mark := range marks {
+		if mark.FontSize < 18 {
+			t.Errorf("mark %q of size %v", mark.Text, mark.FontSize)
+		}
+		text += mark.Text
+	}
+	if text != "TitleScaled" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestExtractGlyphs(t *testing.T) {
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 "+helveticaFont+" /F2 "+cjkFont+" >> >> >>",
+		makeStream("", "BT /F1 12 Tf 72 712 Td (A) Tj /F2 12 Tf <4E2D> Tj ET"))
+	glyphs, err := pageExtractor(t, openPdf(t, pdf), 0).ExtractGlyphs()
+	if err != nil {
+		t.Fatalf("ExtractGlyphs: %v", err)
+	}
+
+	expected := []struct {
+		code     string
+		fontName string
+		text     string
+	}{
+		{"\x41", "F1", "A"},
+		// the CID 4559 of the predefined CMap
+		{"\x11\xcf", "F2", "中"},
+	}
+	if len(glyphs) != len(expected) {
+		t.Fatalf("got %d glyphs", len(glyphs))
+	}
+	for i, tc := range expected {
+		g := glyphs[i]
+		if string(g.Code) != tc.code || g.FontName != tc.fontName || g.Text != tc.text ||
+			string(g.Runes) != tc.text {
+			t.Errorf("glyph %d: code % X of %s, runes %q, text %q", i, g.Code, g.FontName, g.Runes, g.Text)
+		}
+	}
+}
+
+func TestHorizontalScaling(t *testing.T) {
+	// the word shown at 100 then 200 percent scaling, its glyphs advancing twice as far
+	content := "BT /F1 10 Tf 100 700 Td (word) Tj ET BT /F1 10 Tf 200 Tz 100 600 Td (word) Tj ET"
+	e := contentExtractor(t, content, helveticaFont)
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	if len(marks) != 8 {
+		t.Fatalf("got %d marks", len(marks))
+	}
+	for i := 0; i < 4; i++ {
+		if normal, scaled := marks[i], marks[4+i]; math.Abs(scaled.Width-2*normal.Width) > 1e-9 ||
+			math.Abs((scaled.X-100)-2*(normal.X-100)) > 1e-9 {
+			t.Errorf("glyph %d: %+v not scaled twice %+v", i, scaled, normal)
+		}
+	}
+
+	positioned, err := e.ExtractPositionedText()
+	if err != nil {
+		t.Fatalf("ExtractPositionedText: %v", err)
+	}
+	first, _ := positioned.CharBox(0)
+	last, _ := positioned.CharBox(3)
+	scaledFirst, _ := positioned.CharBox(5)
+	scaledLast, _ := positioned.CharBox(8)
+	if width, scaled := last.X1-first.X0, scaledLast.X1-scaledFirst.X0; math.Abs(scaled-2*width) > 1e-9 {
+		t.Errorf("word %v wide, %v scaled", width, scaled)
+	}
+}
+
+func TestFormXObjects(t *testing.T) {
+	// a translated form whose BBox excludes its second line, drawing a scaled nested form of the page font
+	pdf := pagePdf("BT /F1 10 Tf 72 700 Td (P) Tj ET q 1 0 0 1 100 200 cm /Fm1 Do Q", helveticaFont,
+		"/XObject << /Fm1 5 0 R >>",
+		makeStream("/Type /XObject /Subtype /Form /BBox [0 0 100 100] /Matrix [1 0 0 1 50 50] "+
+			"/Resources << /Font << /F1 "+helveticaFont+" >> /XObject << /Fm2 6 0 R >> >>",
+			"BT /F1 10 Tf 10 10 Td (I) Tj 0 200 Td (O) Tj ET /Fm2 Do"),
+		makeStream("/Type /XObject /Subtype /Form /BBox [0 0 50 50] /Matrix [2 0 0 2 0 0]",
+			"BT /F1 10 Tf 10 10 Td (N) Tj ET"))
+	reader := openPdf(t, pdf)
+
+	e := pageExtractor(t, reader, 0)
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	if len(marks) != 1 || marks[0].Text != "P" {
+		t.Errorf("without forms: %+v", marks)
+	}
+
+	e.SetFormXObjects(reader, reader.GetPageResources()[0])
+	if marks, err = e.ExtractTextMarks(); err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	expected := []TextMark{{Text: "P", X: 72, Y: 700, FontSize: 10}, {Text: "I", X: 160, Y: 260, FontSize: 10},
+		{Text: "N", X: 170, Y: 270, FontSize: 20}}
+	if len(marks) != len(expected) {
+		t.Fatalf("got %+v", marks)
+	}
+	for i, mark := range expected {
+		if marks[i].Text != mark.Text || math.Abs(marks[i].X-mark.X) > 1e-9 || math.Abs(marks[i].Y-mark.Y) > 1e-9 ||
+			math.Abs(marks[i].FontSize-mark.FontSize) > 1e-9 {
+			t.Errorf("mark %d: %+v, expected %+v", i, marks[i], mark)
+		}
+	}
+}
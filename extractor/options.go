@@ -0,0 +1,167 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"../common"
+	"../model"
+)
+
+// ExtractOptions are the options of ExtractTextWithOptions, in a single struct rather than a setter each.
+// The zero value extracts as ExtractText does by default, all the pages being joined with
+// DefaultPageSeparator.  Only the behaviors the extractor implements have an option: there is no
+// whitespace normalization, de-hyphenation or filtering of invisible (render mode 3) text, and the invisible
+// format characters are removed with StripChars.
+type ExtractOptions struct {
+	// Charset to transcode the text to, UTF-8 if empty.  See SetOutputCharset.
+	OutputCharset string
+	// Unicode encoding form of the text when not transcoded to a charset.  See SetOutputEncoding.  When
+	// extracting a document, the text of all the pages is encoded as a whole, with a single byte order mark.
+	OutputEncoding OutputEncoding
+	// What to output for character codes that can't be mapped to unicode.  See SetUnmappedMode.
+	UnmappedMode UnmappedMode
+	// Characters removed from the text, none if empty.  DefaultStripChars removes the invisible format
+	// characters.  See SetStripChars.
+	StripChars string
+	// What to output for the private use characters.  See SetPUAMode.
+	PUAMode PUAMode
+	// Output the CID strings instead of unicode text.  See SetOutputCids.
+	OutputCids bool
+	// Minimum effective font size of the text, all the text if 0.  See SetMinFontSize.
+	MinFontSize float64
+	// Open and close markers of the superscript and subscript runs, none if all empty.  See
+	// SetScriptMarkers.
+	SuperscriptMarkers [2]string
+	SubscriptMarkers   [2]string
+	// Fraction of the font size above which a gap between glyphs separates words, DefaultWordGapRatio if 0.
+	// See SetWordGapRatio.
+	WordGapRatio float64
+
+	// Output ParagraphSeparator between text objects starting at a lower baseline.  See SetParagraphBreaks.
+	ParagraphBreaks bool
+	// Separator of the paragraphs, a blank line if empty.
+	ParagraphSeparator string
+	// Output a single newline at every move to another baseline.  See SetBaselineNewlines.
+	BaselineNewlines bool
+
+	// Skip the text of the optional content (layers) hidden in the default configuration of the document.
+	// Only used when extracting a document.
+	SkipHiddenLayers bool
+
+	// Range of the pages extracted, 1 based and inclusive.  0 means the first page and the last page
+	// respectively.  Only used when extracting a document.
+	FirstPage int
+	LastPage  int
+	// Separator of the pages, DefaultPageSeparator if empty.  Only used when extracting a document.
+	PageSeparator string
+}
+
+// setOptions sets the options of the text extraction of the page.
+func (e *Extractor) setOptions(opts ExtractOptions) {
+	e.SetOutputCharset(opts.OutputCharset)
+	e.SetOutputEncoding(opts.OutputEncoding)
+	e.SetUnmappedMode(opts.UnmappedMode)
+	e.SetStripChars(opts.StripChars)
+	e.SetPUAMode(opts.PUAMode)
+	e.SetOutputCids(opts.OutputCids)
+	e.SetMinFontSize(opts.MinFontSize)
+	if opts.SuperscriptMarkers != ([2]string{}) || opts.SubscriptMarkers != ([2]string{}) {
+		e.SetScriptMarkers(opts.SuperscriptMarkers[0], opts.SuperscriptMarkers[1], opts.SubscriptMarkers[0],
+			opts.SubscriptMarkers[1])
+	} else {
+		e.scriptMarkers = false
+	}
+	if opts.WordGapRatio != 0 {
+		e.SetWordGapRatio(opts.WordGapRatio)
+	} else {
+		e.SetWordGapRatio(DefaultWordGapRatio)
+	}
+	e.SetParagraphBreaks(opts.ParagraphBreaks)
+	if opts.ParagraphSeparator != "" {
+		e.SetParagraphSeparator(opts.ParagraphSeparator)
+	}
+	e.SetBaselineNewlines(opts.BaselineNewlines)
+}
+
+// ExtractTextWithOptions extracts the text of the content stream as ExtractText does, with the options.
+// The document options, such as the page range, are ignored.
+func (e *Extractor) ExtractTextWithOptions(opts ExtractOptions) (string, error) {
+	e.setOptions(opts)
+	return e.ExtractText()
+}
+
+// ExtractTextWithOptions extracts the text of the pages of the document in the range of the options, joined
+// with the page separator.  The fonts must have been parsed with ParseFonts.  Pages whose content can't be
+// decoded have an empty text.  Errors are logged to the logger of the reader.
+func ExtractTextWithOptions(reader *model.PdfReader, opts ExtractOptions) (string, error) {
+	fontsForPages := reader.GetFontsForPages()
+	pageCount := len(reader.GetPageList())
+	if len(fontsForPages) < pageCount {
+		return "", errors.New("fonts not parsed")
+	}
+
+	first, last := opts.FirstPage, opts.LastPage
+	if first == 0 {
+		first = 1
+	}
+	if last == 0 {
+		last = pageCount
+	}
+	if first < 1 || last > pageCount || first > last {
+		return "", fmt.Errorf("invalid page range %d-%d of %d pages", first, last, pageCount)
+	}
+
+	separator := opts.PageSeparator
+	if separator == "" {
+		separator = DefaultPageSeparator
+	}
+
+	// the pages are extracted in UTF-8, the joined text being encoded at the end
+	encoding := opts.OutputEncoding
+	opts.OutputEncoding = OutputUTF8
+
+	logger := reader.GetLogger()
+	if logger == nil {
+		logger = common.Log
+	}
+
+	var buf bytes.Buffer
+	for i := first - 1; i < last; i++ {
+		if i > first-1 {
+			buf.WriteString(separator)
+		}
+
+		content, err := reader.GetPageContent(i)
+		if err != nil {
+			logger.Debug("Error: decode content of page %d failed, err: %v", i, err)
+			continue
+		}
+
+		e := New(string(content), fontsForPages[i])
+		e.SetLogger(logger)
+		if opts.SkipHiddenLayers {
+			hidden, err := reader.GetPageHiddenProperties(i)
+			if err != nil {
+				e.log().Debug("Error: hidden layers of page %d failed, err: %v", i, err)
+			}
+			e.SetHiddenProperties(hidden)
+		}
+		text, err := e.ExtractTextWithOptions(opts)
+		if err != nil {
+			e.log().Debug("Error: content stream of page %d partly parsed, err: %v", i, err)
+		}
+		buf.WriteString(text)
+	}
+
+	if opts.OutputCharset != "" {
+		return buf.String(), nil
+	}
+	return encodeText(buf.String(), encoding), nil
+}
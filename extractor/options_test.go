@@ -0,0 +1,87 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"../common"
+)
+
+func TestExtractTextWithOptions(t *testing.T) {
+	// the content of the third page can't be decoded
+	page := "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R " +
+		"/Resources << /Font << /F1 3 0 R >> >> >>"
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R 6 0 R 8 0 R] /Count 3 >>",
+		helveticaFont,
+		fmt.Sprintf(page, 5),
+		makeStream("", "BT /F1 6 Tf 72 760 Td (Header) Tj /F1 24 Tf 0 -40 Td (Title) Tj "+
+			"/F1 6 Tf 0 -20 Td (note) Tj ET"),
+		fmt.Sprintf(page, 7),
+		makeStream("", "BT /F1 12 Tf 72 700 Td (x) Tj /F1 11 Tf 5 Ts (2) Tj ET"),
+		fmt.Sprintf(page, 9),
+		makeStream("/Filter /FlateDecode", "BT /F1 12 Tf 72 700 Td (Lost) Tj ET"))
+	reader := openPdf(t, pdf)
+
+	previous := common.Log
+	defer common.SetLogger(previous)
+	global := &captureLogger{}
+	common.SetLogger(global)
+	logger := &captureLogger{}
+	reader.SetLogger(logger)
+
+	// the small text skipped, but for the moves of its lines, and the superscript marked
+	opts := ExtractOptions{
+		MinFontSize:        10,
+		SuperscriptMarkers: [2]string{"^{", "}"},
+		FirstPage:          1,
+		PageSeparator:      "|",
+	}
+	text, err := ExtractTextWithOptions(reader, opts)
+	if err != nil {
+		t.Fatalf("ExtractTextWithOptions: %v", err)
+	}
+	if text != "\nTitle\n|x^{2}|" {
+		t.Errorf("got %q", text)
+	}
+
+	// the decoding error of the stream itself is logged by the core package
+	if !strings.Contains(strings.Join(logger.messages, "\n"), "decode content of page 2 failed") {
+		t.Errorf("reader logger messages %q", logger.messages)
+	}
+	if strings.Contains(strings.Join(global.messages, "\n"), "page 2") {
+		t.Errorf("global logger messages %q", global.messages)
+	}
+
+	// the zero value, the second page only
+	if text, err = ExtractTextWithOptions(reader, ExtractOptions{FirstPage: 2, LastPage: 2}); text != "x2" {
+		t.Errorf("zero value: got %q, err: %v", text, err)
+	}
+	if _, err = ExtractTextWithOptions(reader, ExtractOptions{FirstPage: 3, LastPage: 4}); err == nil {
+		t.Errorf("no error for an invalid page range")
+	}
+}
+
+func TestExtractTextWithOptionsEncoding(t *testing.T) {
+	reader := openPdf(t, pagesPdf(helveticaFont, "BT /F1 12 Tf 72 712 Td (A) Tj ET",
+		"BT /F1 12 Tf 72 712 Td (B) Tj ET"))
+
+	// a single byte order mark, the separator encoded too
+	opts := ExtractOptions{PageSeparator: "|", OutputEncoding: OutputUTF16BE}
+	text, err := ExtractTextWithOptions(reader, opts)
+	if err != nil || text != "\xfe\xff\x00A\x00|\x00B" {
+		t.Errorf("UTF-16BE: % x, err: %v", text, err)
+	}
+	opts.OutputEncoding = OutputUTF8BOM
+	text, err = ExtractTextWithOptions(reader, opts)
+	if err != nil || text != "\xef\xbb\xbfA|B" {
+		t.Errorf("UTF-8 BOM: % x, err: %v", text, err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"../core"
+	"../model"
+)
+
+// ForEachPageText extracts the text of the pages in order and calls fn with the 0 based index and text of
+// each page as soon as it is extracted, so that the text of the document is never buffered as a whole.
+// Processing stops at the first error returned by fn, which is returned.  The fonts must have been parsed
+// with ParseFonts.  Pages whose content can't be decoded are passed with an empty text.
+func ForEachPageText(reader *model.PdfReader, fn func(pageIndex int, text string) error) error {
+	fontsForPages := reader.GetFontsForPages()
+	pageCount := len(reader.GetPageList())
+	if len(fontsForPages) < pageCount {
+		return errors.New("fonts not parsed")
+	}
+
+	for i := 0; i < pageCount; i++ {
+		if err := fn(i, pageText(reader, fontsForPages[i], i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pageText returns the text of the page (0 based index) with the fonts, empty if its content can't be decoded.
+func pageText(reader *model.PdfReader, fonts model.FontsByNames, pageIndex int) string {
+	content, err := reader.GetPageContent(pageIndex)
+	e := New(string(content), fonts)
+	e.SetLogger(reader.GetLogger())
+	if err != nil {
+		e.log().Debug("Error: decode content of page %d failed, err: %v", pageIndex, err)
+		return ""
+	}
+
+	text, err := e.ExtractText()
+	if err != nil {
+		e.log().Debug("Error: content stream of page %d partly parsed, err: %v", pageIndex, err)
+	}
+	return text
+}
+
+// DefaultPageSeparator is the separator of the pages in the text of JoinPageTexts, a form feed like pdftotext.
+const DefaultPageSeparator = "\f"
+
+// JoinPageTexts extracts the text of the pages with ForEachPageText and joins them with separator, so that
+// a document of n pages has n-1 separators.  Use DefaultPageSeparator for the form feed of pdftotext.
+func JoinPageTexts(reader *model.PdfReader, separator string) (string, error) {
+	var buf bytes.Buffer
+	err := ForEachPageText(reader, func(pageIndex int, text string) error {
+		if pageIndex > 0 {
+			buf.WriteString(separator)
+		}
+		buf.WriteString(text)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ExtractPagesText extracts the text of the pages (0 based indices) in the order given, e.g. the body before
+// the front matter, joined with separator.  A page may be repeated.  Returns an error without extracting
+// anything if an index is out of range.  The fonts must have been parsed with ParseFonts.
+func ExtractPagesText(reader *model.PdfReader, pageIndices []int, separator string) (string, error) {
+	fontsForPages := reader.GetFontsForPages()
+	pageCount := len(reader.GetPageList())
+	if len(fontsForPages) < pageCount {
+		return "", errors.New("fonts not parsed")
+	}
+	for _, i := range pageIndices {
+		if i < 0 || i >= pageCount {
+			return "", fmt.Errorf("page index %d out of range, %d pages", i, pageCount)
+		}
+	}
+
+	var buf bytes.Buffer
+	for n, i := range pageIndices {
+		if n > 0 {
+			buf.WriteString(separator)
+		}
+		buf.WriteString(pageText(reader, fontsForPages[i], i))
+	}
+
+	return buf.String(), nil
+}
+
+// ExtractTextFromStream extracts the text of the content stream object objNum with the fonts, e.g. those of
+// a page from GetFontsForPages, to reproduce the extraction of a single stream.  The stream needn't belong
+// to a page.
+func ExtractTextFromStream(reader *model.PdfReader, objNum int64, fonts model.FontsByNames) (string, error) {
+	obj, err := reader.GetParser().LookupByNumber(int(objNum))
+	if err != nil {
+		return "", err
+	}
+	stream, ok := obj.(*core.PdfObjectStream)
+	if !ok {
+		return "", fmt.Errorf("object %d is not a stream (%T)", objNum, obj)
+	}
+
+	content, err := reader.DecodeContentStream(stream)
+	if err != nil {
+		return "", err
+	}
+
+	e := New(string(content), fonts)
+	e.SetLogger(reader.GetLogger())
+	return e.ExtractText()
+}
@@ -0,0 +1,186 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"../common"
+	"../core"
+)
+
+// captureLogger records the debug messages.
+type captureLogger struct {
+	common.DummyLogger
+	messages []string
+}
+
+func (l *captureLogger) Debug(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestForEachPageTextStops(t *testing.T) {
+	reader := openPdf(t, pagesPdf(helveticaFont, "BT /F1 12 Tf 72 712 Td (one) Tj ET",
+		"BT /F1 12 Tf 72 712 Td (two) Tj ET", "BT /F1 12 Tf 72 712 Td (three) Tj ET"))
+
+	stop := errors.New("stop")
+	var texts []string
+	err := ForEachPageText(reader, func(pageIndex int, text string) error {
+		if pageIndex != len(texts) {
+			t.Errorf("page %d called back after %d pages", pageIndex, len(texts))
+		}
+		texts = append(texts, text)
+		if pageIndex == 1 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("got error %v", err)
+	}
+	if len(texts) != 2 || texts[0] != "one" || texts[1] != "two" {
+		t.Errorf("texts %q", texts)
+	}
+}
+
+func TestPageTextLogger(t *testing.T) {
+	// the content of the second page can't be decoded, the third shows text in an unknown font
+	page := "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R " +
+		"/Resources << /Font << /F1 3 0 R >> >> >>"
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R 6 0 R 8 0 R] /Count 3 >>",
+		helveticaFont,
+		fmt.Sprintf(page, 5),
+		makeStream("", "BT /F1 12 Tf 72 712 Td (one) Tj ET"),
+		fmt.Sprintf(page, 7),
+		makeStream("/Filter /FlateDecode", "BT /F1 12 Tf 72 712 Td (two) Tj ET"),
+		fmt.Sprintf(page, 9),
+		makeStream("", "BT /F2 12 Tf 72 712 Td (three) Tj ET"))
+	reader := openPdf(t, pdf)
+
+	previous := common.Log
+	defer common.SetLogger(previous)
+	global := &captureLogger{}
+	common.SetLogger(global)
+	logger := &captureLogger{}
+	reader.SetLogger(logger)
+
+	if text, err := JoinPageTexts(reader, "|"); err != nil || text != "one||" {
+		t.Errorf("got %q, err: %v", text, err)
+	}
+	if _, err := ExtractTextFromStream(reader, 9, reader.GetFontsForPages()[2]); err == nil {
+		t.Errorf("no error for an unknown font")
+	}
+
+	messages := strings.Join(logger.messages, "\n")
+	if !strings.Contains(messages, "decode content of page 1 failed") ||
+		!strings.Contains(messages, "content stream of page 2 partly parsed") ||
+		strings.Count(messages, "Error: can't find Tf font") != 2 {
+		t.Errorf("reader logger messages %q", logger.messages)
+	}
+	// the decoding and content stream processing errors themselves are logged by the core and contentstream
+	// packages
+	if messages := strings.Join(global.messages, "\n"); strings.Contains(messages, "page") ||
+		strings.Contains(messages, "Error: can't find Tf font") {
+		t.Errorf("global logger messages %q", global.messages)
+	}
+}
+
+func TestJoinPageTexts(t *testing.T) {
+	reader := openPdf(t, pagesPdf(helveticaFont, "BT /F1 12 Tf 72 712 Td (one) Tj ET",
+		"BT /F1 12 Tf 72 712 Td (two) Tj ET", "BT /F1 12 Tf 72 712 Td (three) Tj ET"))
+
+	text, err := JoinPageTexts(reader, DefaultPageSeparator)
+	if err != nil {
+		t.Fatalf("JoinPageTexts: %v", err)
+	}
+	if strings.Count(text, "\f") != 2 || text != "one\ftwo\fthree" {
+		t.Errorf("got %q", text)
+	}
+
+	if text, err = JoinPageTexts(reader, "\n--\n"); err != nil || text != "one\n--\ntwo\n--\nthree" {
+		t.Errorf("custom separator: got %q, err: %v", text, err)
+	}
+}
+
+func TestExtractPagesText(t *testing.T) {
+	reader := openPdf(t, pagesPdf(helveticaFont, "BT /F1 12 Tf 72 712 Td (one) Tj ET",
+		"BT /F1 12 Tf 72 712 Td (two) Tj ET", "BT /F1 12 Tf 72 712 Td (three) Tj ET"))
+
+	testcases := []struct {
+		indices  []int
+		expected string
+	}{
+		{[]int{2, 0, 1}, "three|one|two"},
+		{[]int{1, 1}, "two|two"},
+		{[]int{}, ""},
+	}
+	for _, tc := range testcases {
+		if text, err := ExtractPagesText(reader, tc.indices, "|"); err != nil || text != tc.expected {
+			t.Errorf("%v: got %q, err: %v", tc.indices, text, err)
+		}
+	}
+
+	for _, indices := range [][]int{{0, 3}, {-1}} {
+		if text, err := ExtractPagesText(reader, indices, "|"); err == nil || text != "" {
+			t.Errorf("%v: got %q, err: %v", indices, text, err)
+		}
+	}
+}
+
+func TestExtractTextFromStream(t *testing.T) {
+	// the object 5 is a content stream outside of the page
+	reader := openPdf(t, pagePdf("BT /F1 12 Tf 72 712 Td (page) Tj ET", helveticaFont, "",
+		makeStream("", "BT /F1 12 Tf 72 712 Td (stream) Tj ET")))
+	fonts := reader.GetFontsForPages()[0]
+
+	for objNum, expected := range map[int64]string{4: "page", 5: "stream"} {
+		if text, err := ExtractTextFromStream(reader, objNum, fonts); err != nil || text != expected {
+			t.Errorf("object %d: got %q, err: %v", objNum, text, err)
+		}
+	}
+	if _, err := ExtractTextFromStream(reader, 3, fonts); err == nil {
+		t.Errorf("no error for the page dictionary")
+	}
+}
+
+func TestMultiFilterContents(t *testing.T) {
+	content := "BT /F1 12 Tf 72 712 Td (Hello filters) Tj ET"
+	flated, err := core.NewFlateEncoder().EncodeBytes([]byte(content))
+	if err != nil {
+		t.Fatalf("Flate: %v", err)
+	}
+	encoded, err := core.NewASCII85Encoder().EncodeBytes(flated)
+	if err != nil {
+		t.Fatalf("ASCII85: %v", err)
+	}
+
+	testcases := []struct {
+		name, dict string
+	}{
+		{"filters", "/Filter [/ASCII85Decode /FlateDecode]"},
+		{"abbreviation", "/Filter [/A85 /FlateDecode]"},
+		// the filter array indirect, and parameters for the first filter only
+		{"indirect", "/Filter 5 0 R /DecodeParms [null]"},
+	}
+	for _, tc := range testcases {
+		pdf := makePdf("",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R "+
+				"/Resources << /Font << /F1 "+helveticaFont+" >> >> >>",
+			makeStream(tc.dict, string(encoded)),
+			"[/ASCII85Decode /FlateDecode]")
+		text, err := JoinPageTexts(openPdf(t, pdf), DefaultPageSeparator)
+		if err != nil || text != "Hello filters" {
+			t.Errorf("%s: got %q, err: %v", tc.name, text, err)
+		}
+	}
+}
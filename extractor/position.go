@@ -0,0 +1,77 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"math"
+	"unicode/utf8"
+)
+
+// TextBox is the box of a glyph in points, from its origin on the baseline to its advance and the font
+// size above the baseline.  Only horizontal text is taken into account.
+type TextBox struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// PositionedText is text extracted along with the box of the glyph of each of its characters.
+type PositionedText struct {
+	Text string
+
+	// Box of the glyph of each rune of Text, nil for the spaces and line breaks inserted between glyphs.
+	boxes []*TextBox
+}
+
+// CharBox returns the box of the glyph of the character (0 based rune index) of Text.  Returns false if the
+// index is out of range, or if the character was inserted between glyphs, such as word spaces and line
+// breaks.  All the characters of a glyph decoded to several, such as a ligature, have the glyph box.
+func (t *PositionedText) CharBox(index int) (TextBox, bool) {
+	if index < 0 || index >= len(t.boxes) || t.boxes[index] == nil {
+		return TextBox{}, false
+	}
+	return *t.boxes[index], true
+}
+
+// ExtractPositionedText returns the text of the content stream, in content stream order, with the box of
+// each character.  A line break is inserted when a glyph leaves the baseline of the previous one, and a
+// space when it is separated from it by a word gap.
+func (e *Extractor) ExtractPositionedText() (*PositionedText, error) {
+	marks, err := e.ExtractTextMarks()
+	return positionText(marks, e.wordGapRatio), err
+}
+
+// positionText joins the text of the marks in order, recording the box of the mark of each rune.  A line
+// break is output when a mark leaves the baseline of the previous one, and a space when it is separated
+// from it by a gap wider than gapRatio of the font size.
+func positionText(marks []TextMark, gapRatio float64) *PositionedText {
+	var buf bytes.Buffer
+	boxes := []*TextBox{}
+
+	for i, mark := range marks {
+		if i > 0 {
+			prev := marks[i-1]
+			size := mark.FontSize
+			if size <= 0 {
+				size = 1
+			}
+			if math.Abs(mark.Y-prev.Y) > rowToleranceRatio*size {
+				buf.WriteString("\n")
+				boxes = append(boxes, nil)
+			} else if gap := mark.X - (prev.X + prev.Width); gap > gapRatio*size && prev.Text != " " && mark.Text != " " {
+				buf.WriteString(" ")
+				boxes = append(boxes, nil)
+			}
+		}
+
+		box := &TextBox{mark.X, mark.Y, mark.X + mark.Width, mark.Y + mark.FontSize}
+		buf.WriteString(mark.Text)
+		for n := utf8.RuneCountInString(mark.Text); n > 0; n-- {
+			boxes = append(boxes, box)
+		}
+	}
+
+	return &PositionedText{Text: buf.String(), boxes: boxes}
+}
@@ -0,0 +1,49 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+)
+
+func TestCharBox(t *testing.T) {
+	// é is a 2 byte rune, a space is inserted in the TJ gap and a line break before the second line
+	content := "BT /F1 10 Tf 100 700 Td [(Hell\\351) -500 (world)] TJ 0 -20 Td (ok) Tj ET"
+	e := contentExtractor(t, content, helveticaFont)
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	positioned, err := e.ExtractPositionedText()
+	if err != nil {
+		t.Fatalf("ExtractPositionedText: %v", err)
+	}
+	if positioned.Text != "Hellé world\nok" || len(marks) != 12 {
+		t.Fatalf("got %q of %d marks", positioned.Text, len(marks))
+	}
+
+	// the mark of each rune, -1 for those inserted
+	markIndexes := []int{0, 1, 2, 3, 4, -1, 5, 6, 7, 8, 9, -1, 10, 11}
+	for i, m := range markIndexes {
+		box, ok := positioned.CharBox(i)
+		if m < 0 {
+			if ok {
+				t.Errorf("character %d: box %+v for an inserted character", i, box)
+			}
+			continue
+		}
+		mark := marks[m]
+		if !ok || box != (TextBox{mark.X, mark.Y, mark.X + mark.Width, mark.Y + mark.FontSize}) {
+			t.Errorf("character %d: box %+v, ok %v, expected that of %+v", i, box, ok, mark)
+		}
+	}
+	if box, _ := positioned.CharBox(4); box.X0 <= 100 || box.Y0 != 700 || box.Y1 != 710 {
+		t.Errorf("5th character: box %+v", box)
+	}
+	if _, ok := positioned.CharBox(len(markIndexes)); ok {
+		t.Errorf("box for an index out of range")
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"../contentstream"
+	"../core"
+	"../model"
+)
+
+// ExtractRawStrings returns the string operands of the Tj, TJ, ' and " operators of the content of the page
+// (0 based index), in content stream order, as their raw bytes: the character codes, with no decoding by the
+// fonts, spacing or newlines.  Each string of a TJ array is returned separately.  A ground truth for
+// debugging the mapping of the codes to unicode.  The strings of form XObjects are not included.  The
+// strings parsed before an error of the content stream are returned with the error.
+func ExtractRawStrings(reader *model.PdfReader, pageIndex int) ([]string, error) {
+	content, err := reader.GetPageContent(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := []string{}
+	operations, err := contentstream.NewContentStreamParser(string(content)).Parse()
+	if operations == nil {
+		return strs, err
+	}
+
+	for _, op := range *operations {
+		if len(op.Params) == 0 {
+			continue
+		}
+		switch op.Operand {
+		case "Tj", "'", "\"":
+			if str, ok := op.Params[len(op.Params)-1].(*core.PdfObjectString); ok {
+				strs = append(strs, string(*str))
+			}
+		case "TJ":
+			if arr, ok := op.Params[0].(*core.PdfObjectArray); ok {
+				for _, obj := range *arr {
+					if str, ok := obj.(*core.PdfObjectString); ok {
+						strs = append(strs, string(*str))
+					}
+				}
+			}
+		}
+	}
+
+	return strs, err
+}
@@ -0,0 +1,27 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractRawStrings(t *testing.T) {
+	content := `BT /F1 12 Tf 72 700 Td (Hello\) \\x) Tj [(A) -100 <4243> 5 (D)] TJ (next) ' 1 2 (quote) " ET`
+	reader := openPdf(t, pagePdf(content, helveticaFont, ""))
+
+	strs, err := ExtractRawStrings(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractRawStrings: %v", err)
+	}
+	if expected := []string{`Hello) \x`, "A", "BC", "D", "next", "quote"}; !reflect.DeepEqual(strs, expected) {
+		t.Errorf("got %q, expected %q", strs, expected)
+	}
+	if _, err := ExtractRawStrings(reader, 1); err == nil {
+		t.Errorf("no error for an out of range page")
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+
+	"../contentstream"
+	"../core"
+	"../model"
+)
+
+const (
+	// Fewer shown characters per page on average than this count as almost no text.
+	scannedMaxCharsPerPage = 20
+	// Minimum fraction of the MediaBox an image must cover to count as a page scan.
+	scannedMinImageCoverage = 0.5
+)
+
+// IsLikelyScanned returns true when the document has almost no text while most of its pages are
+// covered by an image, as is the case for scanned documents without a text layer.  Intended as a quick
+// check before routing a document to OCR.
+func IsLikelyScanned(reader *model.PdfReader) bool {
+	pageCount := len(reader.GetPageList())
+	if pageCount == 0 {
+		return false
+	}
+
+	chars := 0
+	scannedPages := 0
+	for i := 0; i < pageCount; i++ {
+		pageChars, coverage := pageTextAndImageCoverage(reader, i)
+		chars += pageChars
+		if coverage >= scannedMinImageCoverage {
+			scannedPages++
+		}
+	}
+
+	return chars < scannedMaxCharsPerPage*pageCount && scannedPages*2 > pageCount
+}
+
+// pageTextAndImageCoverage returns the number of character codes shown on the page and the largest
+// fraction of the MediaBox covered by an image XObject or inline image.
+func pageTextAndImageCoverage(reader *model.PdfReader, pageIndex int) (int, float64) {
+	streams, err := reader.GetPageContentStreams(pageIndex)
+	if err != nil {
+		return 0, 0
+	}
+
+	var content bytes.Buffer
+	for _, stream := range streams {
+		data, err := reader.DecodeContentStream(stream)
+		if err != nil {
+			continue
+		}
+		content.Write(data)
+		content.WriteString("\n")
+	}
+
+	operations, _ := contentstream.NewContentStreamParser(content.String()).Parse()
+	if operations == nil {
+		return 0, 0
+	}
+
+	var xobjects *core.PdfObjectDictionary
+	if resources := reader.GetPageResources(); pageIndex < len(resources) && resources[pageIndex] != nil {
+		if obj, err := reader.GetParser().Trace(resources[pageIndex].Get("XObject")); err == nil {
+			xobjects, _ = obj.(*core.PdfObjectDictionary)
+		}
+	}
+
+	pageArea := 0.0
+	if box, err := reader.GetPageMediaBox(pageIndex); err == nil {
+		pageArea = (box[2] - box[0]) * (box[3] - box[1])
+		if pageArea < 0 {
+			pageArea = -pageArea
+		}
+	}
+
+	chars := 0
+	maxImageArea := 0.0
+	ctm := [6]float64{1, 0, 0, 1, 0, 0}
+	ctmStack := [][6]float64{}
+	imageArea := func() float64 {
+		// image space is the unit square mapped by the ctm
+		area := ctm[0]*ctm[3] - ctm[1]*ctm[2]
+		if area < 0 {
+			area = -area
+		}
+		return area
+	}
+
+	for _, op := range *operations {
+		switch op.Operand {
+		case "q":
+			ctmStack = append(ctmStack, ctm)
+		case "Q":
+			if len(ctmStack) > 0 {
+				ctm = ctmStack[len(ctmStack)-1]
+				ctmStack = ctmStack[:len(ctmStack)-1]
+			}
+		case "cm":
+			if len(op.Params) != 6 {
+				continue
+			}
+			var m [6]float64
+			valid := true
+			for i := 0; i < 6; i++ {
+				if m[i], err = core.GetNumberAsFloat(op.Params[i]); err != nil {
+					valid = false
+				}
+			}
+			if valid {
+				ctm = [6]float64{
+					m[0]*ctm[0] + m[1]*ctm[2], m[0]*ctm[1] + m[1]*ctm[3],
+					m[2]*ctm[0] + m[3]*ctm[2], m[2]*ctm[1] + m[3]*ctm[3],
+					m[4]*ctm[0] + m[5]*ctm[2] + ctm[4], m[4]*ctm[1] + m[5]*ctm[3] + ctm[5],
+				}
+			}
+		case "Tj", "'", "\"", "TJ":
+			for _, param := range op.Params {
+				if str, ok := param.(*core.PdfObjectString); ok {
+					chars += len(*str)
+				} else if arr, ok := param.(*core.PdfObjectArray); ok {
+					for _, obj := range *arr {
+						if str, ok := obj.(*core.PdfObjectString); ok {
+							chars += len(*str)
+						}
+					}
+				}
+			}
+		case "BI":
+			if area := imageArea(); area > maxImageArea {
+				maxImageArea = area
+			}
+		case "Do":
+			if xobjects == nil || len(op.Params) != 1 {
+				continue
+			}
+			name, ok := op.Params[0].(*core.PdfObjectName)
+			if !ok {
+				continue
+			}
+			xobj, err := reader.GetParser().Trace(xobjects.Get(*name))
+			if err != nil {
+				continue
+			}
+			if stream, ok := xobj.(*core.PdfObjectStream); ok {
+				if subtype, ok := stream.PdfObjectDictionary.Get("Subtype").(*core.PdfObjectName); ok && *subtype == "Image" {
+					if area := imageArea(); area > maxImageArea {
+						maxImageArea = area
+					}
+				}
+			}
+		}
+	}
+
+	if pageArea <= 0 {
+		return chars, 0
+	}
+	return chars, maxImageArea / pageArea
+}
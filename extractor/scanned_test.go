@@ -0,0 +1,33 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+)
+
+func TestIsLikelyScanned(t *testing.T) {
+	image := makeStream("/Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray "+
+		"/BitsPerComponent 8", "\x80")
+	testcases := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"text", "BT /F1 12 Tf 72 712 Td (A page of text, without any image.) Tj ET", false},
+		{"scan", "q 612 0 0 792 0 0 cm /Im1 Do Q", true},
+		{"small image", "q 100 0 0 100 0 0 cm /Im1 Do Q", false},
+		{"scan with text", "q 612 0 0 792 0 0 cm /Im1 Do Q BT /F1 12 Tf 72 712 Td (" +
+			"A text layer recognized from the page scan.) Tj ET", false},
+	}
+
+	for _, tc := range testcases {
+		pdf := pagePdf(tc.content, helveticaFont, "/XObject << /Im1 5 0 R >>", image)
+		if scanned := IsLikelyScanned(openPdf(t, pdf)); scanned != tc.expected {
+			t.Errorf("%s: IsLikelyScanned %t", tc.name, scanned)
+		}
+	}
+}
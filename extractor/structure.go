@@ -0,0 +1,137 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"errors"
+
+	"../model"
+)
+
+// ExtractStructuredText returns the text of the page (0 based index) in the logical reading order of a
+// Tagged PDF: the glyphs of the marked content are ordered by the structure tree, whatever their order in
+// the content stream.  Glyphs outside of the marked content of the structure tree, such as artifacts,
+// follow in content stream order.  The text of untagged documents is returned in content stream order.
+// The fonts must have been parsed with ParseFonts.
+func ExtractStructuredText(reader *model.PdfReader, pageIndex int) (string, error) {
+	fontsForPages := reader.GetFontsForPages()
+	if pageIndex < 0 || pageIndex >= len(reader.GetPageList()) {
+		return "", errors.New("page index out of range")
+	}
+	if pageIndex >= len(fontsForPages) {
+		return "", errors.New("fonts not parsed")
+	}
+
+	mcids, err := reader.GetPageStructureMCIDs(pageIndex)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := reader.GetPageContent(pageIndex)
+	if err != nil {
+		return "", err
+	}
+
+	e := New(string(content), fontsForPages[pageIndex])
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		e.log().Debug("Error: content stream of page %d partly parsed, err: %v", pageIndex, err)
+	}
+
+	return positionText(structureOrder(marks, mcids), e.wordGapRatio).Text, nil
+}
+
+// StructureBlock is the text of a structure element of a page.
+type StructureBlock struct {
+	// Role is the standard structure type of the element, e.g. P or H1, its custom type being mapped by the
+	// /RoleMap of the document.
+	Role string
+	Text string
+}
+
+// ExtractStructureBlocks returns the text of the structure elements of the page (0 based index) of a Tagged
+// PDF in the logical reading order, each with the standard structure type of the element, so that custom
+// types are handled as the standard ones they are mapped to, e.g. a custom Title mapped to H1 as a heading.
+// Elements without text on the page are skipped.  Returns an empty list for untagged documents.  The fonts
+// must have been parsed with ParseFonts.
+func ExtractStructureBlocks(reader *model.PdfReader, pageIndex int) ([]StructureBlock, error) {
+	fontsForPages := reader.GetFontsForPages()
+	if pageIndex < 0 || pageIndex >= len(reader.GetPageList()) {
+		return nil, errors.New("page index out of range")
+	}
+	if pageIndex >= len(fontsForPages) {
+		return nil, errors.New("fonts not parsed")
+	}
+
+	contents, err := reader.GetPageStructureContents(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+	blocks := []StructureBlock{}
+	if len(contents) == 0 {
+		return blocks, nil
+	}
+
+	content, err := reader.GetPageContent(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	e := New(string(content), fontsForPages[pageIndex])
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		e.log().Debug("Error: content stream of page %d partly parsed, err: %v", pageIndex, err)
+	}
+	byMCID := map[int][]TextMark{}
+	for _, mark := range marks {
+		byMCID[mark.MCID] = append(byMCID[mark.MCID], mark)
+	}
+
+	// the consecutive contents of an element make a block
+	used := map[int]bool{}
+	for i := 0; i < len(contents); {
+		j := i
+		elementMarks := []TextMark{}
+		for ; j < len(contents) && contents[j].Element == contents[i].Element; j++ {
+			if !used[contents[j].MCID] {
+				used[contents[j].MCID] = true
+				elementMarks = append(elementMarks, byMCID[contents[j].MCID]...)
+			}
+		}
+		if text := positionText(elementMarks, e.wordGapRatio).Text; text != "" {
+			blocks = append(blocks, StructureBlock{Role: contents[i].Role, Text: text})
+		}
+		i = j
+	}
+
+	return blocks, nil
+}
+
+// structureOrder returns the marks grouped by MCID in the order of mcids, followed by the marks of
+// the other MCIDs and those outside of marked content.  The order within a group is kept.
+func structureOrder(marks []TextMark, mcids []int) []TextMark {
+	byMCID := map[int][]TextMark{}
+	for _, mark := range marks {
+		byMCID[mark.MCID] = append(byMCID[mark.MCID], mark)
+	}
+
+	ordered := make([]TextMark, 0, len(marks))
+	used := map[int]bool{}
+	for _, mcid := range mcids {
+		if used[mcid] {
+			continue
+		}
+		used[mcid] = true
+		ordered = append(ordered, byMCID[mcid]...)
+	}
+	for _, mark := range marks {
+		if !used[mark.MCID] {
+			ordered = append(ordered, mark)
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,94 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"testing"
+)
+
+// taggedPdf returns a PDF file of a tagged page of the content with the font F1, the structure tree root
+// having the kids, e.g. "6 0 R 7 0 R", and the objects numbered from 6 on.
+func taggedPdf(content, kids string, objects ...string) []byte {
+	return makePdf("", append([]string{
+		"<< /Type /Catalog /Pages 2 0 R /StructTreeRoot 5 0 R /MarkInfo << /Marked true >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R " +
+			"/Resources << /Font << /F1 " + helveticaFont + " >> >> >>",
+		makeStream("", content),
+		"<< /Type /StructTreeRoot /K [" + kids + "] >>",
+	}, objects...)...)
+}
+
+func TestExtractStructuredText(t *testing.T) {
+	// the content shows the second paragraph first, then an artifact
+	content := "/P << /MCID 0 >> BDC BT /F1 12 Tf 72 600 Td (Second) Tj ET EMC\n" +
+		"/P << /MCID 1 >> BDC BT /F1 12 Tf 72 700 Td (First) Tj ET EMC\n" +
+		"/Artifact BMC BT /F1 12 Tf 72 50 Td (Footer) Tj ET EMC"
+	pdf := taggedPdf(content, "6 0 R",
+		"<< /Type /StructElem /S /Document /P 5 0 R /Pg 3 0 R /K [7 0 R 8 0 R] >>",
+		"<< /Type /StructElem /S /P /P 6 0 R /K 1 >>",
+		"<< /Type /StructElem /S /P /P 6 0 R /K [0] >>")
+	reader := openPdf(t, pdf)
+	if !reader.IsTagged() {
+		t.Fatalf("not tagged")
+	}
+
+	text, err := ExtractStructuredText(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractStructuredText: %v", err)
+	}
+	if text != "First\nSecond\nFooter" {
+		t.Errorf("got %q", text)
+	}
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "Second\nFirst\nFooter" {
+		t.Errorf("content order: got %q", text)
+	}
+}
+
+func TestExtractStructureBlocks(t *testing.T) {
+	// a custom Title mapped to H1, Para to P through another custom type, a mapping loop and a standard type
+	// that isn't remapped
+	content := "/Para << /MCID 0 >> BDC BT /F1 12 Tf 72 600 Td (Body) Tj ET EMC\n" +
+		"/Title << /MCID 1 >> BDC BT /F1 24 Tf 72 700 Td (Heading) Tj ET EMC\n" +
+		"/Loop << /MCID 2 >> BDC BT /F1 12 Tf 72 500 Td (Odd) Tj ET EMC\n" +
+		"/Span << /MCID 3 >> BDC BT /F1 12 Tf 72 400 Td (Note) Tj ET EMC"
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R /StructTreeRoot 5 0 R /MarkInfo << /Marked true >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 "+helveticaFont+" >> >> >>",
+		makeStream("", content),
+		"<< /Type /StructTreeRoot /K [6 0 R] /RoleMap 7 0 R >>",
+		"<< /Type /StructElem /S /Document /P 5 0 R /Pg 3 0 R /K [8 0 R 9 0 R 10 0 R 11 0 R] >>",
+		"<< /Title /H1 /Para /Text /Text /P /Loop /Cycle /Cycle /Loop /Span /Quote >>",
+		"<< /Type /StructElem /S /Title /P 6 0 R /K 1 >>",
+		"<< /Type /StructElem /S /Para /P 6 0 R /K 0 >>",
+		"<< /Type /StructElem /S /Loop /P 6 0 R /K 2 >>",
+		"<< /Type /StructElem /S /Span /P 6 0 R /K 3 >>")
+	reader := openPdf(t, pdf)
+
+	if roleMap := reader.GetStructureRoleMap(); len(roleMap) != 6 || roleMap["Title"] != "H1" {
+		t.Errorf("role map %v", roleMap)
+	}
+
+	blocks, err := ExtractStructureBlocks(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractStructureBlocks: %v", err)
+	}
+	expected := []StructureBlock{{"H1", "Heading"}, {"P", "Body"}, {"Loop", "Odd"}, {"Span", "Note"}}
+	if len(blocks) != len(expected) {
+		t.Fatalf("got %v", blocks)
+	}
+	for i, block := range blocks {
+		if block != expected[i] {
+			t.Errorf("block %d: got %v, expected %v", i, block, expected[i])
+		}
+	}
+
+	if _, err := ExtractStructureBlocks(reader, 1); err == nil {
+		t.Errorf("no error for a page out of range")
+	}
+}
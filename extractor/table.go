@@ -0,0 +1,145 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"../model"
+)
+
+const (
+	// Words on baselines closer than this fraction of the font size are on the same row.
+	rowToleranceRatio = 0.5
+	// Columns are separated by at least this fraction of the font size of blank space.
+	columnGapRatio = 1.0
+)
+
+// textWord is a run of glyphs on a baseline, without spaces or gaps in between.
+type textWord struct {
+	text     string
+	x0, x1   float64 // left and right edges
+	y        float64 // baseline
+	fontSize float64
+}
+
+// textWords groups the marks into words, separated by gaps wider than gapRatio of the font size.  Only
+// horizontal text is taken into account.
+func textWords(marks []TextMark, gapRatio float64) []textWord {
+	words := []textWord{}
+	var word *textWord
+
+	for _, mark := range marks {
+		if strings.TrimFunc(mark.Text, unicode.IsSpace) == "" {
+			word = nil
+			continue
+		}
+
+		size := mark.FontSize
+		if size <= 0 {
+			size = 1
+		}
+		if word != nil && math.Abs(mark.Y-word.y) <= rowToleranceRatio*size &&
+			mark.X >= word.x1-size && mark.X-word.x1 <= gapRatio*size {
+			word.text += mark.Text
+			word.x1 = math.Max(word.x1, mark.X+mark.Width)
+			continue
+		}
+
+		words = append(words, textWord{mark.Text, mark.X, mark.X + mark.Width, mark.Y, size})
+		word = &words[len(words)-1]
+	}
+
+	return words
+}
+
+// tableFromWords clusters the words into a grid: rows of words on the same baseline, from top to bottom,
+// and columns of the x ranges of the words separated by blank space, from left to right.  Words in the same
+// cell are joined by a space, cells without words are empty strings.
+func tableFromWords(words []textWord) [][]string {
+	if len(words) == 0 {
+		return [][]string{}
+	}
+
+	sorted := make([]textWord, len(words))
+	copy(sorted, words)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].y > sorted[j].y })
+
+	rows := [][]textWord{}
+	rowY := 0.0
+	for _, word := range sorted {
+		if len(rows) == 0 || math.Abs(word.y-rowY) > rowToleranceRatio*word.fontSize {
+			rows = append(rows, []textWord{})
+			rowY = word.y
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], word)
+	}
+
+	// columns are the merged x ranges of the words
+	byX := make([]textWord, len(words))
+	copy(byX, words)
+	sort.Slice(byX, func(i, j int) bool { return byX[i].x0 < byX[j].x0 })
+	columns := [][2]float64{}
+	for _, word := range byX {
+		last := len(columns) - 1
+		if last >= 0 && word.x0 <= columns[last][1]+columnGapRatio*word.fontSize {
+			columns[last][1] = math.Max(columns[last][1], word.x1)
+			continue
+		}
+		columns = append(columns, [2]float64{word.x0, word.x1})
+	}
+
+	table := make([][]string, len(rows))
+	for i, row := range rows {
+		sort.Slice(row, func(a, b int) bool { return row[a].x0 < row[b].x0 })
+		cells := make([][]string, len(columns))
+		for _, word := range row {
+			col := sort.Search(len(columns), func(c int) bool { return columns[c][1] >= word.x0 })
+			if col == len(columns) {
+				col--
+			}
+			cells[col] = append(cells[col], word.text)
+		}
+
+		table[i] = make([]string, len(columns))
+		for j := range cells {
+			table[i][j] = strings.Join(cells[j], " ")
+		}
+	}
+
+	return table
+}
+
+// ExtractTables reconstructs the text of the page (0 based index) as a table and returns its cells, row
+// by row.  Rows are detected from the baselines and columns from the horizontal extents of the words, so
+// this suits simple tables without spanning cells.  The fonts must have been parsed with ParseFonts.
+// Experimental.
+func ExtractTables(reader *model.PdfReader, pageIndex int) ([][]string, error) {
+	fontsForPages := reader.GetFontsForPages()
+	if pageIndex < 0 || pageIndex >= len(reader.GetPageList()) {
+		return nil, errors.New("page index out of range")
+	}
+	if pageIndex >= len(fontsForPages) {
+		return nil, errors.New("fonts not parsed")
+	}
+
+	content, err := reader.GetPageContent(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	e := New(string(content), fontsForPages[pageIndex])
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		e.log().Debug("Error: content stream of page %d partly parsed, err: %v", pageIndex, err)
+	}
+
+	return tableFromWords(textWords(marks, e.wordGapRatio)), nil
+}
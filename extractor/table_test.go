@@ -0,0 +1,32 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractTables(t *testing.T) {
+	// 3 rows of 2 columns, without rules, the second cell of the last row empty
+	content := "BT /F1 10 Tf 72 700 Td (Name) Tj 228 0 Td (Quantity) Tj ET\n" +
+		"BT /F1 10 Tf 72 686 Td (Apple pie) Tj 228 0 Td (12) Tj ET\n" +
+		"BT /F1 10 Tf 72 672 Td (Pear) Tj ET"
+	reader := openPdf(t, pagePdf(content, helveticaFont, ""))
+
+	table, err := ExtractTables(reader, 0)
+	if err != nil {
+		t.Fatalf("ExtractTables: %v", err)
+	}
+	expected := [][]string{
+		{"Name", "Quantity"},
+		{"Apple pie", "12"},
+		{"Pear", ""},
+	}
+	if !reflect.DeepEqual(table, expected) {
+		t.Errorf("got %q, expected %q", table, expected)
+	}
+}
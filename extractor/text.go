@@ -9,14 +9,27 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
 
 	"../cmap"
-	"../common"
 	"../contentstream"
 	"../core"
 	"../model"
 )
 
+// A baseline drop of more than this fraction of the font size starts a new line.
+const newlineDropRatio = 0.5
+
+// Text whose baseline is offset from the normal text of the line by more than this fraction of the font size
+// of the line is a superscript or a subscript.
+const scriptOffsetRatio = 0.15
+
 // ExtractText processes and extracts all text data in content streams and returns as a string. Takes into
 // account character encoding via CMaps in the PDF file.
 // The text is processed linearly e.g. in the order in which it appears. A best effort is done to add
@@ -32,97 +45,198 @@ func (e *Extractor) ExtractText() (string, error) {
 
 	processor := contentstream.NewContentStreamProcessor(*operations)
 
-	var codemap *cmap.CMap
-	var cidCodemap *cmap.CMap
 	var font *model.Font
 	inText := false
-	xPos, yPos, xTx := float64(-1), float64(-1), float64(-1)
-
-	preRect0, preRect1, preRect2, preRect3 := float64(-1), float64(-1), float64(-1), float64(-1)
-	rect0, rect1, rect2, rect3 := float64(-1), float64(-1), float64(-1), float64(-1)
+	xPos, xTx := float64(-1), float64(-1)
 
 	var cMatrix [6]float64 = [6]float64{1, 0, 0, 1, 0, 0}
 
 	fontSize := 0.0
 	mScaling := 100.0
+	charSpacing, wordSpacing := 0.0, 0.0
+
+	// advances xPos by the character spacing of each glyph of the shown string and the word spacing,
+	// applying to the single byte code 32 only
+	advanceSpacing := func(data []byte) {
+		codes, _ := e.glyphCodes(font, data)
+		spacing := charSpacing * float64(len(codes))
+		if font == nil || !font.IsMultibyte() {
+			spacing += wordSpacing * float64(bytes.Count(data, []byte(" ")))
+		}
+		xPos += spacing * (mScaling / 100.0)
+	}
+
+	// text line matrix and leading, moved by Td, TD, T* and Tm.  xPos keeps following the Tm moves only.
+	tlm := identityMatrix
+	leading := 0.0
+	// moves the text line matrix to the start of the next line, offset by (tx, ty)
+	nextLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.mult(tlm)
+	}
+
+	// the text of hidden optional content is skipped, along with the line breaks of its moves
+	content := hiddenContent{properties: e.hiddenProperties}
+
+	// text rise (Ts), in unscaled text space units
+	rise := 0.0
+	// baseline and font size in user space of the normal text of the current line, the reference of the
+	// superscripts and subscripts, and the script of the text output last: 1 superscript, -1 subscript
+	scriptY, scriptSize, hasScriptLine := 0.0, 0.0, false
+	script := 0
+	// outputs the markers closing the current script and opening s
+	setScript := func(s int) {
+		if s == script {
+			return
+		}
+		switch script {
+		case 1:
+			buf.WriteString(e.superscriptMarkers[1])
+		case -1:
+			buf.WriteString(e.subscriptMarkers[1])
+		}
+		switch s {
+		case 1:
+			buf.WriteString(e.superscriptMarkers[0])
+		case -1:
+			buf.WriteString(e.subscriptMarkers[0])
+		}
+		script = s
+	}
+	// ends the script of the line at a line break
+	endLine := func() {
+		setScript(0)
+		hasScriptLine = false
+	}
+	// outputs the script markers of the text about to be shown, from the offset of its baseline to the
+	// normal text of the line.  The first text of the line, or a larger one, is the normal text.
+	markScript := func() {
+		if !e.scriptMarkers {
+			return
+		}
+		y := cMatrix[3]*(tlm[3]*rise+tlm[5]) + cMatrix[5]
+		size := math.Abs(fontSize * tlm[3] * cMatrix[3])
+		if !hasScriptLine || size > scriptSize {
+			scriptY, scriptSize, hasScriptLine = y, size, true
+		}
+		switch offset := y - scriptY; {
+		case offset > scriptOffsetRatio*scriptSize:
+			setScript(1)
+		case offset < -scriptOffsetRatio*scriptSize:
+			setScript(-1)
+		default:
+			setScript(0)
+		}
+	}
+
+	// outputs the text of a shown string, unless hidden or smaller than the minimum font size
+	showString := func(text string) {
+		if content.hidden() {
+			return
+		}
+		if e.minFontSize > 0 && math.Abs(fontSize*tlm[3]*cMatrix[3])*e.unitMatrix()[0] < e.minFontSize {
+			return
+		}
+		markScript()
+		buf.WriteString(text)
+	}
+
+	// baseline and font size of the last line in user space, kept across text objects
+	baseline, baselineSize, hasBaseline := 0.0, 0.0, false
+	// whether a paragraph separator was output at the move to the current line, in place of the newline
+	separated := false
+	// outputs a newline when the text line matrix moved to another baseline.  Baseline changes of less than
+	// half the larger of the font sizes, such as superscripts and subscripts, don't start a new line.  Moves
+	// up start one too: producers draw footers before the body and columns one after the other.
+	lineBreak := func() bool {
+		if content.hidden() {
+			return false
+		}
+		y := cMatrix[3]*tlm[5] + cMatrix[5]
+		size := math.Abs(fontSize * tlm[3] * cMatrix[3])
+		newline := hasBaseline && math.Abs(baseline-y) > newlineDropRatio*math.Max(size, baselineSize)
+		baseline, baselineSize, hasBaseline = y, size, true
+		if newline {
+			endLine()
+			if !separated {
+				buf.WriteString("\n")
+			}
+		}
+		separated = false
+		return newline
+	}
+
+	// baseline of the text line matrix, for the paragraph breaks between text objects
+	lineY, prevLineY := 0.0, 0.0
+	hasPrevText, lineStarted := false, false
+	paragraphBreak := func(y float64) {
+		if content.hidden() {
+			return
+		}
+		if e.paragraphBreaks && hasPrevText && !lineStarted {
+			threshold := 1.5 * fontSize
+			if threshold <= 0 {
+				threshold = 18
+			}
+			if cMatrix[3]*(prevLineY-y) > threshold {
+				endLine()
+				buf.WriteString(e.paragraphSeparator)
+				separated = true
+			}
+		}
+		lineStarted = true
+	}
 
 	processor.AddHandler(contentstream.HandlerConditionEnumAllOperands, "",
 		func(op *contentstream.ContentStreamOperation, f model.FontsByNames) error {
+			content.process(op)
 			operand := op.Operand
 			switch operand {
 			case "cm":
 				if inText {
-					common.Log.Debug("cm operand outside text")
+					e.log().Debug("cm operand outside text")
 					return nil
 				}
 				if len(op.Params) != 6 {
-					common.Log.Debug("Error cm should only get 6 input params, got %d", len(op.Params))
+					e.log().Debug("Error cm should only get 6 input params, got %d", len(op.Params))
 					return errors.New("Incorrect parameter count")
 				}
 
 				for i := 0; i < 6; i++ {
 					cMatrix[i], err = core.GetNumberAsFloat(op.Params[i])
 					if err != nil {
-						common.Log.Debug("cm Float parse error")
+						e.log().Debug("cm Float parse error")
 						return nil
 					}
 				}
-			case "re":
-				if inText {
-					common.Log.Debug("re operand outside text")
-					return nil
-				}
-				if len(op.Params) != 4 {
-					common.Log.Debug("Error re should only get 4 input params, got %d", len(op.Params))
-					return errors.New("Incorrect parameter count")
-				}
-
-				rect0, err = core.GetNumberAsFloat(op.Params[0])
-				if err != nil {
-					common.Log.Debug("re Float parse error")
-					return nil
-				}
-				rect1, err = core.GetNumberAsFloat(op.Params[1])
-				if err != nil {
-					common.Log.Debug("re Float parse error")
-					return nil
-				}
-				rect2, err = core.GetNumberAsFloat(op.Params[2])
-				if err != nil {
-					common.Log.Debug("re Float parse error")
-					return nil
-				}
-				rect3, err = core.GetNumberAsFloat(op.Params[3])
-				if err != nil {
-					common.Log.Debug("re Float parse error")
-					return nil
-				}
 			case "BT":
 				inText = true
+				tlm = identityMatrix
+				lineY = 0
+				lineStarted = false
 			case "ET":
 				inText = false
-				preRect0 = rect0
-				preRect1 = rect1
-				preRect2 = rect2
-				preRect3 = rect3
+				if lineStarted {
+					prevLineY = lineY
+					hasPrevText = true
+				}
 			case "Tf":
 				if !inText {
-					common.Log.Debug("Tf operand outside text")
+					e.log().Debug("Tf operand outside text")
 					return nil
 				}
 
 				if len(op.Params) != 2 {
-					common.Log.Debug("Error Tf should only get 2 input params, got %d", len(op.Params))
+					e.log().Debug("Error Tf should only get 2 input params, got %d", len(op.Params))
 					return errors.New("Incorrect parameter count")
 				}
 
 				fontName, ok := op.Params[0].(*core.PdfObjectName)
 				if !ok {
-					common.Log.Debug("Error Tf font input not a name, %s", op.Params[0])
+					e.log().Debug("Error Tf font input not a name, %s", op.Params[0])
 					return errors.New("Tf range error")
 				}
 
-				common.Log.Trace("fontName: %s", fontName)
+				e.log().Trace("fontName: %s", fontName)
 
 				size, err := core.GetNumberAsFloat(op.Params[1])
 				if err != nil {
@@ -132,32 +246,31 @@ func (e *Extractor) ExtractText() (string, error) {
 				}
 
 				font = nil
-				codemap = nil
-				cidCodemap = nil
-				if font, ok = f[core.PdfObjectName(*fontName)]; ok {
-					codemap = font.GetCmap()
-					cidCodemap = font.GetCidCmap()
-				} else {
-					common.Log.Debug("Error: can't find Tf font by name")
+				if font, ok = f[core.PdfObjectName(*fontName)]; !ok {
+					e.log().Debug("Error: can't find Tf font by name")
 					return errors.New("can't find Tf font by name")
 				}
+			case "TL":
+				if v, ok := getNumbers(op.Params, 1); ok {
+					leading = v[0]
+				}
 			case "T*":
 				if !inText {
-					common.Log.Debug("T* operand outside text")
+					e.log().Debug("T* operand outside text")
 					return nil
 				}
-				if rect0 != preRect0 || rect1 != preRect1 || rect2 != preRect2 || rect3 != preRect3 {
-					buf.WriteString("\n")
-				}
+				nextLine(0, -leading)
+				lineY = tlm[5]
+				lineBreak()
 			case "'":
 				//quote = T* + Tj
 				if !inText {
-					common.Log.Debug("quote operand outside text")
+					e.log().Debug("quote operand outside text")
 					return nil
 				}
-				if rect0 != preRect0 || rect1 != preRect1 || rect2 != preRect2 || rect3 != preRect3 {
-					buf.WriteString("\n")
-				}
+				nextLine(0, -leading)
+				lineY = tlm[5]
+				lineBreak()
 				if len(op.Params) < 1 {
 					return nil
 				}
@@ -166,102 +279,98 @@ func (e *Extractor) ExtractText() (string, error) {
 					return fmt.Errorf("Invalid parameter type, not string (%T)", op.Params[0])
 				}
 
-				//first change charcode to cid string
-				if font != nil && font.GetmPredefinedCmap() && cidCodemap != nil {
-					str := cidCodemap.CharcodeBytesToCidStr([]byte(*param))
-					param = core.MakeString(str)
-				}
-
-				if codemap != nil {
-					if font.GetSimpleEncodingTableFlag() {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), font.GetSimpleEncodingTable(), true))
-					} else {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), []uint{}, false))
-					}
-				} else {
-					if font != nil && font.GetSimpleEncodingTableFlag() {
-						for _, cid := range []byte(*param) {
-							r := cmap.Utf8CodepointToUtf8(font.GetSimpleEncodingTable()[cid])
-							buf.WriteString(r)
-						}
-					} else {
-						buf.WriteString(string(*param))
-					}
-				}
+				showString(e.decodeCodes(font, []byte(*param)))
+				advanceSpacing([]byte(*param))
 			case "\"":
 				//quote = T* + ac + aw + Tj
 				if !inText {
-					common.Log.Debug("double quote operand outside text")
+					e.log().Debug("double quote operand outside text")
+					return nil
+				}
+				nextLine(0, -leading)
+				lineY = tlm[5]
+				lineBreak()
+				if len(op.Params) != 3 {
+					e.log().Debug("Error \" should get 3 input params, got %d", len(op.Params))
 					return nil
 				}
-				if rect0 != preRect0 || rect1 != preRect1 || rect2 != preRect2 || rect3 != preRect3 {
-					buf.WriteString("\n")
+				aw, err := core.GetNumberAsFloat(op.Params[0])
+				if err != nil {
+					e.log().Debug("\" word spacing Float parse error")
+					return nil
 				}
-				if len(op.Params) < 1 {
+				ac, err := core.GetNumberAsFloat(op.Params[1])
+				if err != nil {
+					e.log().Debug("\" character spacing Float parse error")
 					return nil
 				}
+				wordSpacing, charSpacing = aw, ac
+
 				param, ok := op.Params[2].(*core.PdfObjectString)
 				if !ok {
 					return fmt.Errorf("Invalid parameter type, not string (%T)", op.Params[2])
 				}
 
-				//first change charcode to cid string
-				if font != nil && font.GetmPredefinedCmap() && cidCodemap != nil {
-					str := cidCodemap.CharcodeBytesToCidStr([]byte(*param))
-					param = core.MakeString(str)
+				showString(e.decodeCodes(font, []byte(*param)))
+				advanceSpacing([]byte(*param))
+			case "Ts":
+				if v, ok := getNumbers(op.Params, 1); ok {
+					rise = v[0]
 				}
-
-				if codemap != nil {
-					if font.GetSimpleEncodingTableFlag() {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), font.GetSimpleEncodingTable(), true))
-					} else {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), []uint{}, false))
-					}
-				} else {
-					if font != nil && font.GetSimpleEncodingTableFlag() {
-						for _, cid := range []byte(*param) {
-							r := cmap.Utf8CodepointToUtf8(font.GetSimpleEncodingTable()[cid])
-							buf.WriteString(r)
-						}
-					} else {
-						buf.WriteString(string(*param))
-					}
+			case "Tc":
+				if v, ok := getNumbers(op.Params, 1); ok {
+					charSpacing = v[0]
+				}
+			case "Tw":
+				if len(op.Params) != 1 {
+					e.log().Debug("Error Tw should only get 1 input param, got %d", len(op.Params))
+					return nil
+				}
+				spacing, err := core.GetNumberAsFloat(op.Params[0])
+				if err != nil {
+					e.log().Debug("Tw Float parse error")
+					return nil
 				}
+				wordSpacing = spacing
 			case "Td", "TD":
 				if !inText {
-					common.Log.Debug("Td/TD operand outside text")
+					e.log().Debug("Td/TD operand outside text")
 					return nil
 				}
 
 				// Params: [tx ty], corresponeds to Tm=Tlm=[1 0 0;0 1 0;tx ty 1]*Tm
 				if len(op.Params) != 2 {
-					common.Log.Debug("Td/TD invalid arguments")
+					e.log().Debug("Td/TD invalid arguments")
 					return nil
 				}
 				tx, err := core.GetNumberAsFloat(op.Params[0])
 				if err != nil {
-					common.Log.Debug("Td Float parse error")
+					e.log().Debug("Td Float parse error")
 					return nil
 				}
 				ty, err := core.GetNumberAsFloat(op.Params[1])
 				if err != nil {
-					common.Log.Debug("Td Float parse error")
+					e.log().Debug("Td Float parse error")
 					return nil
 				}
 
+				if operand == "TD" {
+					leading = -ty
+				}
+				nextLine(tx, ty)
+
+				lineY = tlm[5]
+				paragraphBreak(lineY)
+
 				if tx > 0 {
 					xTx = tx
 					//buf.WriteString(" ")
 				}
-				if ty < 0 {
-					// TODO: More flexible space characters?
-					if rect0 != preRect0 || rect1 != preRect1 || rect2 != preRect2 || rect3 != preRect3 {
-						buf.WriteString("\n")
-					}
-				}
+				// TODO: More flexible space characters?
+				lineBreak()
 			case "Tm":
 				if !inText {
-					common.Log.Debug("Tm operand outside text")
+					e.log().Debug("Tm operand outside text")
 					return nil
 				}
 
@@ -287,24 +396,25 @@ func (e *Extractor) ExtractText() (string, error) {
 					yfloat = core.MakeFloat(float64(*yint))
 				}
 
-				if yPos == -1 {
-					yPos = float64(*yfloat)
-				} else if cMatrix[3]*yPos > cMatrix[3]*float64(*yfloat) {
-					if rect0 != preRect0 || rect1 != preRect1 || rect2 != preRect2 || rect3 != preRect3 {
-						buf.WriteString("\n")
-					}
+				if m, ok := getMatrix(op.Params); ok {
+					tlm = m
+				}
+				lineY = float64(*yfloat)
+				if content.hidden() {
+					return nil
+				}
+				paragraphBreak(lineY)
+				separatedLine := separated
 
+				if lineBreak() {
 					//temp bugfix for using TD and next line
 					xPos += -(xTx*cMatrix[0]*fontSize/1000.0 + fontSize)
-					if xPos < float64(*xfloat) {
+					if xPos < float64(*xfloat) && !e.baselineNewlines && !separatedLine {
 						buf.WriteString("\n")
 					}
 
 					xPos = float64(*xfloat)
-					yPos = float64(*yfloat)
 					return nil
-				} else {
-					yPos = float64(*yfloat)
 				}
 
 				if xPos == -1 {
@@ -315,80 +425,74 @@ func (e *Extractor) ExtractText() (string, error) {
 				}
 			case "TJ":
 				if !inText {
-					common.Log.Debug("TJ operand outside text")
+					e.log().Debug("TJ operand outside text")
 					return nil
 				}
 				if len(op.Params) < 1 {
 					return nil
 				}
+				// a malformed TJ is skipped rather than ending the extraction of the stream
 				paramList, ok := op.Params[0].(*core.PdfObjectArray)
 				if !ok {
-					return fmt.Errorf("Invalid parameter type, no array (%T)", op.Params[0])
+					e.log().Debug("Error: TJ parameter not an array (%T), skipped", op.Params[0])
+					return nil
 				}
 
 				sum := 0
-				for index, obj := range *paramList {
-					switch v := obj.(type) {
-					case *core.PdfObjectString:
-						//first change charcode to cid string
-						if font != nil && font.GetmPredefinedCmap() && cidCodemap != nil {
-							str := cidCodemap.CharcodeBytesToCidStr([]byte(*v))
-							v = core.MakeString(str)
-						}
-
-						//common.Log.Debug("origin: %X", []byte(*v))
-
-						// has ToUnicode
-						if codemap != nil {
-							//common.Log.Debug("parsed str: %s", codemap.CharcodeBytesToUnicode([]byte(*v), []uint{}, false))
-							if font.GetSimpleEncodingTableFlag() {
-								buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*v), font.GetSimpleEncodingTable(), true))
-							} else {
-								buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*v), []uint{}, false))
-							}
-						} else {
-							//no ToUnicode but has font encoding
-							if font != nil && font.GetSimpleEncodingTableFlag() {
-								for _, cid := range []byte(*v) {
-									r := cmap.Utf8CodepointToUtf8(font.GetSimpleEncodingTable()[cid])
-									buf.WriteString(r)
-								}
-							} else {
-								buf.WriteString(string(*v))
-							}
+				shown := false
+				type3Advance, isType3 := 0.0, false
+				// displacement since the last string, a word space when wider than the word gap ratio of
+				// the font size
+				gap := 0.0
+				for _, obj := range *paramList {
+					if v, ok := obj.(*core.PdfObjectString); ok {
+						cids := e.charcodesToCids(font, []byte(*v))
+						text := e.decodeCodes(font, []byte(*v))
+						if shown && gap > e.wordGapRatio*fontSize && isWordSpace(buf.Bytes(), text) {
+							showString(" ")
 						}
-
-						sum += len([]byte(*v))
-
-						if index == len(*paramList)-1 {
-							xPos += fontSize * float64(sum/2)
-							//default space size
-							xPos += 1.5
+						gap = 0
+						showString(text)
+						advanceSpacing([]byte(*v))
+
+						shown = true
+						sum += len(cids)
+						if advance, ok := e.type3Advance(font, []byte(*v)); ok {
+							type3Advance += advance
+							isType3 = true
 						}
-
-					case *core.PdfObjectFloat:
-						xPos += float64(-*v) * (mScaling / 100.0) * fontSize / 1000.0
-					case *core.PdfObjectInteger:
-						xPos += float64(-*v) * (mScaling / 100.0) * fontSize / 1000.0
+					} else if v, err := core.GetNumberAsFloat(obj); err == nil {
+						// integer and real displacements alike
+						displacement := -v * (mScaling / 100.0) * fontSize / 1000.0
+						xPos += displacement
+						gap += displacement
 					}
 				}
-			case "TZ":
-				if !inText {
-					common.Log.Debug("TZ operand outside text")
-					return nil
+
+				// the advance of the strings, whether or not a displacement ends the array
+				if shown {
+					if isType3 {
+						xPos += type3Advance * (mScaling / 100.0) * fontSize
+					} else {
+						xPos += fontSize * float64(sum/2) * (mScaling / 100.0)
+					}
+					//default space size
+					xPos += 1.5
 				}
+			case "Tz":
+				// horizontal scaling, a percentage of the normal width
 				if len(op.Params) < 1 {
 					return nil
 				}
-				param, ok := op.Params[0].(*core.PdfObjectInteger)
-				if !ok {
-					return fmt.Errorf("Invalid parameter type, not integer (%T)", op.Params[0])
+				scaling, err := core.GetNumberAsFloat(op.Params[0])
+				if err != nil {
+					return fmt.Errorf("Invalid parameter type, not number (%T)", op.Params[0])
 				}
 
-				mScaling = float64(*param)
+				mScaling = scaling
 			case "Tj":
 				if !inText {
-					common.Log.Debug("Tj operand outside text")
+					e.log().Debug("Tj operand outside text")
 					return nil
 				}
 				if len(op.Params) < 1 {
@@ -399,30 +503,10 @@ func (e *Extractor) ExtractText() (string, error) {
 					return fmt.Errorf("Invalid parameter type, not string (%T)", op.Params[0])
 				}
 
-				//first change charcode to cid string
-				if font != nil && font.GetmPredefinedCmap() && cidCodemap != nil {
-					str := cidCodemap.CharcodeBytesToCidStr([]byte(*param))
-					param = core.MakeString(str)
-				}
-
-				//common.Log.Debug("origin: %X", []byte(*param))
-
-				if codemap != nil {
-					//common.Log.Debug("parsed str: %s", codemap.CharcodeBytesToUnicode([]byte(*param), []uint{}, false))
-					if font.GetSimpleEncodingTableFlag() {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), font.GetSimpleEncodingTable(), true))
-					} else {
-						buf.WriteString(codemap.CharcodeBytesToUnicode([]byte(*param), []uint{}, false))
-					}
-				} else {
-					if font != nil && font.GetSimpleEncodingTableFlag() {
-						for _, cid := range []byte(*param) {
-							r := cmap.Utf8CodepointToUtf8(font.GetSimpleEncodingTable()[cid])
-							buf.WriteString(r)
-						}
-					} else {
-						buf.WriteString(string(*param))
-					}
+				showString(e.decodeCodes(font, []byte(*param)))
+				advanceSpacing([]byte(*param))
+				if advance, ok := e.type3Advance(font, []byte(*param)); ok {
+					xPos += advance * (mScaling / 100.0) * fontSize
 				}
 			}
 
@@ -430,12 +514,291 @@ func (e *Extractor) ExtractText() (string, error) {
 		})
 
 	err = processor.Process(e.fontNamesMap)
+	setScript(0)
 	if err != nil {
-		common.Log.Error("Error processing: %v", err)
+		e.log().Error("Error processing: %v", err)
 		return buf.String(), err
 	}
 
 	//procBuf(&buf)
 
-	return buf.String(), nil
+	if e.outputCharset != "" {
+		return e.transcodeText(buf.String(), e.outputCharset)
+	}
+
+	return encodeText(buf.String(), e.outputEncoding), nil
+}
+
+// charcodesToCids converts the character codes of a shown string to a CID string when the font uses a
+// predefined CMap. Otherwise the codes are returned unchanged.
+func (e *Extractor) charcodesToCids(font *model.Font, data []byte) []byte {
+	if font != nil && font.GetmPredefinedCmap() && font.GetCidCmap() != nil {
+		return []byte(font.GetCidCmap().CharcodeBytesToCidStr(data))
+	}
+
+	return data
+}
+
+// prefersToUnicode returns whether the font has both a predefined CMap and a ToUnicode CMap, its character
+// codes being mapped to unicode by the ToUnicode rather than through their CIDs.
+func (e *Extractor) prefersToUnicode(font *model.Font) bool {
+	return font != nil && font.GetmPredefinedCmap() && font.GetCidCmap() != nil && font.GetToUnicodeCmap() != nil
+}
+
+// decodeCodes converts the character codes of a shown string to unicode as decodeCids does their CIDs.  The
+// codes of fonts with both a predefined CMap and a ToUnicode CMap are mapped by the ToUnicode, those it
+// doesn't map through their CID and the cid to unicode CMap.
+func (e *Extractor) decodeCodes(font *model.Font, data []byte) string {
+	if !e.outputCids {
+		if text, ok := decodeUTF16String(font, data); ok {
+			return e.filterText(text)
+		}
+	}
+	if e.outputCids || !e.prefersToUnicode(font) {
+		return e.decodeCids(font, e.charcodesToCids(font, data))
+	}
+
+	text := font.GetToUnicodeCmap().CharcodeBytesToUnicodeFallback(data, []uint{}, false, func(code []byte) string {
+		return e.cidsToUnicode(font, e.charcodesToCids(font, code))
+	})
+	return e.filterText(text)
+}
+
+// isWordSpace returns whether a word space may separate the text output so far and the next text: neither
+// is next to white space nor is CJK text, whose words aren't separated by spaces.
+func isWordSpace(out []byte, text string) bool {
+	if len(out) == 0 || text == "" {
+		return false
+	}
+	last, _ := utf8.DecodeLastRune(out)
+	next, _ := utf8.DecodeRuneInString(text)
+	for _, r := range []rune{last, next} {
+		if unicode.IsSpace(r) || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeUTF16String decodes a shown string that is UTF-16BE text with a byte order mark, as some generators
+// write with symbol fonts, when no CMap of the font applies to its codes.  Strings of an odd length, with
+// unpaired surrogates or with control characters other than white space are taken as character codes.
+func decodeUTF16String(font *model.Font, data []byte) (string, bool) {
+	if font != nil && (font.GetCmap() != nil || font.GetCidCmap() != nil || font.IsMultibyte()) {
+		return "", false
+	}
+	if len(data) < 4 || len(data)%2 != 0 || data[0] != 0xFE || data[1] != 0xFF {
+		return "", false
+	}
+
+	units := make([]uint16, 0, len(data)/2-1)
+	for i := 2; i < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	for i := 0; i < len(units); i++ {
+		if units[i] >= 0xD800 && units[i] < 0xDC00 && i+1 < len(units) && units[i+1] >= 0xDC00 && units[i+1] < 0xE000 {
+			i++
+		} else if units[i] >= 0xD800 && units[i] < 0xE000 {
+			return "", false
+		}
+	}
+
+	runes := utf16.Decode(units)
+	for _, r := range runes {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return "", false
+		}
+	}
+	return string(runes), true
+}
+
+// decodeCids converts a CID string to unicode with cidsToUnicode, removing the strip characters and
+// dropping or replacing the private use characters.
+// If the extractor is set to output CIDs, the CID string is returned as is.
+func (e *Extractor) decodeCids(font *model.Font, data []byte) string {
+	if e.outputCids {
+		return string(data)
+	}
+
+	return e.filterText(e.cidsToUnicode(font, data))
+}
+
+// filterText removes the strip characters of the text and drops or replaces its private use characters.
+func (e *Extractor) filterText(text string) string {
+	if e.stripChars != "" || e.puaMode != PUAKeep {
+		text = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(e.stripChars, r) {
+				return -1
+			}
+			if e.puaMode != PUAKeep && unicode.Is(unicode.Co, r) {
+				if e.puaMode == PUADrop {
+					return -1
+				}
+				return '\uFFFD'
+			}
+			return r
+		}, text)
+	}
+	return text
+}
+
+// cidsToUnicode maps a CID string to unicode via the font's ToUnicode CMap or simple encoding table.
+func (e *Extractor) cidsToUnicode(font *model.Font, data []byte) string {
+	// has ToUnicode
+	if font != nil && font.GetCmap() != nil {
+		if font.GetSimpleEncodingTableFlag() {
+			return font.GetCmap().CharcodeBytesToUnicodeFallback(data, font.GetSimpleEncodingTable(), true, e.unmappedText)
+		}
+		return font.GetCmap().CharcodeBytesToUnicodeFallback(data, []uint{}, false, e.unmappedText)
+	}
+
+	var buf bytes.Buffer
+
+	//no ToUnicode but has font encoding
+	if font != nil && font.GetSimpleEncodingTableFlag() {
+		for _, cid := range data {
+			codepoint := font.GetSimpleEncodingTable()[cid]
+			if codepoint == 0 && cid != 0 {
+				buf.WriteString(e.unmappedText([]byte{cid}))
+				continue
+			}
+			buf.WriteString(cmap.CodepointToUtf8(codepoint))
+		}
+		return buf.String()
+	}
+
+	// no mapping at all, only ASCII codes are taken as is
+	for _, b := range data {
+		if b < 0x80 {
+			buf.WriteByte(b)
+		} else {
+			buf.WriteString(e.unmappedText([]byte{b}))
+		}
+	}
+	return buf.String()
+}
+
+// unmappedText returns the text output in place of a character code that can't be mapped to unicode.
+func (e *Extractor) unmappedText(code []byte) string {
+	switch e.unmappedMode {
+	case UnmappedDrop:
+		return ""
+	case UnmappedRaw:
+		return string(code)
+	}
+	return "\uFFFD"
+}
+
+// type3Matrix returns the FontMatrix of a Type3 font, mapping glyph space to text space.  The usual
+// [0.001 0 0 0.001 0 0] is taken if the font has none.
+func type3Matrix(font *model.Font) matrix {
+	fontMatrix := matrix(font.GetFontMatrix())
+	if fontMatrix[0]*fontMatrix[3]-fontMatrix[1]*fontMatrix[2] == 0 {
+		return matrix{0.001, 0, 0, 0.001, 0, 0}
+	}
+	return fontMatrix
+}
+
+// type3Advance returns the advance of the character codes shown with a Type3 font, in unscaled text space
+// units, from the glyph displacements declared by d0/d1 in the CharProcs transformed by the FontMatrix.
+// Only the horizontal component is returned, as for horizontal writing.  Returns false if font is not Type3.
+func (e *Extractor) type3Advance(font *model.Font, data []byte) (float64, bool) {
+	if font == nil || font.GetFontType() != "Type3" {
+		return 0, false
+	}
+
+	fontMatrix := type3Matrix(font)
+	advance := 0.0
+	for _, code := range data {
+		displacement := e.type3GlyphDisplacement(font, code)
+		// a displacement is a vector, the translation of the matrix doesn't apply
+		advance += displacement[0]*fontMatrix[0] + displacement[1]*fontMatrix[2]
+	}
+	return advance, true
+}
+
+// type3GlyphDisplacement returns the glyph displacement (wx, wy) in glyph space of the d0 or d1 operator
+// starting the CharProc of the code, zero if there is none.
+func (e *Extractor) type3GlyphDisplacement(font *model.Font, code byte) [2]float64 {
+	if e.type3Widths == nil {
+		e.type3Widths = map[*model.Font]map[byte][2]float64{}
+	}
+	widths, ok := e.type3Widths[font]
+	if !ok {
+		widths = map[byte][2]float64{}
+		e.type3Widths[font] = widths
+	}
+	if displacement, ok := widths[code]; ok {
+		return displacement
+	}
+
+	displacement := [2]float64{}
+	widths[code] = displacement
+
+	charProc := font.GetType3CharProc(code)
+	if charProc == nil {
+		return displacement
+	}
+	data, err := core.DecodeStream(charProc)
+	if err != nil && !errors.Is(err, core.ErrTruncatedStream) && !errors.Is(err, core.ErrCorruptStream) {
+		e.log().Debug("Error: decode Type3 char proc failed, err: %s", err)
+		return displacement
+	}
+	operations, err := contentstream.NewContentStreamParser(string(data)).Parse()
+	if err != nil || operations == nil || len(*operations) == 0 {
+		e.log().Debug("Error: parse Type3 char proc failed, err: %v", err)
+		return displacement
+	}
+
+	// d0: wx wy, d1: wx wy llx lly urx ury (color is ignored, only the displacement is used)
+	op := (*operations)[0]
+	if (op.Operand == "d0" && len(op.Params) == 2) || (op.Operand == "d1" && len(op.Params) == 6) {
+		if wxy, ok := getNumbers(op.Params[:2], 2); ok {
+			displacement = [2]float64{wxy[0], wxy[1]}
+		}
+	} else {
+		e.log().Debug("Type3 char proc does not start with d0/d1: %s", op.Operand)
+	}
+
+	widths[code] = displacement
+	return displacement
+}
+
+// encodeText returns the UTF-8 text in the Unicode encoding form, with its byte order mark except for
+// OutputUTF8.
+func encodeText(text string, encoding OutputEncoding) string {
+	switch encoding {
+	case OutputUTF8BOM:
+		return "\uFEFF" + text
+	case OutputUTF16LE, OutputUTF16BE:
+		units := utf16.Encode([]rune("\uFEFF" + text))
+		encoded := make([]byte, 2*len(units))
+		for i, unit := range units {
+			if encoding == OutputUTF16LE {
+				encoded[2*i], encoded[2*i+1] = byte(unit), byte(unit>>8)
+			} else {
+				encoded[2*i], encoded[2*i+1] = byte(unit>>8), byte(unit)
+			}
+		}
+		return string(encoded)
+	}
+	return text
+}
+
+// transcodeText converts the UTF-8 text to the charset, which is an encoding name as defined by the
+// WHATWG Encoding Standard, e.g. "gbk" or "shift_jis".
+func (e *Extractor) transcodeText(text string, charset string) (string, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		e.log().Debug("Error: unsupported output charset %s: %v", charset, err)
+		return text, err
+	}
+
+	encoded, err := enc.NewEncoder().String(text)
+	if err != nil {
+		e.log().Debug("Error: failed to transcode text to %s: %v", charset, err)
+		return text, err
+	}
+
+	return encoded, nil
 }
@@ -0,0 +1,518 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestOutputCids(t *testing.T) {
+	e := contentExtractor(t, "BT /F1 12 Tf 72 712 Td <4E2D6587> Tj ET", cjkFont)
+	if text := extractText(t, e); text != "中文" {
+		t.Fatalf("text %q", text)
+	}
+
+	// the CIDs of the Adobe-GB1 collection, 4559 and 3795
+	e.SetOutputCids(true)
+	if cids := extractText(t, e); cids != "\x11\xcf\x0e\xd3" {
+		t.Errorf("CIDs % X", cids)
+	}
+}
+
+func TestOutputCharset(t *testing.T) {
+	e := contentExtractor(t, "BT /F1 12 Tf 72 712 Td <4E2D6587> Tj ET", cjkFont)
+	e.SetOutputCharset("gbk")
+	if text := extractText(t, e); text != "\xd6\xd0\xce\xc4" {
+		t.Errorf("GBK % X", text)
+	}
+
+	e.SetOutputCharset("no-such-charset")
+	if _, err := e.ExtractText(); err == nil {
+		t.Errorf("no error for an unknown charset")
+	}
+}
+
+func TestOutputEncoding(t *testing.T) {
+	e := contentExtractor(t, "BT /F1 12 Tf 72 712 Td (Caf\\351) Tj ET", helveticaFont)
+	testcases := []struct {
+		encoding OutputEncoding
+		expected string
+	}{
+		{OutputUTF8, "\x43\x61\x66\xc3\xa9"},
+		{OutputUTF8BOM, "\xef\xbb\xbf\x43\x61\x66\xc3\xa9"},
+		{OutputUTF16LE, "\xff\xfe\x43\x00\x61\x00\x66\x00\xe9\x00"},
+		{OutputUTF16BE, "\xfe\xff\x00\x43\x00\x61\x00\x66\x00\xe9"},
+	}
+	for _, tc := range testcases {
+		e.SetOutputEncoding(tc.encoding)
+		if text := extractText(t, e); text != tc.expected {
+			t.Errorf("encoding %d: % x", tc.encoding, text)
+		}
+	}
+
+	// a supplementary character as a surrogate pair
+	if text := encodeText("\U0001D400", OutputUTF16LE); text != "\xff\xfe\x35\xd8\x00\xdc" {
+		t.Errorf("surrogate pair % x", text)
+	}
+
+	// the output charset takes precedence
+	e = contentExtractor(t, "BT /F1 12 Tf 72 712 Td <4E2D> Tj ET", cjkFont)
+	e.SetOutputEncoding(OutputUTF16BE)
+	e.SetOutputCharset("gbk")
+	if text := extractText(t, e); text != "\xd6\xd0" {
+		t.Errorf("charset % x", text)
+	}
+}
+
+func TestToUnicodePrecedence(t *testing.T) {
+	// a ToUnicode mapping 4E2D only, 6587 going through its CID and the predefined CMap
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /T def " +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange 1 beginbfchar <4E2D> <0058> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	font := strings.Replace(cjkFont, "/Encoding", "/ToUnicode 5 0 R /Encoding", 1)
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td <4E2D6587> Tj ET", font, "", makeStream("", toUnicode))
+	reader := openPdf(t, pdf)
+
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "X文" {
+		t.Errorf("text %q", text)
+	}
+
+	glyphs, err := pageExtractor(t, reader, 0).ExtractGlyphs()
+	if err != nil {
+		t.Fatalf("ExtractGlyphs: %v", err)
+	}
+	if len(glyphs) != 2 {
+		t.Fatalf("got %d glyphs", len(glyphs))
+	}
+	// the codes are still the CIDs 4559 and 3795
+	if string(glyphs[0].Code) != "\x11\xcf" || glyphs[0].Text != "X" {
+		t.Errorf("glyph 0: % x %q", glyphs[0].Code, glyphs[0].Text)
+	}
+	if string(glyphs[1].Code) != "\x0e\xd3" || glyphs[1].Text != "文" {
+		t.Errorf("glyph 1: % x %q", glyphs[1].Code, glyphs[1].Text)
+	}
+}
+
+func TestUTF16ShownStrings(t *testing.T) {
+	symbolFont := "<< /Type /Font /Subtype /Type1 /BaseFont /Symbol >>"
+	testcases := []struct {
+		font, data, expected string
+	}{
+		{symbolFont, "FEFF00480069D83DDE00", "Hi\U0001F600"},
+		{helveticaFont, "FEFF00480069D83DDE00", "Hi\U0001F600"},
+		// odd lengths, unpaired surrogates and control characters are character codes
+		{symbolFont, "FEFF0048006909", "\uFFFD\uFFFD\x00H\x00i\t"},
+		{helveticaFont, "FEFFD83D0048", "þÿØ=\x00H"},
+		{helveticaFont, "FEFF00480001", "þÿ\x00H\x00\x01"},
+	}
+	for _, tc := range testcases {
+		text := extractText(t, contentExtractor(t, "BT /F1 12 Tf 72 712 Td <"+tc.data+"> Tj ET", tc.font))
+		if text != tc.expected {
+			t.Errorf("%s: got %q", tc.data, text)
+		}
+	}
+}
+
+func TestWinAnsiHighRange(t *testing.T) {
+	var codes, expected bytes.Buffer
+	for b := 0x80; b <= 0xFF; b++ {
+		fmt.Fprintf(&codes, "%02X", b)
+		switch b {
+		case 0x81, 0x8D, 0x8F, 0x90, 0x9D:
+			// undefined in WinAnsiEncoding
+			expected.WriteRune('\uFFFD')
+		default:
+			r, err := charmap.Windows1252.NewDecoder().Bytes([]byte{byte(b)})
+			if err != nil {
+				t.Fatalf("decode %02X: %v", b, err)
+			}
+			expected.Write(r)
+		}
+	}
+
+	e := contentExtractor(t, "BT /F1 12 Tf 72 712 Td <"+codes.String()+"> Tj ET", helveticaFont)
+	text := extractText(t, e)
+	if text != expected.String() {
+		textRunes, expectedRunes := []rune(text), []rune(expected.String())
+		for i := range expectedRunes {
+			if i >= len(textRunes) || textRunes[i] != expectedRunes[i] {
+				t.Fatalf("code %02X: got %q, expected U+%04X", 0x80+i, text, expectedRunes[i])
+			}
+		}
+		t.Fatalf("got %q, expected %q", text, expected.String())
+	}
+	if !utf8.ValidString(text) || !strings.Contains(text, "é") {
+		t.Errorf("not UTF-8 or é missing: %q", text)
+	}
+}
+
+func TestUnmappedMode(t *testing.T) {
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /T def " +
+		"1 begincodespacerange <00> <FF> endcodespacerange 1 beginbfchar <41> <0041> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td <418041> Tj ET",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /ToUnicode 5 0 R >>", "", makeStream("", toUnicode))
+	reader := openPdf(t, pdf)
+
+	testcases := []struct {
+		mode     UnmappedMode
+		expected string
+	}{
+		{UnmappedReplace, "A\uFFFDA"},
+		{UnmappedDrop, "AA"},
+		{UnmappedRaw, "A\x80A"},
+	}
+	for _, tc := range testcases {
+		e := pageExtractor(t, reader, 0)
+		e.SetUnmappedMode(tc.mode)
+		if text := extractText(t, e); text != tc.expected {
+			t.Errorf("mode %d: got %q, expected %q", tc.mode, text, tc.expected)
+		}
+	}
+
+	// U+FFFD by default
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "A\uFFFDA" {
+		t.Errorf("default: got %q", text)
+	}
+}
+
+func TestType3GlyphDisplacement(t *testing.T) {
+	// the d0 and d1 widths differ from /Widths, the glyphs are advanced by them
+	pdf := pagePdf("BT /F1 10 Tf 100 700 Td (aba) Tj ET",
+		"<< /Type /Font /Subtype /Type3 /FontMatrix [0.001 0 0 0.001 0 0] /FontBBox [0 0 1000 1000] "+
+			"/FirstChar 97 /LastChar 98 /Widths [900 900] /Encoding << /Differences [97 /a /b] >> "+
+			"/CharProcs << /a 5 0 R /b 6 0 R >> >>", "",
+		makeStream("", "100 0 d0\n0 0 100 100 re f"),
+		makeStream("", "250 0 0 0 250 250 d1\n0 0 250 250 re f"))
+	reader := openPdf(t, pdf)
+	e := pageExtractor(t, reader, 0)
+
+	// 100 and 250 glyph space units of 1/1000
+	advance, ok := e.type3Advance(reader.GetFontsForPages()[0]["F1"], []byte("aba"))
+	if !ok || math.Abs(advance-0.45) > 1e-9 {
+		t.Errorf("advance %v, ok %v", advance, ok)
+	}
+}
+
+func TestType3FontMatrix(t *testing.T) {
+	testcases := []struct {
+		fontMatrix, charProc string
+		expected             []float64
+	}{
+		// 50 glyph space units of 1/100 at size 10
+		{"[0.01 0 0 0.01 0 0]", "50 0 d0", []float64{100, 105, 110}},
+		// the wy sheared into the advance, the origin translated by 3 glyph space units
+		{"[0.01 0 0.005 0.01 3 2]", "50 20 d0", []float64{130, 136, 142}},
+		// rotated, the wy being the advance
+		{"[0 0.01 -0.01 0 0 0]", "0 -50 d0", []float64{100, 105, 110}},
+	}
+	for _, tc := range testcases {
+		pdf := pagePdf("BT /F1 10 Tf 100 700 Td (aaa) Tj ET",
+			"<< /Type /Font /Subtype /Type3 /FontMatrix "+tc.fontMatrix+" /FontBBox [0 0 100 100] "+
+				"/FirstChar 97 /LastChar 97 /Widths [50] /Encoding << /Differences [97 /a] >> "+
+				"/CharProcs << /a 5 0 R >> >>", "", makeStream("", tc.charProc+"\n0 0 50 50 re f"))
+		glyphs, err := pageExtractor(t, openPdf(t, pdf), 0).ExtractGlyphs()
+		if err != nil {
+			t.Fatalf("ExtractGlyphs: %v", err)
+		}
+		if len(glyphs) != len(tc.expected) {
+			t.Errorf("%s: got %d glyphs", tc.fontMatrix, len(glyphs))
+			continue
+		}
+		for i, x := range tc.expected {
+			if math.Abs(glyphs[i].X-x) > 1e-9 {
+				t.Errorf("%s: glyph %d at %v, expected %v", tc.fontMatrix, i, glyphs[i].X, x)
+			}
+		}
+	}
+}
+
+func TestParagraphBreaks(t *testing.T) {
+	content := "BT /F1 12 Tf 72 700 Td (First paragraph.) Tj ET\n" +
+		"BT /F1 12 Tf 72 660 Td (Second paragraph.) Tj ET\n" +
+		"BT /F1 12 Tf 72 646 Td (Same paragraph.) Tj ET"
+	reader := openPdf(t, pagePdf(content, helveticaFont, ""))
+
+	e := pageExtractor(t, reader, 0)
+	if text := extractText(t, e); strings.Contains(text, "\n\n") {
+		t.Errorf("paragraph separator by default: %q", text)
+	}
+
+	e = pageExtractor(t, reader, 0)
+	e.SetParagraphBreaks(true)
+	if text := extractText(t, e); text != "First paragraph.\n\nSecond paragraph.\nSame paragraph." {
+		t.Errorf("got %q", text)
+	}
+
+	e = pageExtractor(t, reader, 0)
+	e.SetParagraphBreaks(true)
+	e.SetParagraphSeparator("\n---\n")
+	if text := extractText(t, e); text != "First paragraph.\n---\nSecond paragraph.\nSame paragraph." {
+		t.Errorf("custom separator: got %q", text)
+	}
+}
+
+func TestParagraphBreaksTm(t *testing.T) {
+	content := "BT /F1 12 Tf 1 0 0 1 72 700 Tm (First paragraph.) Tj ET\n" +
+		"BT /F1 12 Tf 1 0 0 1 72 660 Tm (Second paragraph.) Tj ET"
+	e := contentExtractor(t, content, helveticaFont)
+	e.SetParagraphBreaks(true)
+	if text := extractText(t, e); text != "First paragraph.\n\nSecond paragraph." {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestDoubleQuoteSpacing(t *testing.T) {
+	content := "BT /F1 10 Tf 12 TL 1 0 0 1 72 700 Tm %s (one two) \" 1 0 0 1 120 688 Tm (!) Tj ET"
+
+	// the word spacing of 40 and character spacing of 2 separate the words by 40 + 4*2
+	spaced, err := contentExtractor(t, fmt.Sprintf(content, "40 2"), helveticaFont).ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	unspaced, err := contentExtractor(t, fmt.Sprintf(content, "0 0"), helveticaFont).ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	if len(spaced) < 5 || len(unspaced) < 5 || spaced[4].Text != "t" {
+		t.Fatalf("marks %v", spaced)
+	}
+	if d := spaced[4].X - unspaced[4].X; math.Abs(d-48) > 1e-9 {
+		t.Errorf("second word moved by %v, expected 48", d)
+	}
+
+	// the text widened by the spacing reaches the next Tm, no longer taken for a gap, the " starting a line
+	e := contentExtractor(t, fmt.Sprintf(content, "40 2"), helveticaFont)
+	if text := extractText(t, e); text != "\none two!" {
+		t.Errorf("spaced: got %q", text)
+	}
+	e = contentExtractor(t, fmt.Sprintf(content, "0 0"), helveticaFont)
+	if text := extractText(t, e); text != "\none two\t!" {
+		t.Errorf("unspaced: got %q", text)
+	}
+}
+
+func TestSuperscriptNoNewline(t *testing.T) {
+	content := "BT /F1 12 Tf 72 700 Td (E = mc) Tj 40 5 Td /F1 7 Tf (2) Tj 5 -5 Td /F1 12 Tf ( is famous) Tj " +
+		"-45 -14 Td (Next line) Tj ET"
+	text := extractText(t, contentExtractor(t, content, helveticaFont))
+	if text != "E = mc2 is famous\nNext line" {
+		t.Errorf("Td: got %q", text)
+	}
+
+	content = "BT /F1 12 Tf 1 0 0 1 72 700 Tm (x) Tj 1 0 0 1 80 704 Tm /F1 7 Tf (n) Tj " +
+		"1 0 0 1 85 700 Tm /F1 12 Tf ( + 1) Tj 1 0 0 1 72 686 Tm (Next line) Tj ET"
+	if text = extractText(t, contentExtractor(t, content, helveticaFont)); text != "x\tn\t + 1\nNext line" {
+		t.Errorf("Tm: got %q", text)
+	}
+}
+
+func TestScriptMarkers(t *testing.T) {
+	testcases := []struct {
+		name, content, expected string
+	}{
+		{"Ts", "BT /F1 12 Tf 72 700 Td (x) Tj /F1 7 Tf 5 Ts (2) Tj ET", "x^2"},
+		{"subscript", "BT /F1 12 Tf 72 700 Td (H) Tj /F1 7 Tf -3 Ts (2) Tj /F1 12 Tf 0 Ts (O) Tj ET", "H_{2}O"},
+		{"Td", "BT /F1 12 Tf 72 700 Td (x) Tj 8 5 Td /F1 7 Tf (2) Tj -8 -5 Td /F1 12 Tf (  y) Tj ET", "x^2  y"},
+	}
+	for _, tc := range testcases {
+		e := contentExtractor(t, tc.content, helveticaFont)
+		e.SetScriptMarkers("^", "", "_{", "}")
+		if text := extractText(t, e); text != tc.expected {
+			t.Errorf("%s: got %q, expected %q", tc.name, text, tc.expected)
+		}
+	}
+
+	// no markers by default
+	if text := extractText(t, contentExtractor(t, testcases[0].content, helveticaFont)); text != "x2" {
+		t.Errorf("by default: got %q", text)
+	}
+}
+
+func TestTJMixedDisplacements(t *testing.T) {
+	content := "BT /F1 12 Tf 1 0 0 1 72 700 Tm [(ab) 5 (cd) -150.5 (e) 100] TJ [] TJ 5 TJ (x) Tj ET"
+	if text := extractText(t, contentExtractor(t, content, helveticaFont)); text != "abcdex" {
+		t.Errorf("got %q", text)
+	}
+
+	// integer and real displacements move the text alike, as seen by the tab before a Tm past the text end
+	for x := 90.0; x <= 110; x += 0.25 {
+		var texts [2]string
+		for i, displacement := range []string{"-100", "-100.0"} {
+			content := fmt.Sprintf("BT /F1 12 Tf 1 0 0 1 72 700 Tm [(ab) %s (cd) %s] TJ 1 0 0 1 %v 700 Tm (x) Tj ET",
+				displacement, displacement, x)
+			texts[i] = extractText(t, contentExtractor(t, content, helveticaFont))
+		}
+		if texts[0] != texts[1] {
+			t.Errorf("Tm at %v: %q with integers, %q with reals", x, texts[0], texts[1])
+		}
+	}
+}
+
+func TestTJWordSpaces(t *testing.T) {
+	// displacements of 3.12 and 8.4 points, the word gap being 2.4 points by default
+	content := "BT /F1 12 Tf 72 712 Td [(T) -260 (ight) -260 (ly) -700 (kerned)] TJ ET"
+	e := contentExtractor(t, content, helveticaFont)
+	if text := extractText(t, e); text != "T ight ly kerned" {
+		t.Errorf("default ratio: got %q", text)
+	}
+	e.SetWordGapRatio(0.4)
+	if text := extractText(t, e); text != "Tightly kerned" {
+		t.Errorf("ratio 0.4: got %q", text)
+	}
+	if text, err := e.ExtractTextWithOptions(ExtractOptions{}); err != nil || text != "T ight ly kerned" {
+		t.Errorf("zero options: got %q, err: %v", text, err)
+	}
+	if text, err := e.ExtractTextWithOptions(ExtractOptions{WordGapRatio: 0.8}); err != nil ||
+		text != "Tightlykerned" {
+		t.Errorf("ratio 0.8: got %q, err: %v", text, err)
+	}
+
+	// no space next to white space, between CJK characters, or for a displacement ending the array
+	testcases := []struct {
+		content, font, expected string
+	}{
+		{"[(a ) -500 (b) -500 ( c) -500] TJ", helveticaFont, "a b c"},
+		{"[<4E2D> -500 <6587> -500 (\\000A)] TJ", cjkFont, "中文A"},
+		// the horizontal scaling applies to the displacements
+		{"50 Tz [(a) -300 (b)] TJ", helveticaFont, "ab"},
+	}
+	for _, tc := range testcases {
+		e := contentExtractor(t, "BT /F1 12 Tf 72 712 Td "+tc.content+" ET", tc.font)
+		if text := extractText(t, e); text != tc.expected {
+			t.Errorf("%s: got %q", tc.content, text)
+		}
+	}
+}
+
+func TestTdMoves(t *testing.T) {
+	testcases := []struct {
+		name, content, expected string
+	}{
+		// the moves accumulate, TD sets the leading of T*
+		{"paragraph", "BT /F1 12 Tf 72 700 Td (one) Tj 0 -14 Td (two) Tj 30 0 Td ( more) Tj " +
+			"-30 -14 TD (three) Tj T* (four) Tj ET", "one\ntwo more\nthree\nfour"},
+		// the baseline moving down the page in a flipped text matrix, the Tm starting a line
+		{"flipped", "BT /F1 12 Tf 1 0 0 -1 0 792 Tm 72 100 Td (one) Tj 0 14 Td (two) Tj ET", "\none\ntwo"},
+	}
+	for _, tc := range testcases {
+		if text := extractText(t, contentExtractor(t, tc.content, helveticaFont)); text != tc.expected {
+			t.Errorf("%s: got %q, expected %q", tc.name, text, tc.expected)
+		}
+	}
+}
+
+func TestStripChars(t *testing.T) {
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /T def " +
+		"1 begincodespacerange <00> <FF> endcodespacerange 5 beginbfchar <41> <0041> <42> <0042> " +
+		"<AD> <00AD> <01> <200B> <02> <FEFF> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td <0241AD4201AD41> Tj ET",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /ToUnicode 5 0 R >>", "", makeStream("", toUnicode))
+	reader := openPdf(t, pdf)
+
+	if text := extractText(t, pageExtractor(t, reader, 0)); text != "\uFEFFA\u00ADB\u200B\u00ADA" {
+		t.Errorf("by default: got %q", text)
+	}
+
+	e := pageExtractor(t, reader, 0)
+	e.SetStripChars(DefaultStripChars)
+	if text := extractText(t, e); text != "ABA" {
+		t.Errorf("got %q", text)
+	}
+	marks, err := e.ExtractTextMarks()
+	if err != nil {
+		t.Fatalf("ExtractTextMarks: %v", err)
+	}
+	text := ""
+	for _, mark := range marks {
+		text += mark.Text
+	}
+	if text != "ABA" {
+		t.Errorf("marks: got %q", text)
+	}
+
+	// the soft hyphens only
+	e.SetStripChars("\u00AD")
+	if text := extractText(t, e); text != "\uFEFFAB\u200BA" {
+		t.Errorf("soft hyphens: got %q", text)
+	}
+}
+
+func TestPUAMode(t *testing.T) {
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /T def " +
+		"1 begincodespacerange <00> <FF> endcodespacerange 3 beginbfchar <61> <0041> <62> <E001> <63> <0043> " +
+		"endbfchar endcmap CMapName currentdict /CMap defineresource pop end end"
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td (abc) Tj ET",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /ToUnicode 5 0 R >>", "", makeStream("", toUnicode))
+	reader := openPdf(t, pdf)
+
+	for mode, expected := range map[PUAMode]string{PUAKeep: "A\uE001C", PUADrop: "AC", PUAReplace: "A\uFFFDC"} {
+		e := pageExtractor(t, reader, 0)
+		e.SetPUAMode(mode)
+		if text := extractText(t, e); text != expected {
+			t.Errorf("mode %d: got %q, expected %q", mode, text, expected)
+		}
+	}
+}
+
+func TestTzScaling(t *testing.T) {
+	// the word estimated 20 wide, 40 when scaled twice, reaching the Tm at 130
+	content := "BT /F1 10 Tf %s 1 0 0 1 100 700 Tm [(word)] TJ 1 0 0 1 130 700 Tm (x) Tj ET"
+	for scaling, expected := range map[string]string{"100 Tz": "word\tx", "200.0 Tz": "wordx"} {
+		e := contentExtractor(t, fmt.Sprintf(content, scaling), helveticaFont)
+		if text := extractText(t, e); text != expected {
+			t.Errorf("%s: got %q, expected %q", scaling, text, expected)
+		}
+	}
+}
+
+func TestBaselineNewlines(t *testing.T) {
+	// a line of 10 Tj at the same baseline, the next line starting right of its end
+	content := "BT /F1 12 Tf 72 700 Td"
+	for i := 0; i < 10; i++ {
+		content += fmt.Sprintf(" (w%d) Tj 20 0 Td", i)
+	}
+	content += " 1 0 0 1 300 686 Tm (next) Tj -228 -14 Td (last) Tj ET"
+
+	// by default the Tm outputs a second newline
+	text := extractText(t, contentExtractor(t, content, helveticaFont))
+	if text != "w0w1w2w3w4w5w6w7w8w9\n\nnext\nlast" {
+		t.Errorf("by default: got %q", text)
+	}
+	e := contentExtractor(t, content, helveticaFont)
+	e.SetBaselineNewlines(true)
+	if text := extractText(t, e); text != "w0w1w2w3w4w5w6w7w8w9\nnext\nlast" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestTableBorders(t *testing.T) {
+	// borders drawn between the text objects of a line and between the lines
+	content := "0 0 200 20 re S BT /F1 12 Tf 1 0 0 1 72 700 Tm (One) Tj ET 100 690 0.5 20 re f " +
+		"BT /F1 12 Tf 1 0 0 1 90 700 Tm (same) Tj ET 72 690 200 0.5 re f 72 670 200 0.5 re f " +
+		"BT /F1 12 Tf 72 680 Td (Two) Tj ET BT /F1 12 Tf 92 680 Td (more) Tj 0 0 10 10 re ET"
+	if text := extractText(t, contentExtractor(t, content, helveticaFont)); text != "One\tsame\nTwomore" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestUpwardNewline(t *testing.T) {
+	// the footer drawn before the body, the second column after the first
+	content := "BT /F1 12 Tf 72 50 Td (Page 1) Tj 0 650 Td (Title) Tj 0 -14 Td (Left) Tj 200 14 Td (Right) Tj " +
+		"0 5 Td (up) Tj ET"
+	text := extractText(t, contentExtractor(t, content, helveticaFont))
+	if text != "Page 1\nTitle\nLeft\nRightup" {
+		t.Errorf("got %q", text)
+	}
+}
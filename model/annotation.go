@@ -0,0 +1,201 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+
+	. "../core"
+)
+
+// Annotation flags of annotations that are not displayed.
+const (
+	annotationFlagHidden = 1 << 1
+	annotationFlagNoView = 1 << 5
+)
+
+// AnnotationAppearance is the normal appearance of an annotation, such as the value of a form field.
+type AnnotationAppearance struct {
+	Subtype string
+	Rect    [4]float64 // llx lly urx ury, in page user space
+	// Matrix maps the appearance stream space to page user space, placing the appearance at the Rect.
+	Matrix  [6]float64
+	Content []byte // decoded content of the appearance stream
+	Fonts   FontsByNames
+}
+
+// GetPageAnnotationAppearances returns the normal appearances (/AP /N) of the annotations of the page
+// (0 based index), in /Annots order.  For appearances with several states, the one of the appearance state
+// /AS is taken.  Hidden annotations and annotations without an appearance are skipped.  The fonts are those
+// of the appearance stream resources, or of the default resources of the interactive form.
+func (this *PdfReader) GetPageAnnotationAppearances(pageIndex int) ([]AnnotationAppearance, error) {
+	pageDict, err := this.getPageDict(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	appearances := []AnnotationAppearance{}
+	annotsObj, err := this.parser.Trace(pageDict.Get("Annots"))
+	if err != nil {
+		return nil, err
+	}
+	annots, ok := annotsObj.(*PdfObjectArray)
+	if !ok {
+		return appearances, nil
+	}
+
+	for _, annotObj := range *annots {
+		annotObj, err := this.parser.Trace(annotObj)
+		if err != nil {
+			this.log().Debug("Error: trace annotation failed, err: %v", err)
+			continue
+		}
+		annotDict, ok := annotObj.(*PdfObjectDictionary)
+		if !ok {
+			continue
+		}
+
+		appearance, err := this.loadAnnotationAppearance(annotDict)
+		if err != nil {
+			this.log().Debug("Skipping annotation appearance: %v", err)
+			continue
+		}
+		if appearance != nil {
+			appearances = append(appearances, *appearance)
+		}
+	}
+
+	return appearances, nil
+}
+
+// loadAnnotationAppearance returns the normal appearance of the annotation, nil if it has none or is hidden.
+func (this *PdfReader) loadAnnotationAppearance(annotDict *PdfObjectDictionary) (*AnnotationAppearance, error) {
+	if flagsObj, err := this.parser.Trace(annotDict.Get("F")); err == nil {
+		if flags, ok := flagsObj.(*PdfObjectInteger); ok && *flags&(annotationFlagHidden|annotationFlagNoView) != 0 {
+			return nil, nil
+		}
+	}
+
+	apObj, err := this.parser.Trace(annotDict.Get("AP"))
+	if err != nil {
+		return nil, err
+	}
+	apDict, ok := apObj.(*PdfObjectDictionary)
+	if !ok {
+		return nil, nil
+	}
+	normalObj, err := this.parser.Trace(apDict.Get("N"))
+	if err != nil {
+		return nil, err
+	}
+	if states, ok := normalObj.(*PdfObjectDictionary); ok {
+		state, ok := annotDict.Get("AS").(*PdfObjectName)
+		if !ok {
+			return nil, nil
+		}
+		normalObj, err = this.parser.Trace(states.Get(*state))
+		if err != nil {
+			return nil, err
+		}
+	}
+	stream, ok := normalObj.(*PdfObjectStream)
+	if !ok {
+		return nil, nil
+	}
+
+	appearance := &AnnotationAppearance{}
+	if subtype, ok := annotDict.Get("Subtype").(*PdfObjectName); ok {
+		appearance.Subtype = string(*subtype)
+	}
+
+	rect, err := this.getFloats(annotDict.Get("Rect"))
+	if err != nil || len(rect) != 4 {
+		return nil, errors.New("missing or invalid annotation Rect")
+	}
+	appearance.Rect = [4]float64{
+		math.Min(rect[0], rect[2]), math.Min(rect[1], rect[3]),
+		math.Max(rect[0], rect[2]), math.Max(rect[1], rect[3]),
+	}
+	appearance.Matrix = this.appearanceMatrix(stream.PdfObjectDictionary, appearance.Rect)
+
+	appearance.Content, err = DecodeStream(stream)
+	if err != nil && !errors.Is(err, ErrTruncatedStream) && !errors.Is(err, ErrCorruptStream) {
+		return nil, err
+	}
+
+	resObj, err := this.parser.Trace(stream.PdfObjectDictionary.Get("Resources"))
+	if err != nil {
+		return nil, err
+	}
+	resDict, _ := resObj.(*PdfObjectDictionary)
+	if resDict == nil || resDict.Get("Font") == nil {
+		resDict = this.getAcroFormResources()
+	}
+	appearance.Fonts, err = this.parseResourceFonts(resDict)
+	if err != nil {
+		return nil, err
+	}
+
+	return appearance, nil
+}
+
+// appearanceMatrix returns the matrix mapping the appearance stream space to page user space: the form
+// /Matrix followed by the mapping of the transformed /BBox onto the annotation rectangle.
+func (this *PdfReader) appearanceMatrix(formDict *PdfObjectDictionary, rect [4]float64) [6]float64 {
+	m := [6]float64{1, 0, 0, 1, 0, 0}
+	if values, err := this.getFloats(formDict.Get("Matrix")); err == nil && len(values) == 6 {
+		copy(m[:], values)
+	}
+
+	bbox, err := this.getFloats(formDict.Get("BBox"))
+	if err != nil || len(bbox) != 4 {
+		bbox = rect[:]
+	}
+
+	// bounding box of the transformed bbox corners
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{bbox[0], bbox[1]}, {bbox[0], bbox[3]}, {bbox[2], bbox[1]}, {bbox[2], bbox[3]}} {
+		x := corner[0]*m[0] + corner[1]*m[2] + m[4]
+		y := corner[0]*m[1] + corner[1]*m[3] + m[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	sx, sy := 1.0, 1.0
+	if maxX > minX {
+		sx = (rect[2] - rect[0]) / (maxX - minX)
+	}
+	if maxY > minY {
+		sy = (rect[3] - rect[1]) / (maxY - minY)
+	}
+	tx, ty := rect[0]-minX*sx, rect[1]-minY*sy
+
+	return [6]float64{m[0] * sx, m[1] * sy, m[2] * sx, m[3] * sy, m[4]*sx + tx, m[5]*sy + ty}
+}
+
+// getAcroFormResources returns the default resources /DR of the interactive form, nil if none.
+func (this *PdfReader) getAcroFormResources() *PdfObjectDictionary {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return nil
+	}
+	acroFormObj, err := this.parser.Trace(rootDict.Get("AcroForm"))
+	if err != nil {
+		return nil
+	}
+	acroForm, ok := acroFormObj.(*PdfObjectDictionary)
+	if !ok {
+		return nil
+	}
+	drObj, err := this.parser.Trace(acroForm.Get("DR"))
+	if err != nil {
+		return nil
+	}
+	dr, _ := drObj.(*PdfObjectDictionary)
+	return dr
+}
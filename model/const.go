@@ -1,12 +1,12 @@
 package model
 
 var (
-	//predefined encodings:
+	//predefined encodings (Unicode code points indexed by character code):
 	PdfDocEncodingUtf8 = []uint{
 		0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7,
 		0x8, 0x9, 0xa, 0xb, 0xc, 0xd, 0xe, 0xf,
 		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x17, 0x17,
-		0xcb98, 0xcb87, 0xcb86, 0xcb99, 0xcb9d, 0xcb9b, 0xcb9a, 0xcb9c,
+		0x2d8, 0x2c7, 0x2c6, 0x2d9, 0x2dd, 0x2db, 0x2da, 0x2dc,
 		0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27,
 		0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
 		0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
@@ -19,22 +19,22 @@ var (
 		0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f,
 		0x70, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x77,
 		0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x0,
-		0xe280a2, 0xe280a0, 0xe280a1, 0xe280a6, 0xe28094, 0xe28093, 0xc692, 0xe28184,
-		0xe280b9, 0xe280ba, 0xe28892, 0xe280b0, 0xe2809e, 0xe2809c, 0xe2809d, 0xe28098,
-		0xe28099, 0xe2809a, 0xe284a2, 0xefac81, 0xefac82, 0xc581, 0xc592, 0xc5a0,
-		0xc5b8, 0xc5bd, 0xc4b1, 0xc582, 0xc593, 0xc5a1, 0xc5be, 0x0,
-		0xe282ac, 0xc2a1, 0xc2a2, 0xc2a3, 0xc2a4, 0xc2a5, 0xc2a6, 0xc2a7,
-		0xc2a8, 0xc2a9, 0xc2aa, 0xc2ab, 0xc2ac, 0x0, 0xc2ae, 0xc2af,
-		0xc2b0, 0xc2b1, 0xc2b2, 0xc2b3, 0xc2b4, 0xc2b5, 0xc2b6, 0xc2b7,
-		0xc2b8, 0xc2b9, 0xc2ba, 0xc2bb, 0xc2bc, 0xc2bd, 0xc2be, 0xc2bf,
-		0xc380, 0xc381, 0xc382, 0xc383, 0xc384, 0xc385, 0xc386, 0xc387,
-		0xc388, 0xc389, 0xc38a, 0xc38b, 0xc38c, 0xc38d, 0xc38e, 0xc38f,
-		0xc390, 0xc391, 0xc392, 0xc393, 0xc394, 0xc395, 0xc396, 0xc397,
-		0xc398, 0xc399, 0xc39a, 0xc39b, 0xc39c, 0xc39d, 0xc39e, 0xc39f,
-		0xc3a0, 0xc3a1, 0xc3a2, 0xc3a3, 0xc3a4, 0xc3a5, 0xc3a6, 0xc3a7,
-		0xc3a8, 0xc3a9, 0xc3aa, 0xc3ab, 0xc3ac, 0xc3ad, 0xc3ae, 0xc3af,
-		0xc3b0, 0xc3b1, 0xc3b2, 0xc3b3, 0xc3b4, 0xc3b5, 0xc3b6, 0xc3b7,
-		0xc3b8, 0xc3b9, 0xc3ba, 0xc3bb, 0xc3bc, 0xc3bd, 0xc3be, 0xc3bf}
+		0x2022, 0x2020, 0x2021, 0x2026, 0x2014, 0x2013, 0x192, 0x2044,
+		0x2039, 0x203a, 0x2212, 0x2030, 0x201e, 0x201c, 0x201d, 0x2018,
+		0x2019, 0x201a, 0x2122, 0xfb01, 0xfb02, 0x141, 0x152, 0x160,
+		0x178, 0x17d, 0x131, 0x142, 0x153, 0x161, 0x17e, 0x0,
+		0x20ac, 0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+		0xa8, 0xa9, 0xaa, 0xab, 0xac, 0x0, 0xae, 0xaf,
+		0xb0, 0xb1, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7,
+		0xb8, 0xb9, 0xba, 0xbb, 0xbc, 0xbd, 0xbe, 0xbf,
+		0xc0, 0xc1, 0xc2, 0xc3, 0xc4, 0xc5, 0xc6, 0xc7,
+		0xc8, 0xc9, 0xca, 0xcb, 0xcc, 0xcd, 0xce, 0xcf,
+		0xd0, 0xd1, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7,
+		0xd8, 0xd9, 0xda, 0xdb, 0xdc, 0xdd, 0xde, 0xdf,
+		0xe0, 0xe1, 0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7,
+		0xe8, 0xe9, 0xea, 0xeb, 0xec, 0xed, 0xee, 0xef,
+		0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7,
+		0xf8, 0xf9, 0xfa, 0xfb, 0xfc, 0xfd, 0xfe, 0xff}
 
 	WinAnsiEncodingUtf8 = []uint{
 		0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7,
@@ -53,22 +53,22 @@ var (
 		0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f,
 		0x70, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x77,
 		0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f,
-		0xe282ac, 0x0, 0xe2809a, 0xc692, 0xe2809e, 0xe280a6, 0xe280a0, 0xe280a1,
-		0xcb86, 0xe280b0, 0xc5a0, 0xe280b9, 0xc592, 0x0, 0xc5bd, 0x0,
-		0x0, 0xe28098, 0xe28099, 0xe2809c, 0xe2809d, 0xe280a2, 0xe28093, 0xe28094,
-		0xcb9c, 0xe284a2, 0xc5a1, 0xe280ba, 0xc593, 0x0, 0xc5be, 0xc5b8,
-		0xc2a0, 0xc2a1, 0xc2a2, 0xc2a3, 0xc2a4, 0xc2a5, 0xc2a6, 0xc2a7,
-		0xc2a8, 0xc2a9, 0xc2aa, 0xc2ab, 0xc2ac, 0xc2ad, 0xc2ae, 0xc2af,
-		0xc2b0, 0xc2b1, 0xc2b2, 0xc2b3, 0xc2b4, 0xc2b5, 0xc2b6, 0xc2b7,
-		0xc2b8, 0xc2b9, 0xc2ba, 0xc2bb, 0xc2bc, 0xc2bd, 0xc2be, 0xc2bf,
-		0xc380, 0xc381, 0xc382, 0xc383, 0xc384, 0xc385, 0xc386, 0xc387,
-		0xc388, 0xc389, 0xc38a, 0xc38b, 0xc38c, 0xc38d, 0xc38e, 0xc38f,
-		0xc390, 0xc391, 0xc392, 0xc393, 0xc394, 0xc395, 0xc396, 0xc397,
-		0xc398, 0xc399, 0xc39a, 0xc39b, 0xc39c, 0xc39d, 0xc39e, 0xc39f,
-		0xc3a0, 0xc3a1, 0xc3a2, 0xc3a3, 0xc3a4, 0xc3a5, 0xc3a6, 0xc3a7,
-		0xc3a8, 0xc3a9, 0xc3aa, 0xc3ab, 0xc3ac, 0xc3ad, 0xc3ae, 0xc3af,
-		0xc3b0, 0xc3b1, 0xc3b2, 0xc3b3, 0xc3b4, 0xc3b5, 0xc3b6, 0xc3b7,
-		0xc3b8, 0xc3b9, 0xc3ba, 0xc3bb, 0xc3bc, 0xc3bd, 0xc3be, 0xc3bf}
+		0x20ac, 0x0, 0x201a, 0x192, 0x201e, 0x2026, 0x2020, 0x2021,
+		0x2c6, 0x2030, 0x160, 0x2039, 0x152, 0x0, 0x17d, 0x0,
+		0x0, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+		0x2dc, 0x2122, 0x161, 0x203a, 0x153, 0x0, 0x17e, 0x178,
+		0xa0, 0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+		0xa8, 0xa9, 0xaa, 0xab, 0xac, 0xad, 0xae, 0xaf,
+		0xb0, 0xb1, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7,
+		0xb8, 0xb9, 0xba, 0xbb, 0xbc, 0xbd, 0xbe, 0xbf,
+		0xc0, 0xc1, 0xc2, 0xc3, 0xc4, 0xc5, 0xc6, 0xc7,
+		0xc8, 0xc9, 0xca, 0xcb, 0xcc, 0xcd, 0xce, 0xcf,
+		0xd0, 0xd1, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7,
+		0xd8, 0xd9, 0xda, 0xdb, 0xdc, 0xdd, 0xde, 0xdf,
+		0xe0, 0xe1, 0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7,
+		0xe8, 0xe9, 0xea, 0xeb, 0xec, 0xed, 0xee, 0xef,
+		0xf0, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7,
+		0xf8, 0xf9, 0xfa, 0xfb, 0xfc, 0xfd, 0xfe, 0xff}
 
 	MacRomanEncodingUtf8 = []uint{
 		0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7,
@@ -87,63 +87,63 @@ var (
 		0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f,
 		0x70, 0x71, 0x72, 0x73, 0x74, 0x75, 0x76, 0x77,
 		0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d, 0x7e, 0x7f,
-		0xc384, 0xc385, 0xc387, 0xc389, 0xc391, 0xc396, 0xc39c, 0xc3a1,
-		0xc3a0, 0xc3a2, 0xc3a4, 0xc3a3, 0xc3a5, 0xc3a7, 0xc3a9, 0xc3a8,
-		0xc3aa, 0xc3ab, 0xc3ad, 0xc3ac, 0xc3ae, 0xc3af, 0xc3b1, 0xc3b3,
-		0xc3b2, 0xc3b4, 0xc3b6, 0xc3b5, 0xc3ba, 0xc3b9, 0xc3bb, 0xc3bc,
-		0xe280a0, 0xc2b0, 0xc2a2, 0xc2a3, 0xc2a7, 0xe280a2, 0xc2b6, 0xc39f,
-		0xc2ae, 0xc2a9, 0xe284a2, 0xc2b4, 0xc2a8, 0xe289a0, 0xc386, 0xc398,
-		0xe2889e, 0xc2b1, 0xe289a4, 0xe289a5, 0xc2a5, 0xc2b5, 0xe28882, 0xe28891,
-		0xe2888f, 0xcf80, 0xe288ab, 0xc2aa, 0xc2ba, 0xcea9, 0xc3a6, 0xc3b8,
-		0xc2bf, 0xc2a1, 0xc2ac, 0xe2889a, 0xc692, 0xe28988, 0xe28886, 0xc2ab,
-		0xc2bb, 0xe280a6, 0xc2a0, 0xc380, 0xc383, 0xc395, 0xc592, 0xc593,
-		0xe28093, 0xe28094, 0xe2809c, 0xe2809d, 0xe28098, 0xe28099, 0xc3b7, 0xe2978a,
-		0xc3bf, 0xc5b8, 0xe28184, 0xe282ac, 0xe280b9, 0xe280ba, 0xefac81, 0xefac82,
-		0xe280a1, 0xc2b7, 0xe2809a, 0xe2809e, 0xe280b0, 0xc382, 0xc38a, 0xc381,
-		0xc38b, 0xc388, 0xc38d, 0xc38e, 0xc38f, 0xc38c, 0xc393, 0xc394,
-		0xefa3bf, 0xc392, 0xc39a, 0xc39b, 0xc399, 0xc4b1, 0xcb86, 0xcb9c,
-		0xc2af, 0xcb98, 0xcb99, 0xcb9a, 0xc2b8, 0xcb9d, 0xcb9b, 0xcb87}
+		0xc4, 0xc5, 0xc7, 0xc9, 0xd1, 0xd6, 0xdc, 0xe1,
+		0xe0, 0xe2, 0xe4, 0xe3, 0xe5, 0xe7, 0xe9, 0xe8,
+		0xea, 0xeb, 0xed, 0xec, 0xee, 0xef, 0xf1, 0xf3,
+		0xf2, 0xf4, 0xf6, 0xf5, 0xfa, 0xf9, 0xfb, 0xfc,
+		0x2020, 0xb0, 0xa2, 0xa3, 0xa7, 0x2022, 0xb6, 0xdf,
+		0xae, 0xa9, 0x2122, 0xb4, 0xa8, 0x2260, 0xc6, 0xd8,
+		0x221e, 0xb1, 0x2264, 0x2265, 0xa5, 0xb5, 0x2202, 0x2211,
+		0x220f, 0x3c0, 0x222b, 0xaa, 0xba, 0x3a9, 0xe6, 0xf8,
+		0xbf, 0xa1, 0xac, 0x221a, 0x192, 0x2248, 0x2206, 0xab,
+		0xbb, 0x2026, 0xa0, 0xc0, 0xc3, 0xd5, 0x152, 0x153,
+		0x2013, 0x2014, 0x201c, 0x201d, 0x2018, 0x2019, 0xf7, 0x25ca,
+		0xff, 0x178, 0x2044, 0x20ac, 0x2039, 0x203a, 0xfb01, 0xfb02,
+		0x2021, 0xb7, 0x201a, 0x201e, 0x2030, 0xc2, 0xca, 0xc1,
+		0xcb, 0xc8, 0xcd, 0xce, 0xcf, 0xcc, 0xd3, 0xd4,
+		0xf8ff, 0xd2, 0xda, 0xdb, 0xd9, 0x131, 0x2c6, 0x2dc,
+		0xaf, 0x2d8, 0x2d9, 0x2da, 0xb8, 0x2dd, 0x2db, 0x2c7}
 
 	MacExpertEncodingUtf8 = []uint{
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x20, 0xef9ca1, 0xef9bb8, 0xef9ea2, 0xef9ca4, 0xef9ba4, 0xef9ca6, 0xef9eb4,
-		0xe281bd, 0xe281be, 0xe280a5, 0xe280a4, 0x2c, 0x2d, 0x2e, 0xe28184,
-		0xef9cb0, 0xef9cb1, 0xef9cb2, 0xef9cb3, 0xef9cb4, 0xef9cb5, 0xef9cb6, 0xef9cb7,
-		0xef9cb8, 0xef9cb9, 0x3a, 0x3b, 0x0, 0xef9b9e, 0x0, 0xef9cbf,
-		0x0, 0x0, 0x0, 0x0, 0xef9fb0, 0x0, 0x0, 0xc2bc,
-		0xc2bd, 0xc2be, 0xe2859b, 0xe2859c, 0xe2859d, 0xe2859e, 0xe28593, 0xe28594,
-		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xefac80, 0xefac81,
-		0xefac82, 0xefac83, 0xefac84, 0xe2828d, 0x0, 0xe2828e, 0xef9bb6, 0xef9ba5,
-		0xef9da0, 0xef9da1, 0xef9da2, 0xef9da3, 0xef9da4, 0xef9da5, 0xef9da6, 0xef9da7,
-		0xef9da8, 0xef9da9, 0xef9daa, 0xef9dab, 0xef9dac, 0xef9dad, 0xef9dae, 0xef9daf,
-		0xef9db0, 0xef9db1, 0xef9db2, 0xef9db3, 0xef9db4, 0xef9db5, 0xef9db6, 0xef9db7,
-		0xef9db8, 0xef9db9, 0xef9dba, 0xe282a1, 0xef9b9c, 0xef9b9d, 0xef9bbe, 0x0,
-		0x0, 0xef9ba9, 0xef9ba0, 0x0, 0x0, 0x0, 0x0, 0xef9fa1,
-		0xef9fa0, 0xef9fa2, 0xef9fa4, 0xef9fa3, 0xef9fa5, 0xef9fa7, 0xef9fa9, 0xef9fa8,
-		0xef9faa, 0xef9fab, 0xef9fad, 0xef9fac, 0xef9fae, 0xef9faf, 0xef9fb1, 0xef9fb3,
-		0xef9fb2, 0xef9fb4, 0xef9fb6, 0xef9fb5, 0xef9fba, 0xef9fb9, 0xef9fbb, 0xef9fbc,
-		0x0, 0xe281b8, 0xe28284, 0xe28283, 0xe28286, 0xe28288, 0xe28287, 0xef9bbd,
-		0x0, 0xef9b9f, 0xe28282, 0x0, 0xef9ea8, 0x0, 0xef9bb5, 0xef9bb0,
-		0xe28285, 0x0, 0xef9ba1, 0xef9ba7, 0xef9fbd, 0x0, 0xef9ba3, 0x0,
-		0x0, 0xef9fbe, 0x0, 0xe28289, 0xe28280, 0xef9bbf, 0xef9fa6, 0xef9fb8,
-		0xef9ebf, 0xe28281, 0xef9bb9, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x0, 0xef9eb8, 0x0, 0x0, 0x0, 0x0, 0x0, 0xef9bba,
-		0xe28092, 0xef9ba6, 0x0, 0x0, 0x0, 0x0, 0xef9ea1, 0x0,
-		0xef9fbf, 0x0, 0xc2b9, 0xc2b2, 0xc2b3, 0xe281b4, 0xe281b5, 0xe281b6,
-		0xe281b7, 0xe281b9, 0xe281b0, 0x0, 0xef9bac, 0xef9bb1, 0xef9bb3, 0x0,
-		0x0, 0xef9bad, 0xef9bb2, 0xef9bab, 0x0, 0x0, 0x0, 0x0,
-		0x0, 0xef9bae, 0xef9bbb, 0xef9bb4, 0xef9eaf, 0xef9baa, 0xe281bf, 0xef9baf,
-		0xef9ba2, 0xef9ba8, 0xef9bb7, 0xef9bbc, 0x0, 0x0, 0x0, 0x0}
+		0x20, 0xf721, 0xf6f8, 0xf7a2, 0xf724, 0xf6e4, 0xf726, 0xf7b4,
+		0x207d, 0x207e, 0x2025, 0x2024, 0x2c, 0x2d, 0x2e, 0x2044,
+		0xf730, 0xf731, 0xf732, 0xf733, 0xf734, 0xf735, 0xf736, 0xf737,
+		0xf738, 0xf739, 0x3a, 0x3b, 0x0, 0xf6de, 0x0, 0xf73f,
+		0x0, 0x0, 0x0, 0x0, 0xf7f0, 0x0, 0x0, 0xbc,
+		0xbd, 0xbe, 0x215b, 0x215c, 0x215d, 0x215e, 0x2153, 0x2154,
+		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xfb00, 0xfb01,
+		0xfb02, 0xfb03, 0xfb04, 0x208d, 0x0, 0x208e, 0xf6f6, 0xf6e5,
+		0xf760, 0xf761, 0xf762, 0xf763, 0xf764, 0xf765, 0xf766, 0xf767,
+		0xf768, 0xf769, 0xf76a, 0xf76b, 0xf76c, 0xf76d, 0xf76e, 0xf76f,
+		0xf770, 0xf771, 0xf772, 0xf773, 0xf774, 0xf775, 0xf776, 0xf777,
+		0xf778, 0xf779, 0xf77a, 0x20a1, 0xf6dc, 0xf6dd, 0xf6fe, 0x0,
+		0x0, 0xf6e9, 0xf6e0, 0x0, 0x0, 0x0, 0x0, 0xf7e1,
+		0xf7e0, 0xf7e2, 0xf7e4, 0xf7e3, 0xf7e5, 0xf7e7, 0xf7e9, 0xf7e8,
+		0xf7ea, 0xf7eb, 0xf7ed, 0xf7ec, 0xf7ee, 0xf7ef, 0xf7f1, 0xf7f3,
+		0xf7f2, 0xf7f4, 0xf7f6, 0xf7f5, 0xf7fa, 0xf7f9, 0xf7fb, 0xf7fc,
+		0x0, 0x2078, 0x2084, 0x2083, 0x2086, 0x2088, 0x2087, 0xf6fd,
+		0x0, 0xf6df, 0x2082, 0x0, 0xf7a8, 0x0, 0xf6f5, 0xf6f0,
+		0x2085, 0x0, 0xf6e1, 0xf6e7, 0xf7fd, 0x0, 0xf6e3, 0x0,
+		0x0, 0xf7fe, 0x0, 0x2089, 0x2080, 0xf6ff, 0xf7e6, 0xf7f8,
+		0xf7bf, 0x2081, 0xf6f9, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0x0, 0xf7b8, 0x0, 0x0, 0x0, 0x0, 0x0, 0xf6fa,
+		0x2012, 0xf6e6, 0x0, 0x0, 0x0, 0x0, 0xf7a1, 0x0,
+		0xf7ff, 0x0, 0xb9, 0xb2, 0xb3, 0x2074, 0x2075, 0x2076,
+		0x2077, 0x2079, 0x2070, 0x0, 0xf6ec, 0xf6f1, 0xf6f3, 0x0,
+		0x0, 0xf6ed, 0xf6f2, 0xf6eb, 0x0, 0x0, 0x0, 0x0,
+		0x0, 0xf6ee, 0xf6fb, 0xf6f4, 0xf7af, 0xf6ea, 0x207f, 0xf6ef,
+		0xf6e2, 0xf6e8, 0xf6f7, 0xf6fc, 0x0, 0x0, 0x0, 0x0}
 
 	StandardEncodingUtf8 = []uint{
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0xe28099,
+		0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x2019,
 		0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
 		0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
 		0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f,
@@ -159,86 +159,86 @@ var (
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x0, 0xc2a1, 0xc2a2, 0xc2a3, 0xe28184, 0xc2a5, 0xc692, 0xc2a7,
-		0xc2a4, 0x27, 0xe2809c, 0xc2ab, 0xe280b9, 0xe280ba, 0xefac81, 0xefac82,
-		0x0, 0xe28093, 0xe280a0, 0xe280a1, 0xc2b7, 0x0, 0xc2b6, 0xe280a2,
-		0xe2809a, 0xe2809e, 0xe2809d, 0xc2bb, 0xe280a6, 0xe280b0, 0x0, 0xc2bf,
-		0x0, 0x60, 0xc2b4, 0xcb86, 0xcb9c, 0xc2af, 0xcb98, 0xcb99,
-		0xc2a8, 0x0, 0xcb9a, 0xc2b8, 0xcb9d, 0xcb9b, 0xcb87, 0xe28094,
+		0x0, 0xa1, 0xa2, 0xa3, 0x2044, 0xa5, 0x192, 0xa7,
+		0xa4, 0x27, 0x201c, 0xab, 0x2039, 0x203a, 0xfb01, 0xfb02,
+		0x0, 0x2013, 0x2020, 0x2021, 0xb7, 0x0, 0xb6, 0x2022,
+		0x201a, 0x201e, 0x201d, 0xbb, 0x2026, 0x2030, 0x0, 0xbf,
+		0x0, 0x60, 0xb4, 0x2c6, 0x2dc, 0xaf, 0x2d8, 0x2d9,
+		0xa8, 0x0, 0x2da, 0xb8, 0x2dd, 0x2db, 0x2c7, 0x2014,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0xc386, 0x0, 0xc2aa, 0x0, 0x0, 0x0, 0x0, 0xc581,
-		0xc398, 0xc592, 0xc2ba, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0xc3a6, 0x0, 0x0, 0x0, 0xc4b1, 0x0, 0x0, 0xc582,
-		0xc3b8, 0xc593, 0xc39f, 0x0, 0x0, 0x0, 0x0, 0x0}
+		0xc6, 0x0, 0xaa, 0x0, 0x0, 0x0, 0x0, 0x141,
+		0xd8, 0x152, 0xba, 0x0, 0x0, 0x0, 0x0, 0x0,
+		0xe6, 0x0, 0x0, 0x0, 0x131, 0x0, 0x0, 0x142,
+		0xf8, 0x153, 0xdf, 0x0, 0x0, 0x0, 0x0, 0x0}
 
 	SymbolEncodingUtf8 = []uint{
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x20, 0x21, 0xe28880, 0x23, 0xe28883, 0x25, 0x26, 0xe2888b,
-		0x28, 0x29, 0xe28897, 0x2b, 0x2c, 0xe28892, 0x2e, 0x2f,
+		0x20, 0x21, 0x2200, 0x23, 0x2203, 0x25, 0x26, 0x220b,
+		0x28, 0x29, 0x2217, 0x2b, 0x2c, 0x2212, 0x2e, 0x2f,
 		0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
 		0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f,
-		0xe28985, 0xce91, 0xce92, 0xcea7, 0xce94, 0xce95, 0xcea6, 0xce93,
-		0xce97, 0xce99, 0xcf91, 0xce9a, 0xce9b, 0xce9c, 0xce9d, 0xce9f,
-		0xcea0, 0xce98, 0xcea1, 0xcea3, 0xcea4, 0xcea5, 0xcf82, 0xcea9,
-		0xce9e, 0xcea8, 0xce96, 0x5b, 0xe288b4, 0x5d, 0xe28aa5, 0x5f,
-		0xefa3a5, 0xceb1, 0xceb2, 0xcf87, 0xceb4, 0xceb5, 0xcf86, 0xceb3,
-		0xceb7, 0xceb9, 0xcf95, 0xceba, 0xcebb, 0xcebc, 0xcebd, 0xcebf,
-		0xcf80, 0xceb8, 0xcf81, 0xcf83, 0xcf84, 0xcf85, 0xcf96, 0xcf89,
-		0xcebe, 0xcf88, 0xceb6, 0x7b, 0x7c, 0x7d, 0xe288bc, 0x0,
+		0x2245, 0x391, 0x392, 0x3a7, 0x394, 0x395, 0x3a6, 0x393,
+		0x397, 0x399, 0x3d1, 0x39a, 0x39b, 0x39c, 0x39d, 0x39f,
+		0x3a0, 0x398, 0x3a1, 0x3a3, 0x3a4, 0x3a5, 0x3c2, 0x3a9,
+		0x39e, 0x3a8, 0x396, 0x5b, 0x2234, 0x5d, 0x22a5, 0x5f,
+		0xf8e5, 0x3b1, 0x3b2, 0x3c7, 0x3b4, 0x3b5, 0x3c6, 0x3b3,
+		0x3b7, 0x3b9, 0x3d5, 0x3ba, 0x3bb, 0x3bc, 0x3bd, 0x3bf,
+		0x3c0, 0x3b8, 0x3c1, 0x3c3, 0x3c4, 0x3c5, 0x3d6, 0x3c9,
+		0x3be, 0x3c8, 0x3b6, 0x7b, 0x7c, 0x7d, 0x223c, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0xe282ac, 0xcf92, 0xe280b2, 0xe289a4, 0xe28184, 0xe2889e, 0xc692, 0xe299a3,
-		0xe299a6, 0xe299a5, 0xe299a0, 0xe28694, 0xe28690, 0xe28691, 0xe28692, 0xe28693,
-		0xc2b0, 0xc2b1, 0xe280b3, 0xe289a5, 0xc397, 0xe2889d, 0xe28882, 0xe280a2,
-		0xc3b7, 0xe289a0, 0xe289a1, 0xe28988, 0xe280a6, 0xefa3a6, 0xefa3a7, 0xe286b5,
-		0xe284b5, 0xe28491, 0xe2849c, 0xe28498, 0xe28a97, 0xe28a95, 0xe28885, 0xe288a9,
-		0xe288aa, 0xe28a83, 0xe28a87, 0xe28a84, 0xe28a82, 0xe28a86, 0xe28888, 0xe28889,
-		0xe288a0, 0xe28887, 0xef9b9a, 0xef9b99, 0xef9b9b, 0xe2888f, 0xe2889a, 0xe28b85,
-		0xc2ac, 0xe288a7, 0xe288a8, 0xe28794, 0xe28790, 0xe28791, 0xe28792, 0xe28793,
-		0xe2978a, 0xe28ca9, 0xefa3a8, 0xefa3a9, 0xefa3aa, 0xe28891, 0xefa3ab, 0xefa3ac,
-		0xefa3ad, 0xefa3ae, 0xefa3af, 0xefa3b0, 0xefa3b1, 0xefa3b2, 0xefa3b3, 0xefa3b4,
-		0x0, 0xe28caa, 0xe288ab, 0xe28ca0, 0xefa3b5, 0xe28ca1, 0xefa3b6, 0xefa3b7,
-		0xefa3b8, 0xefa3b9, 0xefa3ba, 0xefa3bb, 0xefa3bc, 0xefa3bd, 0xefa3be, 0x0}
+		0x20ac, 0x3d2, 0x2032, 0x2264, 0x2044, 0x221e, 0x192, 0x2663,
+		0x2666, 0x2665, 0x2660, 0x2194, 0x2190, 0x2191, 0x2192, 0x2193,
+		0xb0, 0xb1, 0x2033, 0x2265, 0xd7, 0x221d, 0x2202, 0x2022,
+		0xf7, 0x2260, 0x2261, 0x2248, 0x2026, 0xf8e6, 0xf8e7, 0x21b5,
+		0x2135, 0x2111, 0x211c, 0x2118, 0x2297, 0x2295, 0x2205, 0x2229,
+		0x222a, 0x2283, 0x2287, 0x2284, 0x2282, 0x2286, 0x2208, 0x2209,
+		0x2220, 0x2207, 0xf6da, 0xf6d9, 0xf6db, 0x220f, 0x221a, 0x22c5,
+		0xac, 0x2227, 0x2228, 0x21d4, 0x21d0, 0x21d1, 0x21d2, 0x21d3,
+		0x25ca, 0x2329, 0xf8e8, 0xf8e9, 0xf8ea, 0x2211, 0xf8eb, 0xf8ec,
+		0xf8ed, 0xf8ee, 0xf8ef, 0xf8f0, 0xf8f1, 0xf8f2, 0xf8f3, 0xf8f4,
+		0x0, 0x232a, 0x222b, 0x2320, 0xf8f5, 0x2321, 0xf8f6, 0xf8f7,
+		0xf8f8, 0xf8f9, 0xf8fa, 0xf8fb, 0xf8fc, 0xf8fd, 0xf8fe, 0x0}
 
 	ZapfDingbatsEncodingUtf8 = []uint{
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x20, 0xe29c81, 0xe29c82, 0xe29c83, 0xe29c84, 0xe2988e, 0xe29c86, 0xe29c87,
-		0xe29c88, 0xe29c89, 0xe2989b, 0xe2989e, 0xe29c8c, 0xe29c8d, 0xe29c8e, 0xe29c8f,
-		0xe29c90, 0xe29c91, 0xe29c92, 0xe29c93, 0xe29c94, 0xe29c95, 0xe29c96, 0xe29c97,
-		0xe29c98, 0xe29c99, 0xe29c9a, 0xe29c9b, 0xe29c9c, 0xe29c9d, 0xe29c9e, 0xe29c9f,
-		0xe29ca0, 0xe29ca1, 0xe29ca2, 0xe29ca3, 0xe29ca4, 0xe29ca5, 0xe29ca6, 0xe29ca7,
-		0xe29885, 0xe29ca9, 0xe29caa, 0xe29cab, 0xe29cac, 0xe29cad, 0xe29cae, 0xe29caf,
-		0xe29cb0, 0xe29cb1, 0xe29cb2, 0xe29cb3, 0xe29cb4, 0xe29cb5, 0xe29cb6, 0xe29cb7,
-		0xe29cb8, 0xe29cb9, 0xe29cba, 0xe29cbb, 0xe29cbc, 0xe29cbd, 0xe29cbe, 0xe29cbf,
-		0xe29d80, 0xe29d81, 0xe29d82, 0xe29d83, 0xe29d84, 0xe29d85, 0xe29d86, 0xe29d87,
-		0xe29d88, 0xe29d89, 0xe29d8a, 0xe29d8b, 0xe2978f, 0xe29d8d, 0xe296a0, 0xe29d8f,
-		0xe29d90, 0xe29d91, 0xe29d92, 0xe296b2, 0xe296bc, 0xe29786, 0xe29d96, 0xe29797,
-		0xe29d98, 0xe29d99, 0xe29d9a, 0xe29d9b, 0xe29d9c, 0xe29d9d, 0xe29d9e, 0x0,
-		0xefa397, 0xefa398, 0xefa399, 0xefa39a, 0xefa39b, 0xefa39c, 0xefa39d, 0xefa39e,
-		0xefa39f, 0xefa3a0, 0xefa3a1, 0xefa3a2, 0xefa3a3, 0xefa3a4, 0x0, 0x0,
+		0x20, 0x2701, 0x2702, 0x2703, 0x2704, 0x260e, 0x2706, 0x2707,
+		0x2708, 0x2709, 0x261b, 0x261e, 0x270c, 0x270d, 0x270e, 0x270f,
+		0x2710, 0x2711, 0x2712, 0x2713, 0x2714, 0x2715, 0x2716, 0x2717,
+		0x2718, 0x2719, 0x271a, 0x271b, 0x271c, 0x271d, 0x271e, 0x271f,
+		0x2720, 0x2721, 0x2722, 0x2723, 0x2724, 0x2725, 0x2726, 0x2727,
+		0x2605, 0x2729, 0x272a, 0x272b, 0x272c, 0x272d, 0x272e, 0x272f,
+		0x2730, 0x2731, 0x2732, 0x2733, 0x2734, 0x2735, 0x2736, 0x2737,
+		0x2738, 0x2739, 0x273a, 0x273b, 0x273c, 0x273d, 0x273e, 0x273f,
+		0x2740, 0x2741, 0x2742, 0x2743, 0x2744, 0x2745, 0x2746, 0x2747,
+		0x2748, 0x2749, 0x274a, 0x274b, 0x25cf, 0x274d, 0x25a0, 0x274f,
+		0x2750, 0x2751, 0x2752, 0x25b2, 0x25bc, 0x25c6, 0x2756, 0x25d7,
+		0x2758, 0x2759, 0x275a, 0x275b, 0x275c, 0x275d, 0x275e, 0x0,
+		0xf8d7, 0xf8d8, 0xf8d9, 0xf8da, 0xf8db, 0xf8dc, 0xf8dd, 0xf8de,
+		0xf8df, 0xf8e0, 0xf8e1, 0xf8e2, 0xf8e3, 0xf8e4, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
 		0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
-		0x0, 0xe29da1, 0xe29da2, 0xe29da3, 0xe29da4, 0xe29da5, 0xe29da6, 0xe29da7,
-		0xe299a3, 0xe299a6, 0xe299a5, 0xe299a0, 0xe291a0, 0xe291a1, 0xe291a2, 0xe291a3,
-		0xe291a4, 0xe291a5, 0xe291a6, 0xe291a7, 0xe291a8, 0xe291a9, 0xe29db6, 0xe29db7,
-		0xe29db8, 0xe29db9, 0xe29dba, 0xe29dbb, 0xe29dbc, 0xe29dbd, 0xe29dbe, 0xe29dbf,
-		0xe29e80, 0xe29e81, 0xe29e82, 0xe29e83, 0xe29e84, 0xe29e85, 0xe29e86, 0xe29e87,
-		0xe29e88, 0xe29e89, 0xe29e8a, 0xe29e8b, 0xe29e8c, 0xe29e8d, 0xe29e8e, 0xe29e8f,
-		0xe29e90, 0xe29e91, 0xe29e92, 0xe29e93, 0xe29e94, 0xe28692, 0xe28694, 0xe28695,
-		0xe29e98, 0xe29e99, 0xe29e9a, 0xe29e9b, 0xe29e9c, 0xe29e9d, 0xe29e9e, 0xe29e9f,
-		0xe29ea0, 0xe29ea1, 0xe29ea2, 0xe29ea3, 0xe29ea4, 0xe29ea5, 0xe29ea6, 0xe29ea7,
-		0xe29ea8, 0xe29ea9, 0xe29eaa, 0xe29eab, 0xe29eac, 0xe29ead, 0xe29eae, 0xe29eaf,
-		0x0, 0xe29eb1, 0xe29eb2, 0xe29eb3, 0xe29eb4, 0xe29eb5, 0xe29eb6, 0xe29eb7,
-		0xe29eb8, 0xe29eb9, 0xe29eba, 0xe29ebb, 0xe29ebc, 0xe29ebd, 0xe29ebe, 0x0}
+		0x0, 0x2761, 0x2762, 0x2763, 0x2764, 0x2765, 0x2766, 0x2767,
+		0x2663, 0x2666, 0x2665, 0x2660, 0x2460, 0x2461, 0x2462, 0x2463,
+		0x2464, 0x2465, 0x2466, 0x2467, 0x2468, 0x2469, 0x2776, 0x2777,
+		0x2778, 0x2779, 0x277a, 0x277b, 0x277c, 0x277d, 0x277e, 0x277f,
+		0x2780, 0x2781, 0x2782, 0x2783, 0x2784, 0x2785, 0x2786, 0x2787,
+		0x2788, 0x2789, 0x278a, 0x278b, 0x278c, 0x278d, 0x278e, 0x278f,
+		0x2790, 0x2791, 0x2792, 0x2793, 0x2794, 0x2192, 0x2194, 0x2195,
+		0x2798, 0x2799, 0x279a, 0x279b, 0x279c, 0x279d, 0x279e, 0x279f,
+		0x27a0, 0x27a1, 0x27a2, 0x27a3, 0x27a4, 0x27a5, 0x27a6, 0x27a7,
+		0x27a8, 0x27a9, 0x27aa, 0x27ab, 0x27ac, 0x27ad, 0x27ae, 0x27af,
+		0x0, 0x27b1, 0x27b2, 0x27b3, 0x27b4, 0x27b5, 0x27b6, 0x27b7,
+		0x27b8, 0x27b9, 0x27ba, 0x27bb, 0x27bc, 0x27bd, 0x27be, 0x0}
 
 	mPdfPredefinedSimpleEncodings = map[string][]uint{
 		"MacRomanEncoding":     MacRomanEncodingUtf8,
@@ -314,89 +314,89 @@ var (
 	mPdfCharacterNames = map[string]uint{
 		".notdef":        0x0,
 		"A":              0x41,
-		"AE":             0xC386,
-		"Aacute":         0xC381,
-		"Acircumflex":    0xC382,
-		"Adieresis":      0xC384,
-		"Agrave":         0xC380,
-		"Aring":          0xC385,
-		"Aogonek":        0xC484,
-		"Atilde":         0xC383,
+		"AE":             0xC6,
+		"Aacute":         0xC1,
+		"Acircumflex":    0xC2,
+		"Adieresis":      0xC4,
+		"Agrave":         0xC0,
+		"Aring":          0xC5,
+		"Aogonek":        0x104,
+		"Atilde":         0xC3,
 		"B":              0x42,
 		"C":              0x43,
-		"Cacute":         0xC486,
-		"Ccedilla":       0xC387,
+		"Cacute":         0x106,
+		"Ccedilla":       0xC7,
 		"D":              0x44,
 		"E":              0x45,
-		"Eacute":         0xC389,
-		"Ecircumflex":    0xC38A,
-		"Edieresis":      0xC38B,
-		"Egrave":         0xC388,
-		"Eogonek":        0xC498,
-		"Eth":            0xC390,
-		"Euro":           0xE282AC,
+		"Eacute":         0xC9,
+		"Ecircumflex":    0xCA,
+		"Edieresis":      0xCB,
+		"Egrave":         0xC8,
+		"Eogonek":        0x118,
+		"Eth":            0xD0,
+		"Euro":           0x20AC,
 		"F":              0x46,
 		"G":              0x47,
 		"H":              0x48,
 		"I":              0x49,
-		"Iacute":         0xC38D,
-		"Icircumflex":    0xC38E,
-		"Idiereses":      0xC38F,
-		"Igrave":         0xC38C,
+		"Iacute":         0xCD,
+		"Icircumflex":    0xCE,
+		"Idiereses":      0xCF,
+		"Igrave":         0xCC,
 		"J":              0x4A,
 		"K":              0x4B,
 		"L":              0x4C,
-		"Lslash":         0xC581,
+		"Lslash":         0x141,
 		"M":              0x4D,
 		"N":              0x4E,
-		"Nacute":         0xC583,
-		"Ntilde":         0xC391,
+		"Nacute":         0x143,
+		"Ntilde":         0xD1,
 		"O":              0x4F,
-		"OE":             0xC592,
-		"Oacute":         0xC393,
-		"Ocircumflex":    0xC394,
-		"Odieresis":      0xC396,
-		"Ograve":         0xC392,
-		"Oslash":         0xC398,
-		"Otilde":         0xC395,
+		"OE":             0x152,
+		"Oacute":         0xD3,
+		"Ocircumflex":    0xD4,
+		"Odieresis":      0xD6,
+		"Ograve":         0xD2,
+		"Oslash":         0xD8,
+		"Otilde":         0xD5,
 		"P":              0x50,
 		"Q":              0x51,
 		"R":              0x52,
 		"S":              0x53,
-		"Sacute":         0xC59A,
-		"Scaron":         0xC5A0,
+		"Sacute":         0x15A,
+		"Scaron":         0x160,
 		"T":              0x54,
-		"Thorn":          0xC3BE,
+		"Thorn":          0xFE,
 		"U":              0x55,
-		"Uacute":         0xC39A,
-		"Ucircumflex":    0xC39B,
-		"Udieresis":      0xC39C,
-		"Ugrave":         0xC399,
+		"Uacute":         0xDA,
+		"Ucircumflex":    0xDB,
+		"Udieresis":      0xDC,
+		"Ugrave":         0xD9,
 		"V":              0x56,
 		"W":              0x57,
 		"X":              0x58,
 		"Y":              0x59,
-		"Yacute":         0xC39D,
-		"Ydieresis":      0xC5B8,
+		"Yacute":         0xDD,
+		"Ydieresis":      0x178,
 		"Z":              0x5A,
-		"Zacute":         0xC5B9,
-		"Zcaron":         0xC5BD,
-		"Zdot":           0xC5BB, //Im not sure about this one
+		"Zacute":         0x179,
+		"Zcaron":         0x17D,
+		"Zdot":           0x17B, //Im not sure about this one
 		"a":              0x61,
-		"aacute":         0xC3A1,
-		"acircumflex":    0xC3A2,
-		"acute":          0xC2B4,
-		"adieresis":      0xC3A4,
-		"ae":             0xC3A6,
-		"agrave":         0xC3A0,
+		"aacute":         0xE1,
+		"acircumflex":    0xE2,
+		"acute":          0xB4,
+		"adieresis":      0xE4,
+		"ae":             0xE6,
+		"agrave":         0xE0,
 		"ampersand":      0x26,
-		"aogonek":        0xC485,
-		"aring":          0xC3A5,
-		"asciicircum":    0xCB86,
-		"asciitilde":     0xCB9C,
+		"aogonek":        0x105,
+		"aring":          0xE5,
+		"asciicircum":    0x2C6,
+		"asciitilde":     0x2DC,
 		"asterisk":       0x2A,
 		"at":             0x40,
-		"atilde":         0xC3A3,
+		"atilde":         0xE3,
 		"b":              0x62,
 		"backslash":      0x5C,
 		"bar":            0x7C,
@@ -404,157 +404,157 @@ var (
 		"braceright":     0x7D,
 		"bracketleft":    0x5B,
 		"bracketright":   0x5D,
-		"breve":          0xCB98,
-		"brokenbar":      0xC2A6,
-		"bullet":         0xE280A2,
+		"breve":          0x2D8,
+		"brokenbar":      0xA6,
+		"bullet":         0x2022,
 		"c":              0x63,
-		"caron":          0xCB87,
-		"ccedilla":       0xC3A7,
-		"cedilla":        0xC2B8,
-		"cent":           0xC2A2,
+		"caron":          0x2C7,
+		"ccedilla":       0xE7,
+		"cedilla":        0xB8,
+		"cent":           0xA2,
 		"circumflex":     0x5E,
-		"cacute":         0xC487,
+		"cacute":         0x107,
 		"colon":          0x3A,
 		"comma":          0x2C,
-		"copyright":      0xC2A9,
-		"currency":       0xC2A4,
+		"copyright":      0xA9,
+		"currency":       0xA4,
 		"d":              0x64,
-		"dagger":         0xE280A0,
-		"daggerdbl":      0xE280A1,
-		"degree":         0xC2B0,
-		"dieresis":       0xC2A8,
-		"divide":         0xC3B7,
+		"dagger":         0x2020,
+		"daggerdbl":      0x2021,
+		"degree":         0xB0,
+		"dieresis":       0xA8,
+		"divide":         0xF7,
 		"dollar":         0x24,
-		"dotaccent":      0xCB99,
-		"dotlessi":       0xC4B1,
+		"dotaccent":      0x2D9,
+		"dotlessi":       0x131,
 		"e":              0x65,
-		"eacute":         0xC3A9,
-		"ecircumflex":    0xC3AA,
-		"edieresis":      0xC3AB,
-		"eogonek":        0xC499,
-		"egrave":         0xC3A8,
+		"eacute":         0xE9,
+		"ecircumflex":    0xEA,
+		"edieresis":      0xEB,
+		"eogonek":        0x119,
+		"egrave":         0xE8,
 		"eight":          0x38,
-		"ellipsis":       0xE280A6,
-		"emdash":         0xE28094,
-		"endash":         0xE28093,
+		"ellipsis":       0x2026,
+		"emdash":         0x2014,
+		"endash":         0x2013,
 		"equal":          0x3D,
-		"eth":            0xC3B0,
+		"eth":            0xF0,
 		"exclam":         0x21,
-		"exclamdown":     0xC2A1,
+		"exclamdown":     0xA1,
 		"f":              0x66,
-		"fi":             0xEFAC81,
+		"fi":             0xFB01,
 		"five":           0x35,
-		"fl":             0xEFAC82,
-		"florin":         0xC692,
+		"fl":             0xFB02,
+		"florin":         0x192,
 		"four":           0x34,
-		"fraction":       0xE281A4,
+		"fraction":       0x2064,
 		"g":              0x67,
-		"germandbls":     0xC39F,
+		"germandbls":     0xDF,
 		"grave":          0x60,
 		"greater":        0x3E,
-		"guillemotleft":  0xC2AB,
-		"guillemotright": 0xC2BB,
-		"guilsinglleft":  0xE280B9,
-		"guilsinglright": 0xE280BA,
+		"guillemotleft":  0xAB,
+		"guillemotright": 0xBB,
+		"guilsinglleft":  0x2039,
+		"guilsinglright": 0x203A,
 		"h":              0x68,
-		"hungarumlaut":   0xCB9D,
+		"hungarumlaut":   0x2DD,
 		"hyphen":         0x2D,
 		"i":              0x69,
-		"iacute":         0xC3AD,
-		"icircumflex":    0xC3AE,
-		"idieresis":      0xC3AF,
-		"igrave":         0xC3AC,
+		"iacute":         0xED,
+		"icircumflex":    0xEE,
+		"idieresis":      0xEF,
+		"igrave":         0xEC,
 		"j":              0x6A,
 		"k":              0x6B,
 		"l":              0x6C,
 		"less":           0x3C,
-		"logicalnot":     0xC2AC,
-		"lslash":         0xC582,
+		"logicalnot":     0xAC,
+		"lslash":         0x142,
 		"m":              0x6D,
-		"macron":         0xC2AF,
-		"minus":          0xE28892,
-		"mu":             0xC2B5,
-		"multiply":       0xC397,
+		"macron":         0xAF,
+		"minus":          0x2212,
+		"mu":             0xB5,
+		"multiply":       0xD7,
 		"n":              0x6E,
 		"nine":           0x39,
-		"nacute":         0xC584,
-		"ntilde":         0xC3B1,
+		"nacute":         0x144,
+		"ntilde":         0xF1,
 		"numbersign":     0x23,
 		"o":              0x6F,
-		"oacute":         0xC3B3,
-		"ocircumflex":    0xC3B4,
-		"odieresis":      0xC3B6,
-		"oe":             0xC593,
-		"ogonek":         0xCB9B,
-		"ograve":         0xC3B2,
+		"oacute":         0xF3,
+		"ocircumflex":    0xF4,
+		"odieresis":      0xF6,
+		"oe":             0x153,
+		"ogonek":         0x2DB,
+		"ograve":         0xF2,
 		"one":            0x31,
-		"onehalf":        0xC2BD,
-		"onequarter":     0xC2BC,
-		"onesuperior":    0xC2B9,
-		"ordfeminine":    0xC2AA,
-		"ordmasculine":   0xC2BA,
-		"oslash":         0xC3B8,
-		"otilde":         0xC3B5,
+		"onehalf":        0xBD,
+		"onequarter":     0xBC,
+		"onesuperior":    0xB9,
+		"ordfeminine":    0xAA,
+		"ordmasculine":   0xBA,
+		"oslash":         0xF8,
+		"otilde":         0xF5,
 		"p":              0x70,
-		"paragraph":      0xC2B6,
+		"paragraph":      0xB6,
 		"parenleft":      0x28,
 		"parenright":     0x29,
 		"percent":        0x25,
 		"period":         0x2E,
-		"periodcentered": 0xC2B7,
-		"perthousand":    0xE280B0,
+		"periodcentered": 0xB7,
+		"perthousand":    0x2030,
 		"plus":           0x2B,
-		"plusminus":      0xC2B1,
+		"plusminus":      0xB1,
 		"q":              0x71,
 		"question":       0x3F,
-		"questiondown":   0xC2BF,
+		"questiondown":   0xBF,
 		"quotedbl":       0x22,
-		"quotedblbase":   0xE2809E,
-		"quotedblleft":   0xE2809C,
-		"quotedblright":  0xE2809D,
-		"quoteleft":      0xE28098,
-		"quoteright":     0xE28099,
-		"quotesinglbase": 0xE2809A,
+		"quotedblbase":   0x201E,
+		"quotedblleft":   0x201C,
+		"quotedblright":  0x201D,
+		"quoteleft":      0x2018,
+		"quoteright":     0x2019,
+		"quotesinglbase": 0x201A,
 		"quotesingle":    0x27,
 		"r":              0x72,
-		"registered":     0xC2AE,
-		"rign":           0xCB9A,
+		"registered":     0xAE,
+		"rign":           0x2DA,
 		"s":              0x73,
-		"sacute":         0xC59B,
-		"scaron":         0xC5A1,
-		"section":        0xC2A7,
+		"sacute":         0x15B,
+		"scaron":         0x161,
+		"section":        0xA7,
 		"semicolon":      0x3B,
 		"seven":          0x37,
 		"six":            0x36,
 		"slash":          0x2F,
 		"space":          0x20,
-		"sterling":       0xC2A3,
+		"sterling":       0xA3,
 		"t":              0x74,
-		"thorn":          0xC39E,
+		"thorn":          0xDE,
 		"three":          0x33,
-		"threequarters":  0xC2BE,
-		"threesuperior":  0xC2B3,
+		"threequarters":  0xBE,
+		"threesuperior":  0xB3,
 		"tilde":          0x7E,
-		"trademark":      0xE284A2,
+		"trademark":      0x2122,
 		"two":            0x32,
-		"twosuperior":    0xC2B2,
+		"twosuperior":    0xB2,
 		"u":              0x75,
-		"uacute":         0xC3BA,
-		"ucircumflex":    0xC3BB,
-		"udieresis":      0xC3BC,
-		"ugrave":         0xC3B9,
+		"uacute":         0xFA,
+		"ucircumflex":    0xFB,
+		"udieresis":      0xFC,
+		"ugrave":         0xF9,
 		"underscore":     0x5F,
 		"v":              0x76,
 		"w":              0x77,
 		"x":              0x78,
 		"y":              0x79,
-		"yacute":         0xC3BD,
-		"ydieresis":      0xC3BF,
-		"yen":            0xC2A5,
+		"yacute":         0xFD,
+		"ydieresis":      0xFF,
+		"yen":            0xA5,
 		"z":              0x7A,
-		"zacute":         0xC5BA,
-		"zcaron":         0xC5BE,
-		"zdot":           0xC5BC, //not sure about this
+		"zacute":         0x17A,
+		"zcaron":         0x17E,
+		"zdot":           0x17C, //not sure about this
 		"zero":           0x30,
 	}
 
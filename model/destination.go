@@ -0,0 +1,116 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+
+	. "../core"
+)
+
+// Destination is an explicit destination: a page and a view of it.
+type Destination struct {
+	PageIndex int       // 0 based, -1 if the page is not in the document
+	Mode      string    // XYZ, Fit, FitH, FitV, FitR, FitB, FitBH or FitBV
+	Params    []float64 // the view parameters following the mode, NaN for null (unchanged) entries
+}
+
+// GetNamedDestinations returns the named destinations of the document, from both the /Dests dictionary
+// of the catalog (PDF 1.1) and the /Dests name tree of the /Names dictionary, the latter taking precedence
+// for names in both.  Destinations that can't be resolved are skipped.
+func (this *PdfReader) GetNamedDestinations() (map[string]Destination, error) {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return nil, errors.New("catalog missing")
+	}
+
+	this.loadPageList()
+	pageIndexes := map[int64]int{}
+	for i, page := range this.pageList {
+		pageIndexes[page.ObjectNumber] = i
+	}
+
+	dests := map[string]Destination{}
+	add := func(name string, obj PdfObject) {
+		dest, err := this.resolveDestination(obj, pageIndexes)
+		if err != nil {
+			this.log().Debug("Skipping destination %s: %v", name, err)
+			return
+		}
+		dests[name] = dest
+	}
+
+	if destsObj, err := this.parser.Trace(rootDict.Get("Dests")); err == nil {
+		if destsDict, ok := destsObj.(*PdfObjectDictionary); ok {
+			for _, key := range destsDict.Keys() {
+				add(string(key), destsDict.Get(key))
+			}
+		}
+	}
+
+	if namesObj, err := this.parser.Trace(rootDict.Get("Names")); err == nil {
+		if namesDict, ok := namesObj.(*PdfObjectDictionary); ok {
+			this.walkTree(namesDict.Get("Dests"), "Names", func(keyObj PdfObject, value PdfObject) {
+				if key, ok := keyObj.(*PdfObjectString); ok {
+					add(string(*key), value)
+				}
+			})
+		}
+	}
+
+	return dests, nil
+}
+
+// resolveDestination resolves a destination array [page /Mode params...], or a dictionary with the
+// array as /D.  The page is a page object reference, or a page number for remote destinations.
+func (this *PdfReader) resolveDestination(obj PdfObject, pageIndexes map[int64]int) (Destination, error) {
+	dest := Destination{PageIndex: -1}
+
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		return dest, err
+	}
+	if dict, ok := obj.(*PdfObjectDictionary); ok {
+		obj, err = this.parser.Trace(dict.Get("D"))
+		if err != nil {
+			return dest, err
+		}
+	}
+	arr, ok := obj.(*PdfObjectArray)
+	if !ok || len(*arr) < 2 {
+		return dest, errors.New("invalid destination")
+	}
+
+	switch page := (*arr)[0].(type) {
+	case *PdfObjectReference:
+		if index, has := pageIndexes[page.ObjectNumber]; has {
+			dest.PageIndex = index
+		}
+	case *PdfObjectInteger:
+		dest.PageIndex = int(*page)
+	default:
+		return dest, errors.New("invalid destination page")
+	}
+
+	mode, ok := (*arr)[1].(*PdfObjectName)
+	if !ok {
+		return dest, errors.New("invalid destination mode")
+	}
+	dest.Mode = string(*mode)
+
+	dest.Params = []float64{}
+	for _, paramObj := range (*arr)[2:] {
+		paramObj, _ = this.parser.Trace(paramObj)
+		param, err := GetNumberAsFloat(paramObj)
+		if err != nil {
+			param = math.NaN()
+		}
+		dest.Params = append(dest.Params, param)
+	}
+
+	return dest, nil
+}
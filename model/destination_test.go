@@ -0,0 +1,84 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestNamedDestinationsNameTree(t *testing.T) {
+	// the name tree has an intermediate node, the second destination is a dictionary
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R /Names << /Dests 5 0 R >> >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Kids [6 0 R] >>",
+		"<< /Limits [(chap1) (chap2)] /Names [(chap1) [4 0 R /XYZ 0 792 null] (chap2) 7 0 R] >>",
+		"<< /D [3 0 R /Fit] >>")
+	dests, err := openPdf(t, pdf).GetNamedDestinations()
+	if err != nil {
+		t.Fatalf("GetNamedDestinations: %v", err)
+	}
+	if len(dests) != 2 {
+		t.Fatalf("got %v", dests)
+	}
+
+	chap1 := dests["chap1"]
+	if chap1.PageIndex != 1 || chap1.Mode != "XYZ" || len(chap1.Params) != 3 ||
+		chap1.Params[0] != 0 || chap1.Params[1] != 792 || !math.IsNaN(chap1.Params[2]) {
+		t.Errorf("chap1: %+v", chap1)
+	}
+	chap2 := dests["chap2"]
+	if chap2.PageIndex != 0 || chap2.Mode != "Fit" || len(chap2.Params) != 0 {
+		t.Errorf("chap2: %+v", chap2)
+	}
+}
+
+func TestNamedDestinationsLegacyDests(t *testing.T) {
+	// a PDF 1.1 catalog /Dests dictionary, and a name tree overriding one of its entries
+	pages := []string{
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /D [4 0 R /FitH 700] >>",
+	}
+	testcases := []struct {
+		name     string
+		catalog  string
+		expected map[string]int
+	}{
+		{"Dests", "<< /Type /Catalog /Pages 2 0 R /Dests << /intro [3 0 R /Fit] /index 5 0 R >> >>",
+			map[string]int{"intro": 0, "index": 1}},
+		{"Dests and name tree", "<< /Type /Catalog /Pages 2 0 R /Dests << /intro [3 0 R /Fit] /index 5 0 R >> " +
+			"/Names << /Dests << /Names [(intro) [4 0 R /Fit] (toc) [3 0 R /Fit]] >> >> >>",
+			map[string]int{"intro": 1, "index": 1, "toc": 0}},
+	}
+
+	for _, tc := range testcases {
+		pdf := bytes.Replace(makePdf("", append([]string{tc.catalog}, pages...)...), []byte("%PDF-1.4"),
+			[]byte("%PDF-1.1"), 1)
+		dests, err := openPdf(t, pdf).GetNamedDestinations()
+		if err != nil {
+			t.Errorf("%s: GetNamedDestinations: %v", tc.name, err)
+			continue
+		}
+		if len(dests) != len(tc.expected) {
+			t.Errorf("%s: got %v", tc.name, dests)
+			continue
+		}
+		for name, pageIndex := range tc.expected {
+			if dest, ok := dests[name]; !ok || dest.PageIndex != pageIndex {
+				t.Errorf("%s: %s %+v, expected page %d", tc.name, name, dest, pageIndex)
+			}
+		}
+		if index := dests["index"]; index.Mode != "FitH" || len(index.Params) != 1 || index.Params[0] != 700 {
+			t.Errorf("%s: index %+v", tc.name, index)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func init() {
+	SetCMapResourceDir("../resources/")
+}
+
+const helveticaFont = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+
+// makePdf returns a PDF file of the objects, numbered from 1, with a cross-reference table and a trailer
+// of /Size, /Root 1 0 R and the trailer entries.
+func makePdf(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, obj := range objects {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer,
+		xref)
+	return buf.Bytes()
+}
+
+// makeStream returns a stream object of the data, with the entries of dict besides /Length.
+func makeStream(dict, data string) string {
+	return fmt.Sprintf("<< /Length %d %s >>\nstream\n%s\nendstream", len(data), dict, data)
+}
+
+// pagePdf returns a PDF file of a page of the content with the resources, e.g. "/Font << /F1 5 0 R >>",
+// and the objects numbered from 5 on.
+func pagePdf(content, resources string, objects ...string) []byte {
+	return makePdf("", append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << " + resources +
+			" >> >>",
+		makeStream("", content),
+	}, objects...)...)
+}
+
+// openPdf returns a reader of the PDF file.
+func openPdf(t *testing.T, pdf []byte) *PdfReader {
+	reader, err := NewPdfReader(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("NewPdfReader: %v", err)
+	}
+	return reader
+}
+
+// parseFonts returns the fonts of the page (0 based index) of the reader, parsed with ParseFonts.
+func parseFonts(t *testing.T, reader *PdfReader, pageIndex int) FontsByNames {
+	if err := reader.ParseFonts(); err != nil {
+		t.Fatalf("ParseFonts: %v", err)
+	}
+	return reader.GetFontsForPages()[pageIndex]
+}
@@ -0,0 +1,86 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+
+	. "../core"
+)
+
+// FormXObject is a form XObject, a content stream drawn by the Do operator.
+type FormXObject struct {
+	// Matrix maps the form space to the user space of the content stream drawing the form.
+	Matrix [6]float64
+	// BBox is the bounding box of the form in form space, llx lly urx ury, clipping what it draws.
+	BBox    [4]float64
+	Content []byte // decoded content of the form stream
+	Fonts   FontsByNames
+	// Resources of the form, in which the forms it draws are looked up.
+	Resources *PdfObjectDictionary
+}
+
+// GetFormXObject returns the form XObject of the name in the /XObject of the resources, nil if there is
+// none or it is another kind of XObject, such as an image.  A form without /Resources inherits those
+// passed, as PDF 1.1 forms do from the page.
+func (this *PdfReader) GetFormXObject(resources *PdfObjectDictionary, name string) (*FormXObject, error) {
+	if resources == nil {
+		return nil, nil
+	}
+	xobjectsObj, err := this.parser.Trace(resources.Get("XObject"))
+	if err != nil {
+		return nil, err
+	}
+	xobjects, ok := xobjectsObj.(*PdfObjectDictionary)
+	if !ok {
+		return nil, nil
+	}
+	xobjectObj, err := this.parser.Trace(xobjects.Get(PdfObjectName(name)))
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := xobjectObj.(*PdfObjectStream)
+	if !ok {
+		return nil, nil
+	}
+	if subtype, ok := stream.PdfObjectDictionary.Get("Subtype").(*PdfObjectName); !ok || *subtype != "Form" {
+		return nil, nil
+	}
+
+	form := &FormXObject{Matrix: [6]float64{1, 0, 0, 1, 0, 0}}
+	if values, err := this.getFloats(stream.PdfObjectDictionary.Get("Matrix")); err == nil && len(values) == 6 {
+		copy(form.Matrix[:], values)
+	}
+	bbox, err := this.getFloats(stream.PdfObjectDictionary.Get("BBox"))
+	if err != nil || len(bbox) != 4 {
+		return nil, errors.New("missing or invalid form BBox")
+	}
+	form.BBox = [4]float64{
+		math.Min(bbox[0], bbox[2]), math.Min(bbox[1], bbox[3]),
+		math.Max(bbox[0], bbox[2]), math.Max(bbox[1], bbox[3]),
+	}
+
+	form.Content, err = DecodeStream(stream)
+	if err != nil && !errors.Is(err, ErrTruncatedStream) && !errors.Is(err, ErrCorruptStream) {
+		return nil, err
+	}
+
+	resObj, err := this.parser.Trace(stream.PdfObjectDictionary.Get("Resources"))
+	if err != nil {
+		return nil, err
+	}
+	form.Resources, _ = resObj.(*PdfObjectDictionary)
+	if form.Resources == nil {
+		form.Resources = resources
+	}
+	form.Fonts, err = this.parseResourceFonts(form.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return form, nil
+}
@@ -0,0 +1,402 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+
+	. "../core"
+)
+
+// Function is a PDF function, mapping m input values to n output values.
+type Function interface {
+	// Eval evaluates the function.  Inputs are clipped to the domain and outputs to the range.
+	Eval(inputs []float64) []float64
+}
+
+// Functions nested deeper than this in stitching functions are invalid.
+const maxFunctionDepth = 8
+
+// LoadFunction loads the function dictionary or stream obj: a sampled (Type 0), exponential (Type 2),
+// stitching (Type 3) or PostScript calculator (Type 4) function.
+func (this *PdfReader) LoadFunction(obj PdfObject) (Function, error) {
+	return this.loadFunction(obj, 0)
+}
+
+func (this *PdfReader) loadFunction(obj PdfObject, depth int) (Function, error) {
+	if depth > maxFunctionDepth {
+		return nil, errors.New("functions nested too deep")
+	}
+
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var dict *PdfObjectDictionary
+	var stream *PdfObjectStream
+	switch t := obj.(type) {
+	case *PdfObjectDictionary:
+		dict = t
+	case *PdfObjectStream:
+		stream = t
+		dict = t.PdfObjectDictionary
+	default:
+		return nil, errors.New("function not a dictionary or stream")
+	}
+
+	typeObj, err := this.parser.Trace(dict.Get("FunctionType"))
+	if err != nil {
+		return nil, err
+	}
+	functionType, ok := typeObj.(*PdfObjectInteger)
+	if !ok {
+		return nil, errors.New("missing or invalid FunctionType")
+	}
+	domain, err := this.getFloats(dict.Get("Domain"))
+	if err != nil || len(domain) < 2 || len(domain)%2 != 0 {
+		return nil, errors.New("missing or invalid function Domain")
+	}
+	rangeArr, _ := this.getFloats(dict.Get("Range"))
+	if len(rangeArr)%2 != 0 {
+		return nil, errors.New("invalid function Range")
+	}
+
+	switch *functionType {
+	case 0:
+		if stream == nil {
+			return nil, errors.New("sampled function not a stream")
+		}
+		return this.loadSampledFunction(stream, domain, rangeArr)
+	case 2:
+		return this.loadExponentialFunction(dict, domain, rangeArr)
+	case 3:
+		return this.loadStitchingFunction(dict, domain, rangeArr, depth)
+	case 4:
+		if stream == nil {
+			return nil, errors.New("PostScript calculator function not a stream")
+		}
+		return this.loadPostScriptFunction(stream, domain, rangeArr)
+	}
+
+	return nil, errors.New("unsupported FunctionType")
+}
+
+// getFloats returns the traced numeric array obj.
+func (this *PdfReader) getFloats(obj PdfObject) ([]float64, error) {
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := obj.(*PdfObjectArray)
+	if !ok {
+		return nil, errors.New("not an array")
+	}
+
+	vals := make([]float64, len(*arr))
+	for i, elem := range *arr {
+		elem, err = this.parser.Trace(elem)
+		if err != nil {
+			return nil, err
+		}
+		vals[i], err = GetNumberAsFloat(elem)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// clip returns x clipped to [min, max].
+func clip(x, min, max float64) float64 {
+	return math.Max(min, math.Min(max, x))
+}
+
+// interpolate maps x from [xmin, xmax] to [ymin, ymax] linearly.
+func interpolate(x, xmin, xmax, ymin, ymax float64) float64 {
+	if xmax == xmin {
+		return ymin
+	}
+	return ymin + (x-xmin)*(ymax-ymin)/(xmax-xmin)
+}
+
+// clipInputs returns the m inputs clipped to the domain, missing inputs are taken as the domain minimum.
+func clipInputs(inputs []float64, domain []float64) []float64 {
+	clipped := make([]float64, len(domain)/2)
+	for i := range clipped {
+		x := domain[2*i]
+		if i < len(inputs) {
+			x = inputs[i]
+		}
+		clipped[i] = clip(x, domain[2*i], domain[2*i+1])
+	}
+	return clipped
+}
+
+// clipOutputs clips the outputs to the range, if any.
+func clipOutputs(outputs []float64, rangeArr []float64) []float64 {
+	for i := range outputs {
+		if 2*i+1 < len(rangeArr) {
+			outputs[i] = clip(outputs[i], rangeArr[2*i], rangeArr[2*i+1])
+		}
+	}
+	return outputs
+}
+
+// Sampled functions of more inputs than this are not supported, the interpolation is exponential in them.
+const maxSampledFunctionInputs = 8
+
+// sampledFunction is a Type 0 function: a table of samples, interpolated linearly between samples.  Cubic
+// spline interpolation (Order 3) is approximated linearly.
+type sampledFunction struct {
+	domain, rangeArr []float64
+	size             []int
+	bitsPerSample    int
+	encode, decode   []float64
+	samples          []byte
+}
+
+func (this *PdfReader) loadSampledFunction(stream *PdfObjectStream, domain, rangeArr []float64) (Function, error) {
+	m := len(domain) / 2
+	if m > maxSampledFunctionInputs {
+		return nil, errors.New("too many sampled function inputs")
+	}
+	if len(rangeArr) == 0 {
+		return nil, errors.New("sampled function without Range")
+	}
+	n := len(rangeArr) / 2
+
+	f := &sampledFunction{domain: domain, rangeArr: rangeArr}
+
+	sizes, err := this.getFloats(stream.PdfObjectDictionary.Get("Size"))
+	if err != nil || len(sizes) != m {
+		return nil, errors.New("missing or invalid sampled function Size")
+	}
+	total := n
+	for _, size := range sizes {
+		if size < 1 || size > 1<<16 {
+			return nil, errors.New("invalid sampled function Size")
+		}
+		f.size = append(f.size, int(size))
+		total *= int(size)
+	}
+
+	bpsObj, err := this.parser.Trace(stream.PdfObjectDictionary.Get("BitsPerSample"))
+	if err != nil {
+		return nil, err
+	}
+	bps, ok := bpsObj.(*PdfObjectInteger)
+	if !ok {
+		return nil, errors.New("missing or invalid BitsPerSample")
+	}
+	switch *bps {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+		f.bitsPerSample = int(*bps)
+	default:
+		return nil, errors.New("invalid BitsPerSample")
+	}
+
+	f.encode, _ = this.getFloats(stream.PdfObjectDictionary.Get("Encode"))
+	if len(f.encode) != 2*m {
+		f.encode = make([]float64, 2*m)
+		for i, size := range f.size {
+			f.encode[2*i+1] = float64(size - 1)
+		}
+	}
+	f.decode, _ = this.getFloats(stream.PdfObjectDictionary.Get("Decode"))
+	if len(f.decode) != 2*n {
+		f.decode = rangeArr
+	}
+
+	f.samples, err = DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.samples)*8 < total*f.bitsPerSample {
+		return nil, errors.New("sampled function data too short")
+	}
+
+	return f, nil
+}
+
+// sample returns the j-th output value of the sample at offset (in samples).
+func (f *sampledFunction) sample(offset int, j int) float64 {
+	n := len(f.rangeArr) / 2
+	bit := (offset*n + j) * f.bitsPerSample
+
+	v := readBits(f.samples, bit, f.bitsPerSample)
+	max := float64(uint64(1)<<uint(f.bitsPerSample) - 1)
+	return interpolate(float64(v), 0, max, f.decode[2*j], f.decode[2*j+1])
+}
+
+// readBits returns the n bits value at the bit offset of data, most significant bit first.  Bits past the
+// end of data are 0.
+func readBits(data []byte, bit int, n int) uint64 {
+	if n == 8 && bit%8 == 0 && bit/8 < len(data) {
+		return uint64(data[bit/8])
+	}
+
+	v := uint64(0)
+	for i := bit; i < bit+n; i++ {
+		v <<= 1
+		if i/8 < len(data) {
+			v |= uint64(data[i/8] >> uint(7-i%8) & 1)
+		}
+	}
+	return v
+}
+
+func (f *sampledFunction) Eval(inputs []float64) []float64 {
+	inputs = clipInputs(inputs, f.domain)
+	m := len(inputs)
+	n := len(f.rangeArr) / 2
+
+	// the sample cell of the inputs, and the position in it
+	low := make([]int, m)
+	frac := make([]float64, m)
+	for i, x := range inputs {
+		e := interpolate(x, f.domain[2*i], f.domain[2*i+1], f.encode[2*i], f.encode[2*i+1])
+		e = clip(e, 0, float64(f.size[i]-1))
+		low[i] = int(math.Floor(e))
+		if low[i] == f.size[i]-1 && low[i] > 0 {
+			low[i]--
+		}
+		frac[i] = e - float64(low[i])
+	}
+
+	// multilinear interpolation over the corners of the cell
+	outputs := make([]float64, n)
+	for corner := 0; corner < 1<<uint(m); corner++ {
+		weight := 1.0
+		offset, stride := 0, 1
+		for i := 0; i < m; i++ {
+			index := low[i]
+			if corner>>uint(i)&1 == 1 {
+				index++
+				weight *= frac[i]
+			} else {
+				weight *= 1 - frac[i]
+			}
+			if index >= f.size[i] {
+				index = f.size[i] - 1
+			}
+			offset += index * stride
+			stride *= f.size[i]
+		}
+		if weight == 0 {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			outputs[j] += weight * f.sample(offset, j)
+		}
+	}
+
+	return clipOutputs(outputs, f.rangeArr)
+}
+
+// exponentialFunction is a Type 2 function of one input: C0 + x^N (C1 - C0).
+type exponentialFunction struct {
+	domain, rangeArr []float64
+	c0, c1           []float64
+	n                float64
+}
+
+func (this *PdfReader) loadExponentialFunction(dict *PdfObjectDictionary, domain, rangeArr []float64) (Function, error) {
+	f := &exponentialFunction{domain: domain, rangeArr: rangeArr, c0: []float64{0}, c1: []float64{1}}
+
+	if c0, err := this.getFloats(dict.Get("C0")); err == nil {
+		f.c0 = c0
+	}
+	if c1, err := this.getFloats(dict.Get("C1")); err == nil {
+		f.c1 = c1
+	}
+	if len(f.c0) != len(f.c1) {
+		return nil, errors.New("exponential function C0 and C1 of different sizes")
+	}
+
+	nObj, err := this.parser.Trace(dict.Get("N"))
+	if err != nil {
+		return nil, err
+	}
+	f.n, err = GetNumberAsFloat(nObj)
+	if err != nil {
+		return nil, errors.New("missing or invalid exponential function N")
+	}
+
+	return f, nil
+}
+
+func (f *exponentialFunction) Eval(inputs []float64) []float64 {
+	x := clipInputs(inputs, f.domain)[0]
+	xn := math.Pow(x, f.n)
+
+	outputs := make([]float64, len(f.c0))
+	for j := range outputs {
+		outputs[j] = f.c0[j] + xn*(f.c1[j]-f.c0[j])
+	}
+	return clipOutputs(outputs, f.rangeArr)
+}
+
+// stitchingFunction is a Type 3 function of one input, combining functions over subdomains of the domain
+// split by the bounds.
+type stitchingFunction struct {
+	domain, rangeArr []float64
+	functions        []Function
+	bounds, encode   []float64
+}
+
+func (this *PdfReader) loadStitchingFunction(dict *PdfObjectDictionary, domain, rangeArr []float64, depth int) (Function, error) {
+	f := &stitchingFunction{domain: domain, rangeArr: rangeArr}
+
+	functionsObj, err := this.parser.Trace(dict.Get("Functions"))
+	if err != nil {
+		return nil, err
+	}
+	functions, ok := functionsObj.(*PdfObjectArray)
+	if !ok || len(*functions) == 0 {
+		return nil, errors.New("missing or invalid stitching function Functions")
+	}
+	for _, obj := range *functions {
+		function, err := this.loadFunction(obj, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		f.functions = append(f.functions, function)
+	}
+	k := len(f.functions)
+
+	f.bounds, err = this.getFloats(dict.Get("Bounds"))
+	if err != nil || len(f.bounds) != k-1 {
+		return nil, errors.New("missing or invalid stitching function Bounds")
+	}
+	f.encode, err = this.getFloats(dict.Get("Encode"))
+	if err != nil || len(f.encode) != 2*k {
+		return nil, errors.New("missing or invalid stitching function Encode")
+	}
+
+	return f, nil
+}
+
+func (f *stitchingFunction) Eval(inputs []float64) []float64 {
+	x := clipInputs(inputs, f.domain)[0]
+
+	// the subdomain [low, high) of x, the last one including the end of the domain
+	i := 0
+	for i < len(f.bounds) && x >= f.bounds[i] {
+		i++
+	}
+	low, high := f.domain[0], f.domain[1]
+	if i > 0 {
+		low = f.bounds[i-1]
+	}
+	if i < len(f.bounds) {
+		high = f.bounds[i]
+	}
+
+	x = interpolate(x, low, high, f.encode[2*i], f.encode[2*i+1])
+	return clipOutputs(f.functions[i].Eval([]float64{x}), f.rangeArr)
+}
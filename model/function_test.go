@@ -0,0 +1,90 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"math"
+	"testing"
+
+	. "../core"
+)
+
+// loadFunction returns the function of the object 5 of a file of the objects, numbered from 5 on.
+func loadFunction(t *testing.T, objects ...string) Function {
+	reader := openPdf(t, pagePdf("", "", objects...))
+	f, err := reader.LoadFunction(&PdfObjectReference{ObjectNumber: 5})
+	if err != nil {
+		t.Fatalf("LoadFunction: %v", err)
+	}
+	return f
+}
+
+// checkFunction compares the outputs of the function for the inputs to the expected ones.
+func checkFunction(t *testing.T, name string, f Function, cases [][2][]float64) {
+	for _, c := range cases {
+		inputs, expected := c[0], c[1]
+		outputs := f.Eval(inputs)
+		if len(outputs) != len(expected) {
+			t.Errorf("%s%v: got %v, expected %v", name, inputs, outputs, expected)
+			continue
+		}
+		for i := range expected {
+			if math.Abs(outputs[i]-expected[i]) > 1e-6 {
+				t.Errorf("%s%v: got %v, expected %v", name, inputs, outputs, expected)
+				break
+			}
+		}
+	}
+}
+
+func TestSampledFunction(t *testing.T) {
+	// 3 samples of 2 outputs, linearly interpolated
+	f := loadFunction(t, makeStream("/FunctionType 0 /Domain [0 1] /Range [0 1 0 10] /Size [3] "+
+		"/BitsPerSample 8 /Decode [0 1 0 10]", "\x00\xff\x80\x00\xff\x80"))
+	checkFunction(t, "sampled", f, [][2][]float64{
+		{{0}, {0, 10}},
+		{{0.5}, {128.0 / 255, 0}},
+		{{0.75}, {(128.0/255 + 1) / 2, 10 * 128.0 / 255 / 2}},
+		{{1}, {1, 10 * 128.0 / 255}},
+		// clipped to the domain
+		{{2}, {1, 10 * 128.0 / 255}},
+	})
+}
+
+func TestExponentialFunction(t *testing.T) {
+	f := loadFunction(t, "<< /FunctionType 2 /Domain [0 1] /C0 [0 1] /C1 [1 0] /N 2 >>")
+	checkFunction(t, "exponential", f, [][2][]float64{
+		{{0}, {0, 1}},
+		{{0.5}, {0.25, 0.75}},
+		{{1}, {1, 0}},
+		{{-1}, {0, 1}},
+	})
+}
+
+func TestStitchingFunction(t *testing.T) {
+	// 0 to 1 on [0, 0.5), 1 to 0 on [0.5, 1] by the encoding of the second function
+	f := loadFunction(t, "<< /FunctionType 3 /Domain [0 1] /Functions [6 0 R 6 0 R] /Bounds [0.5] "+
+		"/Encode [0 1 1 0] >>",
+		"<< /FunctionType 2 /Domain [0 1] /C0 [0] /C1 [1] /N 1 >>")
+	checkFunction(t, "stitching", f, [][2][]float64{
+		{{0}, {0}},
+		{{0.25}, {0.5}},
+		{{0.5}, {1}},
+		{{0.75}, {0.5}},
+		{{1}, {0}},
+	})
+}
+
+func TestPostScriptFunction(t *testing.T) {
+	// x y -> x*y, max(x, y), the range clipping the first output
+	f := loadFunction(t, makeStream("/FunctionType 4 /Domain [0 1 0 1] /Range [0 0.5 0 1]",
+		"{ 2 copy mul 3 1 roll 2 copy gt { pop } { exch pop } ifelse }"))
+	checkFunction(t, "PostScript", f, [][2][]float64{
+		{{0.5, 0.5}, {0.25, 0.5}},
+		{{0.2, 0.9}, {0.18, 0.9}},
+		{{1, 1}, {0.5, 1}},
+	})
+}
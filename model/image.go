@@ -0,0 +1,496 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+
+	. "../core"
+)
+
+// Images larger than this number of pixels are not decoded.
+const maxImagePixels = 1 << 28
+
+// Color spaces nested deeper than this, through alternate and base color spaces, are invalid.
+const maxColorSpaceDepth = 4
+
+// imageColorSpace converts the color components of image samples to RGB.
+type imageColorSpace struct {
+	components int
+	// toRGB converts the components, in the ranges of the color space, to RGB components in [0, 1].
+	toRGB func(values []float64) (float64, float64, float64)
+	// indexed color spaces have the sample values as color table indexes
+	indexed bool
+}
+
+// defaultDecode returns the default Decode array of the color space for the bits per component.
+func (cs *imageColorSpace) defaultDecode(bitsPerComponent int) []float64 {
+	if cs.indexed {
+		return []float64{0, float64(uint64(1)<<uint(bitsPerComponent) - 1)}
+	}
+
+	decode := make([]float64, 2*cs.components)
+	for i := 0; i < cs.components; i++ {
+		decode[2*i+1] = 1
+	}
+	return decode
+}
+
+var (
+	deviceGray = &imageColorSpace{components: 1, toRGB: func(v []float64) (float64, float64, float64) {
+		return v[0], v[0], v[0]
+	}}
+	deviceRGB = &imageColorSpace{components: 3, toRGB: func(v []float64) (float64, float64, float64) {
+		return v[0], v[1], v[2]
+	}}
+	deviceCMYK = &imageColorSpace{components: 4, toRGB: func(v []float64) (float64, float64, float64) {
+		return (1 - v[0]) * (1 - v[3]), (1 - v[1]) * (1 - v[3]), (1 - v[2]) * (1 - v[3])
+	}}
+)
+
+// loadColorSpace loads an image color space, a name or an array.  Calibrated and ICC based color spaces
+// are taken as their device counterparts.
+func (this *PdfReader) loadColorSpace(obj PdfObject, depth int) (*imageColorSpace, error) {
+	if depth > maxColorSpaceDepth {
+		return nil, errors.New("color space nested too deep")
+	}
+
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var family *PdfObjectName
+	var params []PdfObject
+	switch t := obj.(type) {
+	case *PdfObjectName:
+		family = t
+	case *PdfObjectArray:
+		if len(*t) == 0 {
+			return nil, errors.New("empty color space array")
+		}
+		familyObj, err := this.parser.Trace((*t)[0])
+		if err != nil {
+			return nil, err
+		}
+		family, _ = familyObj.(*PdfObjectName)
+		params = (*t)[1:]
+	}
+	if family == nil {
+		return nil, errors.New("invalid color space")
+	}
+
+	switch *family {
+	case "DeviceGray", "G", "CalGray":
+		return deviceGray, nil
+	case "DeviceRGB", "RGB", "CalRGB":
+		return deviceRGB, nil
+	case "DeviceCMYK", "CMYK":
+		return deviceCMYK, nil
+	case "ICCBased":
+		return this.loadICCBasedColorSpace(params, depth)
+	case "Separation", "DeviceN":
+		return this.loadDeviceNColorSpace(*family, params, depth)
+	case "Indexed", "I":
+		return this.loadIndexedColorSpace(params, depth)
+	}
+
+	return nil, errors.New("unsupported color space " + string(*family))
+}
+
+// loadICCBasedColorSpace loads [/ICCBased stream] as its /Alternate color space, or the device color space
+// of its number of components /N.
+func (this *PdfReader) loadICCBasedColorSpace(params []PdfObject, depth int) (*imageColorSpace, error) {
+	if len(params) < 1 {
+		return nil, errors.New("ICCBased color space without profile")
+	}
+	obj, err := this.parser.Trace(params[0])
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*PdfObjectStream)
+	if !ok {
+		return nil, errors.New("ICCBased profile not a stream")
+	}
+
+	if alternate := stream.PdfObjectDictionary.Get("Alternate"); alternate != nil {
+		return this.loadColorSpace(alternate, depth+1)
+	}
+
+	nObj, err := this.parser.Trace(stream.PdfObjectDictionary.Get("N"))
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := nObj.(*PdfObjectInteger); ok {
+		switch *n {
+		case 1:
+			return deviceGray, nil
+		case 3:
+			return deviceRGB, nil
+		case 4:
+			return deviceCMYK, nil
+		}
+	}
+	return nil, errors.New("invalid ICCBased N")
+}
+
+// loadDeviceNColorSpace loads [/Separation name alternate tintTransform] or
+// [/DeviceN names alternate tintTransform attributes], converting the tints with the tint transform to the
+// alternate color space.  The /All separation is taken as black for tint 1, the /None one is not painted
+// (white).
+func (this *PdfReader) loadDeviceNColorSpace(family PdfObjectName, params []PdfObject, depth int) (*imageColorSpace, error) {
+	if len(params) < 3 {
+		return nil, errors.New("invalid " + string(family) + " color space")
+	}
+
+	components := 1
+	namesObj, err := this.parser.Trace(params[0])
+	if err != nil {
+		return nil, err
+	}
+	if family == "DeviceN" {
+		names, ok := namesObj.(*PdfObjectArray)
+		if !ok || len(*names) == 0 {
+			return nil, errors.New("invalid DeviceN colorants")
+		}
+		components = len(*names)
+	} else if name, ok := namesObj.(*PdfObjectName); ok {
+		switch *name {
+		case "All":
+			return &imageColorSpace{components: 1, toRGB: func(v []float64) (float64, float64, float64) {
+				return 1 - v[0], 1 - v[0], 1 - v[0]
+			}}, nil
+		case "None":
+			return &imageColorSpace{components: 1, toRGB: func(v []float64) (float64, float64, float64) {
+				return 1, 1, 1
+			}}, nil
+		}
+	}
+
+	alternate, err := this.loadColorSpace(params[1], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	tintTransform, err := this.LoadFunction(params[2])
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageColorSpace{components: components, toRGB: func(v []float64) (float64, float64, float64) {
+		values := tintTransform.Eval(v)
+		for len(values) < alternate.components {
+			values = append(values, 0)
+		}
+		return alternate.toRGB(values)
+	}}, nil
+}
+
+// loadIndexedColorSpace loads [/Indexed base hival lookup], the lookup table being a string or a stream of
+// hival + 1 colors of the base color space, each component a byte mapped to [0, 1].  Color table indexes
+// are clipped to [0, hival], missing table entries are 0.
+func (this *PdfReader) loadIndexedColorSpace(params []PdfObject, depth int) (*imageColorSpace, error) {
+	if len(params) < 3 {
+		return nil, errors.New("invalid Indexed color space")
+	}
+
+	base, err := this.loadColorSpace(params[0], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if base.indexed {
+		return nil, errors.New("Indexed color space with an Indexed base")
+	}
+
+	hivalObj, err := this.parser.Trace(params[1])
+	if err != nil {
+		return nil, err
+	}
+	hival, ok := hivalObj.(*PdfObjectInteger)
+	if !ok || *hival < 0 || *hival > 255 {
+		return nil, errors.New("invalid Indexed color space hival")
+	}
+
+	lookupObj, err := this.parser.Trace(params[2])
+	if err != nil {
+		return nil, err
+	}
+	var lookup []byte
+	switch t := lookupObj.(type) {
+	case *PdfObjectString:
+		lookup = []byte(*t)
+	case *PdfObjectStream:
+		lookup, err = DecodeStream(t)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("invalid Indexed color space lookup table")
+	}
+	if need := (int(*hival) + 1) * base.components; len(lookup) < need {
+		this.log().Debug("Warning: Indexed color space lookup table too short, %d of %d bytes", len(lookup), need)
+	}
+
+	maxIndex := int(*hival)
+	values := make([]float64, base.components)
+	return &imageColorSpace{components: 1, indexed: true, toRGB: func(v []float64) (float64, float64, float64) {
+		index := int(clip(math.Floor(v[0]+0.5), 0, float64(maxIndex)))
+		for c := range values {
+			values[c] = 0
+			if i := index*base.components + c; i < len(lookup) {
+				values[c] = float64(lookup[i]) / 255
+			}
+		}
+		return base.toRGB(values)
+	}}, nil
+}
+
+// getImageInteger returns the traced integer value of key in the image dictionary, or def if missing.
+func (this *PdfReader) getImageInteger(dict *PdfObjectDictionary, key PdfObjectName, def int) (int, error) {
+	obj, err := this.parser.Trace(dict.Get(key))
+	if err != nil {
+		return 0, err
+	}
+	if obj == nil {
+		return def, nil
+	}
+	if _, isNull := obj.(*PdfObjectNull); isNull {
+		return def, nil
+	}
+	v, ok := obj.(*PdfObjectInteger)
+	if !ok {
+		return 0, errors.New("invalid image " + string(key))
+	}
+	return int(*v), nil
+}
+
+// DecodeImage decodes the samples of an image XObject to an image.  The samples are converted to RGB
+// through the color space and the /Decode array.  Stencil masks (/ImageMask) are opaque black where
+// painted and transparent elsewhere.  The transparency of the image is its alpha channel: the gray levels
+// of its soft mask (/SMask), or else the painted area of its explicit mask (/Mask image), and the samples
+// of the color key masking ranges (/Mask array) are transparent.  JPXDecode images are not supported, nor
+// JBIG2Decode images other than generic regions.
+func (this *PdfReader) DecodeImage(stream *PdfObjectStream) (image.Image, error) {
+	return this.decodeImage(stream, true)
+}
+
+// decodeImage decodes an image XObject, applying its masks if withMasks.
+func (this *PdfReader) decodeImage(stream *PdfObjectStream, withMasks bool) (*image.NRGBA, error) {
+	dict := stream.PdfObjectDictionary
+
+	width, err := this.getImageInteger(dict, "Width", 0)
+	if err != nil {
+		return nil, err
+	}
+	height, err := this.getImageInteger(dict, "Height", 0)
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 || height <= 0 || width > maxImagePixels/height {
+		return nil, errors.New("invalid image size")
+	}
+
+	isMask := false
+	if maskObj, err := this.parser.Trace(dict.Get("ImageMask")); err == nil {
+		if mask, ok := maskObj.(*PdfObjectBool); ok {
+			isMask = bool(*mask)
+		}
+	}
+
+	bitsPerComponent := 1
+	cs := deviceGray
+	if !isMask {
+		bitsPerComponent, err = this.getImageInteger(dict, "BitsPerComponent", 8)
+		if err != nil {
+			return nil, err
+		}
+		cs, err = this.loadColorSpace(dict.Get("ColorSpace"), 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch bitsPerComponent {
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, errors.New("invalid image BitsPerComponent")
+	}
+
+	decode, _ := this.getFloats(dict.Get("Decode"))
+	if len(decode) != 2*cs.components {
+		decode = cs.defaultDecode(bitsPerComponent)
+	}
+
+	// color key masking: [min max] ranges of the sample values, one per component
+	var colorKey []float64
+	if withMasks && !isMask {
+		if maskObj, err := this.parser.Trace(dict.Get("Mask")); err == nil {
+			if _, isArray := maskObj.(*PdfObjectArray); isArray {
+				colorKey, _ = this.getFloats(maskObj)
+				if len(colorKey) != 2*cs.components {
+					this.log().Debug("Invalid color key mask: %s", maskObj)
+					colorKey = nil
+				}
+			}
+		}
+	}
+
+	data, err := this.decodeImageData(stream)
+	if err != nil {
+		return nil, err
+	}
+	rowBits := (width*cs.components*bitsPerComponent + 7) / 8 * 8
+	if len(data)*8 < rowBits*height {
+		this.log().Debug("Warning: image data too short, %d of %d bytes", len(data), rowBits/8*height)
+	}
+
+	// the colors of the samples, cached for few bits per pixel where they repeat the most
+	maxSample := float64(uint64(1)<<uint(bitsPerComponent) - 1)
+	cache := map[uint64]color.NRGBA{}
+	useCache := cs.components*bitsPerComponent <= 32
+	raw := make([]uint64, cs.components)
+	values := make([]float64, cs.components)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bit := y*rowBits + x*cs.components*bitsPerComponent
+			key := uint64(0)
+			for c := range raw {
+				raw[c] = readBits(data, bit+c*bitsPerComponent, bitsPerComponent)
+				key = key<<uint(bitsPerComponent) | raw[c]
+			}
+			if useCache {
+				if pixel, has := cache[key]; has {
+					img.SetNRGBA(x, y, pixel)
+					continue
+				}
+			}
+
+			for c := range raw {
+				values[c] = interpolate(float64(raw[c]), 0, maxSample, decode[2*c], decode[2*c+1])
+			}
+			var pixel color.NRGBA
+			if isMask {
+				// painted where the decoded sample is 0
+				if values[0] < 0.5 {
+					pixel = color.NRGBA{0, 0, 0, 255}
+				}
+			} else {
+				r, g, b := cs.toRGB(values)
+				pixel = color.NRGBA{toColorByte(r), toColorByte(g), toColorByte(b), 255}
+				if colorKey != nil && inColorKey(raw, colorKey) {
+					pixel.A = 0
+				}
+			}
+
+			if useCache {
+				cache[key] = pixel
+			}
+			img.SetNRGBA(x, y, pixel)
+		}
+	}
+
+	if withMasks && !isMask {
+		if err := this.applyImageMask(img, dict); err != nil {
+			this.log().Debug("Error: image mask not applied, err: %v", err)
+		}
+	}
+
+	return img, nil
+}
+
+// decodeImageData decodes the samples of an image XObject.  The JBIG2Globals stream of a JBIG2Decode image
+// is resolved here, the decoder of the stream can't follow references.
+func (this *PdfReader) decodeImageData(stream *PdfObjectStream) ([]byte, error) {
+	filter, _ := this.parser.Trace(stream.PdfObjectDictionary.Get("Filter"))
+	params, _ := this.parser.Trace(stream.PdfObjectDictionary.Get("DecodeParms"))
+	if arr, ok := filter.(*PdfObjectArray); ok && len(*arr) == 1 {
+		filter, _ = this.parser.Trace((*arr)[0])
+		if arr, ok := params.(*PdfObjectArray); ok && len(*arr) == 1 {
+			params, _ = this.parser.Trace((*arr)[0])
+		}
+	}
+	if name, ok := filter.(*PdfObjectName); !ok || *name != StreamEncodingFilterNameJBIG2 {
+		return DecodeStream(stream)
+	}
+
+	encoder := NewJBIG2Encoder()
+	if paramsDict, ok := params.(*PdfObjectDictionary); ok {
+		globalsObj, err := this.parser.Trace(paramsDict.Get("JBIG2Globals"))
+		if err != nil {
+			return nil, err
+		}
+		if globals, ok := globalsObj.(*PdfObjectStream); ok {
+			encoder.Globals, err = DecodeStream(globals)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return encoder.DecodeStream(stream)
+}
+
+// inColorKey returns whether the sample values are all within their color key masking ranges.
+func inColorKey(raw []uint64, colorKey []float64) bool {
+	for c, v := range raw {
+		if float64(v) < colorKey[2*c] || float64(v) > colorKey[2*c+1] {
+			return false
+		}
+	}
+	return true
+}
+
+// applyImageMask multiplies the alpha channel of the image by its soft mask (/SMask), the gray levels of
+// the mask, or else by its explicit mask (/Mask stream), a stencil mask opaque where painted.  The mask is
+// scaled to the size of the image.
+func (this *PdfReader) applyImageMask(img *image.NRGBA, dict *PdfObjectDictionary) error {
+	soft := true
+	maskObj, err := this.parser.Trace(dict.Get("SMask"))
+	if err != nil {
+		return err
+	}
+	maskStream, ok := maskObj.(*PdfObjectStream)
+	if !ok {
+		soft = false
+		maskObj, err = this.parser.Trace(dict.Get("Mask"))
+		if err != nil {
+			return err
+		}
+		if maskStream, ok = maskObj.(*PdfObjectStream); !ok {
+			return nil
+		}
+	}
+
+	mask, err := this.decodeImage(maskStream, false)
+	if err != nil {
+		return err
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	maskWidth, maskHeight := mask.Bounds().Dx(), mask.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m := mask.NRGBAAt(x*maskWidth/width, y*maskHeight/height)
+			alpha := m.A
+			if soft {
+				alpha = m.R
+			}
+
+			pixel := img.NRGBAAt(x, y)
+			pixel.A = uint8(uint(pixel.A) * uint(alpha) / 255)
+			img.SetNRGBA(x, y, pixel)
+		}
+	}
+
+	return nil
+}
+
+// toColorByte converts a color component in [0, 1] to a byte.
+func toColorByte(v float64) uint8 {
+	return uint8(math.Floor(clip(v, 0, 1)*255 + 0.5))
+}
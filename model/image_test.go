@@ -0,0 +1,123 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"image/color"
+	"testing"
+
+	. "../core"
+)
+
+// imagePdf returns a PDF file of the image XObject of the dictionary entries and samples as object 5, and
+// the objects numbered from 6 on.
+func imagePdf(dict, samples string, objects ...string) []byte {
+	return pagePdf("q 100 0 0 100 0 0 cm /Im1 Do Q", "/XObject << /Im1 5 0 R >>",
+		append([]string{makeStream("/Type /XObject /Subtype /Image /BitsPerComponent 8 "+dict, samples)},
+			objects...)...)
+}
+
+// decodeImage returns the RGB colors of the pixels of the image object 5 of the PDF file, in row order.
+func decodeImage(t *testing.T, pdf []byte) []color.NRGBA {
+	reader := openPdf(t, pdf)
+	obj, err := reader.parser.LookupByNumber(5)
+	if err != nil {
+		t.Fatalf("LookupByNumber: %v", err)
+	}
+	stream, ok := obj.(*PdfObjectStream)
+	if !ok {
+		t.Fatalf("got %T", obj)
+	}
+	img, err := reader.DecodeImage(stream)
+	if err != nil {
+		t.Fatalf("DecodeImage: %v", err)
+	}
+
+	pixels := []color.NRGBA{}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixels = append(pixels, color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA))
+		}
+	}
+	return pixels
+}
+
+// checkPixels compares the pixels to the expected RGB colors, opaque.
+func checkPixels(t *testing.T, name string, pixels []color.NRGBA, expected [][3]uint8) {
+	if len(pixels) != len(expected) {
+		t.Errorf("%s: got %d pixels", name, len(pixels))
+		return
+	}
+	for i, rgb := range expected {
+		if pixels[i] != (color.NRGBA{rgb[0], rgb[1], rgb[2], 255}) {
+			t.Errorf("%s: pixel %d %v, expected %v", name, i, pixels[i], rgb)
+		}
+	}
+}
+
+func TestSeparationImage(t *testing.T) {
+	// a red spot color, of tints 0, 1 and 0.5
+	pdf := imagePdf("/Width 3 /Height 1 /ColorSpace [/Separation /Red /DeviceRGB 6 0 R]", "\x00\xff\x80",
+		"<< /FunctionType 2 /Domain [0 1] /C0 [1 1 1] /C1 [1 0 0] /N 1 >>")
+	checkPixels(t, "Separation", decodeImage(t, pdf), [][3]uint8{{255, 255, 255}, {255, 0, 0}, {255, 127, 127}})
+}
+
+func TestDeviceNImage(t *testing.T) {
+	// a sampled tint transform of the 2 colorants, the first input varying fastest in the samples
+	samples := "\xff\xff\xff\x00\xff\xff\xff\x00\xff\x00\x00\x00"
+	pdf := imagePdf("/Width 2 /Height 1 /ColorSpace [/DeviceN [/Cyan /Magenta] /DeviceRGB 6 0 R]", "\xff\x00\x00\xff",
+		makeStream("/FunctionType 0 /Domain [0 1 0 1] /Range [0 1 0 1 0 1] /Size [2 2] /BitsPerSample 8", samples))
+	checkPixels(t, "DeviceN", decodeImage(t, pdf), [][3]uint8{{0, 255, 255}, {255, 0, 255}})
+}
+
+func TestIndexedImage(t *testing.T) {
+	// a palette of red, green and blue, as a string and as a stream
+	palette := "\xff\x00\x00\x00\xff\x00\x00\x00\xff"
+	for _, lookup := range []string{"<FF000000FF000000FF>", "6 0 R"} {
+		pdf := imagePdf("/Width 4 /Height 1 /ColorSpace [/Indexed /DeviceRGB 2 "+lookup+"]", "\x02\x00\x01\x02",
+			makeStream("", palette))
+		checkPixels(t, "Indexed "+lookup, decodeImage(t, pdf),
+			[][3]uint8{{0, 0, 255}, {255, 0, 0}, {0, 255, 0}, {0, 0, 255}})
+	}
+}
+
+func TestImageMasks(t *testing.T) {
+	// 2x2 RGB of red, green, blue and black
+	samples := "\xff\x00\x00\x00\xff\x00\x00\x00\xff\x00\x00\x00"
+	testcases := []struct {
+		name, dict string
+		mask       string
+		alpha      []uint8
+	}{
+		{"SMask", "/SMask 6 0 R",
+			makeStream("/Type /XObject /Subtype /Image /Width 2 /Height 2 /BitsPerComponent 8 "+
+				"/ColorSpace /DeviceGray", "\x00\x55\xaa\xff"), []uint8{0, 85, 170, 255}},
+		// the explicit mask of 1x2 scaled to the image, painting where the samples are 0
+		{"Mask stream", "/Mask 6 0 R",
+			makeStream("/Type /XObject /Subtype /Image /Width 1 /Height 2 /ImageMask true", "\x00\x80"),
+			[]uint8{255, 255, 0, 0}},
+		// the pixels of no red
+		{"color key", "/Mask [0 0 0 255 0 255]", "", []uint8{255, 0, 0, 0}},
+	}
+	for _, tc := range testcases {
+		objects := []string{}
+		if tc.mask != "" {
+			objects = append(objects, tc.mask)
+		}
+		pdf := imagePdf("/Width 2 /Height 2 /ColorSpace /DeviceRGB "+tc.dict, samples, objects...)
+		pixels := decodeImage(t, pdf)
+		if len(pixels) != len(tc.alpha) {
+			t.Errorf("%s: got %d pixels", tc.name, len(pixels))
+			continue
+		}
+		for i, alpha := range tc.alpha {
+			if pixels[i].A != alpha {
+				t.Errorf("%s: pixel %d %v, expected alpha %d", tc.name, i, pixels[i], alpha)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	. "../core"
+)
+
+// GetCreationDate returns the /CreationDate of the document information dictionary.  Returns an error if
+// the document has none or it is not a valid date.
+func (this *PdfReader) GetCreationDate() (time.Time, error) {
+	return this.getInfoDate("CreationDate")
+}
+
+// GetModificationDate returns the /ModDate of the document information dictionary.  Returns an error if
+// the document has none or it is not a valid date.
+func (this *PdfReader) GetModificationDate() (time.Time, error) {
+	return this.getInfoDate("ModDate")
+}
+
+// getInfoDate returns the date of the key of the document information dictionary /Info of the trailer.
+func (this *PdfReader) getInfoDate(key PdfObjectName) (time.Time, error) {
+	trailerDict := this.parser.GetTrailer()
+	if trailerDict == nil {
+		return time.Time{}, errors.New("trailer missing")
+	}
+	infoObj, err := this.parser.Trace(trailerDict.Get("Info"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, ok := infoObj.(*PdfObjectDictionary)
+	if !ok {
+		return time.Time{}, errors.New("document information missing")
+	}
+
+	dateObj, err := this.parser.Trace(info.Get(key))
+	if err != nil {
+		return time.Time{}, err
+	}
+	date, ok := dateObj.(*PdfObjectString)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s missing", key)
+	}
+	return ParsePdfDate(decodeTextString(string(*date)))
+}
+
+// ParsePdfDate parses a PDF date string (section 7.9.4), D:YYYYMMDDHHmmSSOHH'mm' where O is the
+// relationship of local time to UT, +, - or Z.  The prefix D: and all the fields after the year are
+// optional, the month and day defaulting to 1 and the time to midnight.  The time is in a fixed zone of the
+// offset, UTC if there is none.  Trailing apostrophes and the apostrophe-less offset form +HHmm are
+// accepted.
+func ParsePdfDate(s string) (time.Time, error) {
+	str := strings.TrimPrefix(strings.TrimSpace(s), "D:")
+
+	// the digits of the date and time, up to the relationship to UT
+	end := 0
+	for end < len(str) && end < 14 && str[end] >= '0' && str[end] <= '9' {
+		end++
+	}
+	digits := str[:end]
+	if len(digits) < 4 || len(digits)%2 != 0 {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	// year, month, day, hour, minute, second
+	fields := []int{0, 1, 1, 0, 0, 0}
+	fields[0], _ = strconv.Atoi(digits[:4])
+	for i := 1; 4+2*i <= len(digits); i++ {
+		fields[i], _ = strconv.Atoi(digits[2+2*i : 4+2*i])
+	}
+	if fields[1] < 1 || fields[1] > 12 || fields[2] < 1 || fields[2] > 31 || fields[3] > 23 || fields[4] > 59 ||
+		fields[5] > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	location := time.UTC
+	if zone := strings.TrimRight(str[end:], "'"); zone != "" {
+		offset, err := parsePdfDateOffset(zone)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q: %v", s, err)
+		}
+		if offset != 0 || zone[0] != 'Z' {
+			location = time.FixedZone("", offset)
+		}
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0,
+		location), nil
+}
+
+// parsePdfDateOffset returns the offset in seconds of the relationship to UT of a date, Z, +HH'mm or -HH'mm
+// with the minutes and apostrophe optional.
+func parsePdfDateOffset(zone string) (int, error) {
+	sign := 1
+	switch zone[0] {
+	case 'Z', 'z':
+		if len(zone) == 1 {
+			return 0, nil
+		}
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return 0, errors.New("invalid time zone")
+	}
+
+	digits := strings.Replace(zone[1:], "'", "", -1)
+	if digits == "" {
+		return 0, nil
+	}
+	if len(digits) != 2 && len(digits) != 4 {
+		return 0, errors.New("invalid time zone offset")
+	}
+	hours, err := strconv.Atoi(digits[:2])
+	if err != nil || hours > 23 {
+		return 0, errors.New("invalid time zone offset")
+	}
+	minutes := 0
+	if len(digits) == 4 {
+		minutes, err = strconv.Atoi(digits[2:])
+		if err != nil || minutes > 59 {
+			return 0, errors.New("invalid time zone offset")
+		}
+	}
+
+	return sign * (hours*3600 + minutes*60), nil
+}
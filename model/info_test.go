@@ -0,0 +1,79 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePdfDate(t *testing.T) {
+	testcases := []struct {
+		date   string
+		utc    time.Time
+		offset int
+	}{
+		{"D:20200101120000+05'30'", time.Date(2020, 1, 1, 6, 30, 0, 0, time.UTC), 5*3600 + 30*60},
+		{"D:20200101", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		// truncated forms
+		{"D:2020", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"D:202003", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"D:2020031514", time.Date(2020, 3, 15, 14, 0, 0, 0, time.UTC), 0},
+		{"20200315142501", time.Date(2020, 3, 15, 14, 25, 1, 0, time.UTC), 0},
+		// offsets
+		{"D:20200315142501Z", time.Date(2020, 3, 15, 14, 25, 1, 0, time.UTC), 0},
+		{"D:20200315142501Z00'00'", time.Date(2020, 3, 15, 14, 25, 1, 0, time.UTC), 0},
+		{"D:20200315142501-08'00", time.Date(2020, 3, 15, 22, 25, 1, 0, time.UTC), -8 * 3600},
+		{"D:20200315142501+0530", time.Date(2020, 3, 15, 8, 55, 1, 0, time.UTC), 5*3600 + 30*60},
+		{"D:20200315142501+05'", time.Date(2020, 3, 15, 9, 25, 1, 0, time.UTC), 5 * 3600},
+	}
+	for _, tc := range testcases {
+		date, err := ParsePdfDate(tc.date)
+		if err != nil {
+			t.Errorf("%s: %v", tc.date, err)
+			continue
+		}
+		if !date.Equal(tc.utc) {
+			t.Errorf("%s: got %v, expected %v", tc.date, date, tc.utc)
+		}
+		if _, offset := date.Zone(); offset != tc.offset {
+			t.Errorf("%s: zone offset %d, expected %d", tc.date, offset, tc.offset)
+		}
+	}
+
+	// the wall clock time is kept
+	if date, _ := ParsePdfDate("D:20200101120000+05'30'"); date.Hour() != 12 || date.Minute() != 0 {
+		t.Errorf("wall clock %v", date)
+	}
+
+	for _, date := range []string{"", "D:", "D:20201", "D:20201301", "D:20200132", "D:20200101126000",
+		"D:20200101120000+5", "D:20200101120000X", "D:2020010112000a"} {
+		if _, err := ParsePdfDate(date); err == nil {
+			t.Errorf("%q: no error", date)
+		}
+	}
+}
+
+func TestInfoDates(t *testing.T) {
+	// the creation date a literal string, the modification date UTF-16BE
+	pdf := makePdf("/Info 3 0 R", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /CreationDate (D:20200101120000+05'30') /ModDate <FEFF0044003A0032003000320031> >>")
+	reader := openPdf(t, pdf)
+
+	date, err := reader.GetCreationDate()
+	if err != nil || !date.Equal(time.Date(2020, 1, 1, 6, 30, 0, 0, time.UTC)) {
+		t.Errorf("CreationDate %v, err: %v", date, err)
+	}
+	date, err = reader.GetModificationDate()
+	if err != nil || !date.Equal(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ModDate %v, err: %v", date, err)
+	}
+
+	pdf = makePdf("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")
+	if _, err := openPdf(t, pdf).GetCreationDate(); err == nil {
+		t.Errorf("no error without /Info")
+	}
+}
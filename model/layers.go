@@ -0,0 +1,228 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"strings"
+
+	. "../core"
+)
+
+// OptionalContentGroup is an optional content group (layer) of the document, with its state in the default
+// configuration.
+type OptionalContentGroup struct {
+	Name         string
+	ObjectNumber int64
+	On           bool
+}
+
+// GetOptionalContentGroups returns the optional content groups (layers) of the /OCGs of the catalog
+// /OCProperties, in order, with their state in the default configuration /D.  Returns an empty list if
+// the document has none.
+func (this *PdfReader) GetOptionalContentGroups() []OptionalContentGroup {
+	groups := []OptionalContentGroup{}
+	ocProperties := this.getOCProperties()
+	if ocProperties == nil {
+		return groups
+	}
+	ocgsObj, err := this.parser.Trace(ocProperties.Get("OCGs"))
+	if err != nil {
+		return groups
+	}
+	ocgs, ok := ocgsObj.(*PdfObjectArray)
+	if !ok {
+		return groups
+	}
+
+	hidden := this.hiddenOCGs()
+	for _, obj := range *ocgs {
+		ref, ok := obj.(*PdfObjectReference)
+		if !ok {
+			continue
+		}
+		group := OptionalContentGroup{ObjectNumber: ref.ObjectNumber, On: !hidden[ref.ObjectNumber]}
+		if ocgObj, err := this.parser.Trace(ref); err == nil {
+			if ocg, ok := ocgObj.(*PdfObjectDictionary); ok {
+				if nameObj, err := this.parser.Trace(ocg.Get("Name")); err == nil {
+					if name, ok := nameObj.(*PdfObjectString); ok {
+						group.Name = strings.TrimSpace(decodeTextString(string(*name)))
+					}
+				}
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// GetPageHiddenProperties returns the names of the /Properties resources of the page (0 based index) that
+// are optional content hidden in the default configuration: the groups off, and the membership
+// dictionaries (OCMD) whose visibility policy /P is not met.  The visibility expressions /VE are not
+// evaluated.  To be passed to Extractor.SetHiddenProperties to skip the text of the hidden layers.
+func (this *PdfReader) GetPageHiddenProperties(pageIndex int) (map[string]bool, error) {
+	_, resource, err := this.findPage(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	if resource == nil || this.getOCProperties() == nil {
+		return names, nil
+	}
+	propertiesObj, err := this.parser.Trace(resource.Get("Properties"))
+	if err != nil {
+		return names, nil
+	}
+	properties, ok := propertiesObj.(*PdfObjectDictionary)
+	if !ok {
+		return names, nil
+	}
+
+	hidden := this.hiddenOCGs()
+	for name, obj := range properties.Dict() {
+		if !this.isOptionalContentVisible(obj, hidden) {
+			names[string(name)] = true
+		}
+	}
+
+	return names, nil
+}
+
+// getOCProperties returns the /OCProperties of the catalog, nil if none.
+func (this *PdfReader) getOCProperties() *PdfObjectDictionary {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return nil
+	}
+	obj, err := this.parser.Trace(rootDict.Get("OCProperties"))
+	if err != nil {
+		return nil
+	}
+	ocProperties, _ := obj.(*PdfObjectDictionary)
+	return ocProperties
+}
+
+// hiddenOCGs returns the object numbers of the optional content groups off in the default configuration:
+// those of /OFF, or all those not in /ON when the /BaseState is /OFF.
+func (this *PdfReader) hiddenOCGs() map[int64]bool {
+	hidden := map[int64]bool{}
+	ocProperties := this.getOCProperties()
+	if ocProperties == nil {
+		return hidden
+	}
+	configObj, err := this.parser.Trace(ocProperties.Get("D"))
+	if err != nil {
+		return hidden
+	}
+	config, ok := configObj.(*PdfObjectDictionary)
+	if !ok {
+		return hidden
+	}
+
+	refs := func(key PdfObjectName) []int64 {
+		numbers := []int64{}
+		if arrObj, err := this.parser.Trace(config.Get(key)); err == nil {
+			if arr, ok := arrObj.(*PdfObjectArray); ok {
+				for _, obj := range *arr {
+					if ref, ok := obj.(*PdfObjectReference); ok {
+						numbers = append(numbers, ref.ObjectNumber)
+					}
+				}
+			}
+		}
+		return numbers
+	}
+
+	if baseState, ok := config.Get("BaseState").(*PdfObjectName); ok && *baseState == "OFF" {
+		on := map[int64]bool{}
+		for _, n := range refs("ON") {
+			on[n] = true
+		}
+		if ocgsObj, err := this.parser.Trace(ocProperties.Get("OCGs")); err == nil {
+			if ocgs, ok := ocgsObj.(*PdfObjectArray); ok {
+				for _, obj := range *ocgs {
+					if ref, ok := obj.(*PdfObjectReference); ok && !on[ref.ObjectNumber] {
+						hidden[ref.ObjectNumber] = true
+					}
+				}
+			}
+		}
+		return hidden
+	}
+
+	for _, n := range refs("OFF") {
+		hidden[n] = true
+	}
+	return hidden
+}
+
+// isOptionalContentVisible returns whether the optional content group or membership dictionary obj is
+// visible with the groups hidden.  Property lists of other types are visible.
+func (this *PdfReader) isOptionalContentVisible(obj PdfObject, hidden map[int64]bool) bool {
+	ref, isRef := obj.(*PdfObjectReference)
+	dictObj, err := this.parser.Trace(obj)
+	if err != nil {
+		return true
+	}
+	dict, ok := dictObj.(*PdfObjectDictionary)
+	if !ok {
+		return true
+	}
+
+	objType, _ := dict.Get("Type").(*PdfObjectName)
+	if objType == nil {
+		return true
+	}
+	switch *objType {
+	case "OCG":
+		return !isRef || !hidden[ref.ObjectNumber]
+	case "OCMD":
+	default:
+		return true
+	}
+
+	// the states of the groups of the membership dictionary, a single group or an array
+	states := []bool{}
+	ocgsObj := dict.Get("OCGs")
+	if ref, ok := ocgsObj.(*PdfObjectReference); ok {
+		if ocgsObj, err = this.parser.Trace(ref); err != nil {
+			return true
+		}
+		if _, isDict := ocgsObj.(*PdfObjectDictionary); isDict {
+			states = append(states, !hidden[ref.ObjectNumber])
+		}
+	}
+	if arr, ok := ocgsObj.(*PdfObjectArray); ok {
+		for _, obj := range *arr {
+			if ref, ok := obj.(*PdfObjectReference); ok {
+				states = append(states, !hidden[ref.ObjectNumber])
+			}
+		}
+	}
+	if len(states) == 0 {
+		return true
+	}
+
+	anyOn, allOn := false, true
+	for _, on := range states {
+		anyOn = anyOn || on
+		allOn = allOn && on
+	}
+	policy, _ := dict.Get("P").(*PdfObjectName)
+	if policy == nil {
+		return anyOn
+	}
+	switch *policy {
+	case "AllOn":
+		return allOn
+	case "AnyOff":
+		return !allOn
+	case "AllOff":
+		return !anyOn
+	}
+	return anyOn
+}
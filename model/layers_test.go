@@ -0,0 +1,57 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+// layersPdf returns a PDF file of a page whose properties W, N and M are the groups Watermark and Notes,
+// objects 5 and 6, and a membership dictionary of both with the policy, the default configuration being d.
+func layersPdf(d, policy string) []byte {
+	return makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R /OCProperties << /OCGs [5 0 R 6 0 R] /D "+d+" >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R "+
+			"/Resources << /Properties << /W 5 0 R /N 6 0 R /M 7 0 R >> >> >>",
+		makeStream("", ""),
+		"<< /Type /OCG /Name (Watermark) >>",
+		"<< /Type /OCG /Name (Notes) >>",
+		"<< /Type /OCMD /OCGs [5 0 R 6 0 R] /P /"+policy+" >>")
+}
+
+func TestOptionalContentGroups(t *testing.T) {
+	testcases := []struct {
+		d, policy string
+		on        [2]bool
+		hidden    map[string]bool
+	}{
+		{"<< /OFF [5 0 R] >>", "AllOn", [2]bool{false, true}, map[string]bool{"W": true, "M": true}},
+		{"<< /OFF [5 0 R] >>", "AnyOn", [2]bool{false, true}, map[string]bool{"W": true}},
+		// the groups missing from /ON are off
+		{"<< /BaseState /OFF /ON [6 0 R] >>", "AllOff", [2]bool{false, true}, map[string]bool{"W": true,
+			"M": true}},
+		{"<< >>", "AnyOff", [2]bool{true, true}, map[string]bool{"M": true}},
+	}
+
+	for _, tc := range testcases {
+		reader := openPdf(t, layersPdf(tc.d, tc.policy))
+		groups := reader.GetOptionalContentGroups()
+		expected := []OptionalContentGroup{{"Watermark", 5, tc.on[0]}, {"Notes", 6, tc.on[1]}}
+		if !reflect.DeepEqual(groups, expected) {
+			t.Errorf("%s: groups %v", tc.d, groups)
+		}
+		hidden, err := reader.GetPageHiddenProperties(0)
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.d, tc.policy, err)
+			continue
+		}
+		if !reflect.DeepEqual(hidden, tc.hidden) {
+			t.Errorf("%s %s: hidden %v, expected %v", tc.d, tc.policy, hidden, tc.hidden)
+		}
+	}
+}
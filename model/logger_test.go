@@ -0,0 +1,50 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"../common"
+)
+
+// captureLogger records the debug messages.
+type captureLogger struct {
+	common.DummyLogger
+	messages []string
+}
+
+func (this *captureLogger) Debug(format string, args ...interface{}) {
+	this.messages = append(this.messages, fmt.Sprintf(format, args...))
+}
+
+func TestReaderLogger(t *testing.T) {
+	previous := common.Log
+	defer common.SetLogger(previous)
+	global := &captureLogger{}
+	common.SetLogger(global)
+
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /UserUnit -1 >>")
+	reader := openPdf(t, pdf)
+	logger := &captureLogger{}
+	reader.SetLogger(logger)
+	global.messages = nil
+
+	if userUnit, err := reader.GetPageUserUnit(0); err != nil || userUnit != 1 {
+		t.Fatalf("UserUnit %v, err: %v", userUnit, err)
+	}
+	if len(logger.messages) != 1 || !strings.Contains(logger.messages[0], "Invalid UserUnit") {
+		t.Errorf("reader logger messages %q", logger.messages)
+	}
+	if len(global.messages) != 0 {
+		t.Errorf("global logger messages %q", global.messages)
+	}
+}
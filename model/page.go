@@ -0,0 +1,324 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"errors"
+	"math"
+
+	. "../core"
+)
+
+// getPageDict returns the page dictionary of the page (0 based index).
+func (this *PdfReader) getPageDict(pageIndex int) (*PdfObjectDictionary, error) {
+	page, err := this.GetPage(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	pageDict, ok := page.PdfObject.(*PdfObjectDictionary)
+	if !ok {
+		return nil, errors.New("page object not a dictionary")
+	}
+
+	return pageDict, nil
+}
+
+// getInheritedAttribute returns the traced value of key in the page dictionary, or in its closest
+// ancestor in the page tree having it, nil if none has it.
+func (this *PdfReader) getInheritedAttribute(pageDict *PdfObjectDictionary, key PdfObjectName) PdfObject {
+	traversed := map[*PdfObjectDictionary]bool{}
+	for node := pageDict; node != nil && !traversed[node]; {
+		traversed[node] = true
+
+		if obj := node.Get(key); obj != nil {
+			if traced, err := this.parser.Trace(obj); err == nil {
+				return traced
+			}
+			return nil
+		}
+
+		parentObj, err := this.parser.Trace(node.Get("Parent"))
+		if err != nil {
+			return nil
+		}
+		if parentInd, ok := parentObj.(*PdfIndirectObject); ok {
+			parentObj = parentInd.PdfObject
+		}
+		node, _ = parentObj.(*PdfObjectDictionary)
+	}
+
+	return nil
+}
+
+// Page attributes inherited from the ancestors in the page tree, Resources aside.
+var inheritablePageAttributes = []PdfObjectName{"MediaBox", "CropBox", "Rotate"}
+
+// Pages returns the page dictionaries in document order, the order of the Kids traversal of GetPageList.
+// The dictionaries are copies with the inherited attributes merged in: the Resources of the page, and the
+// MediaBox, CropBox and Rotate of the closest ancestor having them when the page lacks them.
+func (this *PdfReader) Pages() []*PdfObjectDictionary {
+	this.loadPageList()
+	pages := make([]*PdfObjectDictionary, 0, len(this.pageList))
+	for i, page := range this.pageList {
+		// buildPageList only lists dictionaries
+		pageDict, _ := page.PdfObject.(*PdfObjectDictionary)
+
+		merged := MakeDict()
+		merged.Merge(pageDict)
+		if i < len(this.pageResources) && this.pageResources[i] != nil {
+			merged.Set("Resources", this.pageResources[i])
+		}
+		for _, key := range inheritablePageAttributes {
+			if merged.Get(key) != nil {
+				continue
+			}
+			if obj := this.getInheritedAttribute(pageDict, key); obj != nil {
+				merged.Set(key, obj)
+			}
+		}
+		pages = append(pages, merged)
+	}
+
+	return pages
+}
+
+// GetPageUserUnit returns the /UserUnit of the page (0 based index), the size of a user space unit in
+// multiples of 1/72 inch.  Defaults to 1.0.
+func (this *PdfReader) GetPageUserUnit(pageIndex int) (float64, error) {
+	pageDict, err := this.getPageDict(pageIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	userUnitObj, err := this.parser.Trace(pageDict.Get("UserUnit"))
+	if err != nil || userUnitObj == nil {
+		return 1.0, nil
+	}
+	userUnit, err := GetNumberAsFloat(userUnitObj)
+	if err != nil || userUnit <= 0 {
+		this.log().Debug("Invalid UserUnit: %s, using 1.0", userUnitObj)
+		return 1.0, nil
+	}
+
+	return userUnit, nil
+}
+
+// GetPageMediaBox returns the MediaBox [llx lly urx ury] of the page (0 based index) in user space units.
+// The MediaBox may be inherited from the page tree.
+func (this *PdfReader) GetPageMediaBox(pageIndex int) ([4]float64, error) {
+	var box [4]float64
+
+	pageDict, err := this.getPageDict(pageIndex)
+	if err != nil {
+		return box, err
+	}
+
+	boxObj := this.getInheritedAttribute(pageDict, "MediaBox")
+	if boxObj == nil {
+		return box, errors.New("missing MediaBox")
+	}
+	box, err = this.getRectangle(boxObj)
+	if err != nil {
+		this.log().Debug("Invalid MediaBox: %s", boxObj)
+		return box, errors.New("invalid MediaBox")
+	}
+
+	return box, nil
+}
+
+// getRectangle reads a rectangle [llx lly urx ury].  The array and its elements may be indirect references.
+func (this *PdfReader) getRectangle(obj PdfObject) ([4]float64, error) {
+	var rect [4]float64
+
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		return rect, err
+	}
+	arr, ok := obj.(*PdfObjectArray)
+	if !ok || len(*arr) != 4 {
+		return rect, errors.New("rectangle not an array of 4 numbers")
+	}
+	for i, elem := range *arr {
+		elem, err = this.parser.Trace(elem)
+		if err != nil {
+			return rect, err
+		}
+		rect[i], err = GetNumberAsFloat(elem)
+		if err != nil {
+			return rect, err
+		}
+	}
+
+	return rect, nil
+}
+
+// GetPageSize returns the physical width and height of the page (0 based index) in points (1/72 inch):
+// the MediaBox dimensions scaled by the UserUnit.
+func (this *PdfReader) GetPageSize(pageIndex int) (float64, float64, error) {
+	box, err := this.GetPageMediaBox(pageIndex)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userUnit, err := this.GetPageUserUnit(pageIndex)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	width := (box[2] - box[0]) * userUnit
+	height := (box[3] - box[1]) * userUnit
+	if width < 0 {
+		width = -width
+	}
+	if height < 0 {
+		height = -height
+	}
+
+	return width, height, nil
+}
+
+// GetPageRotate returns the /Rotate of the page (0 based index), the clockwise rotation of the page
+// when displayed, normalized to 0, 90, 180 or 270.  The Rotate may be inherited from the page tree,
+// negative values are taken modulo 360 and values that are not a multiple of 90 are rounded to the
+// nearest multiple, halfway values down (45 is 0).
+func (this *PdfReader) GetPageRotate(pageIndex int) (int, error) {
+	pageDict, err := this.getPageDict(pageIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	rotateObj := this.getInheritedAttribute(pageDict, "Rotate")
+	if rotateObj == nil {
+		return 0, nil
+	}
+	rotate, err := GetNumberAsFloat(rotateObj)
+	if err != nil {
+		this.log().Debug("Invalid Rotate: %s, using 0", rotateObj)
+		return 0, nil
+	}
+
+	return this.normalizeRotation(rotate), nil
+}
+
+// normalizeRotation maps a rotation in degrees to 0, 90, 180 or 270.
+func (this *PdfReader) normalizeRotation(rotate float64) int {
+	rounded := int(math.Ceil(rotate/90-0.5)) * 90
+	if float64(rounded) != rotate {
+		this.log().Debug("Warning: Rotate %v not a multiple of 90, using %d", rotate, rounded)
+	}
+
+	rounded %= 360
+	if rounded < 0 {
+		rounded += 360
+	}
+	return rounded
+}
+
+// GetPageContentStreams returns the content streams of the page (0 based index), in order.  /Contents may
+// be a single stream or an array of streams, entries not tracing to streams, such as nulls in broken files,
+// are skipped with a warning.
+func (this *PdfReader) GetPageContentStreams(pageIndex int) ([]*PdfObjectStream, error) {
+	pageDict, err := this.getPageDict(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := []*PdfObjectStream{}
+	contentsObj, err := this.parser.Trace(pageDict.Get("Contents"))
+	if err != nil {
+		return nil, err
+	}
+	if contentsObj == nil {
+		return streams, nil
+	}
+
+	contents := []PdfObject{contentsObj}
+	if contentsArray, ok := contentsObj.(*PdfObjectArray); ok {
+		contents = *contentsArray
+	}
+
+	for i, obj := range contents {
+		contentObj, err := this.parser.Trace(obj)
+		if err != nil {
+			this.log().Debug("Error: trace content to obj failed, err: %s", err)
+			continue
+		}
+		contentStmObj, ok := contentObj.(*PdfObjectStream)
+		if !ok {
+			this.log().Debug("Warning: page %d content %d is not a stream (%T), skipped", pageIndex, i, contentObj)
+			continue
+		}
+		streams = append(streams, contentStmObj)
+	}
+
+	return streams, nil
+}
+
+// DecodeContentStream decodes a content stream.  The decoded data is cached by object number, so that a
+// stream shared by several pages is decoded once and consistently.  The returned data must not be modified.
+func (this *PdfReader) DecodeContentStream(stream *PdfObjectStream) ([]byte, error) {
+	if stream.ObjectNumber <= 0 {
+		// Not an indirect object, can't be shared.
+		data, err := DecodeStream(stream)
+		if errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptStream) {
+			this.log().Debug("Content stream: %v", err)
+			return data, nil
+		}
+		return data, err
+	}
+
+	this.contentCacheMutex.Lock()
+	defer this.contentCacheMutex.Unlock()
+
+	if data, has := this.contentCache[stream.PdfObjectReference]; has {
+		return data, nil
+	}
+
+	data, err := DecodeStream(stream)
+	if errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptStream) {
+		// the content decoded so far is extracted
+		this.log().Debug("Content stream %d: %v", stream.ObjectNumber, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if this.contentCache == nil {
+		this.contentCache = map[PdfObjectReference][]byte{}
+	}
+	this.contentCache[stream.PdfObjectReference] = data
+	return data, nil
+}
+
+// GetPageContent returns the decoded content of the page (0 based index): its content streams decoded and
+// concatenated in order, separated by a newline since a stream boundary may fall between two tokens.
+func (this *PdfReader) GetPageContent(pageIndex int) ([]byte, error) {
+	streams, err := this.GetPageContentStreams(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return this.DecodeContentStreams(streams)
+}
+
+// DecodeContentStreams decodes the content streams of a page and concatenates them in order, separated by a
+// newline, to be parsed as a single content stream.
+func (this *PdfReader) DecodeContentStreams(streams []*PdfObjectStream) ([]byte, error) {
+	var content bytes.Buffer
+	for i, stream := range streams {
+		data, err := this.DecodeContentStream(stream)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.Write(data)
+	}
+
+	return content.Bytes(), nil
+}
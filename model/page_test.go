@@ -0,0 +1,368 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "../core"
+)
+
+func TestPageUserUnit(t *testing.T) {
+	// the MediaBox is inherited from the page tree
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /UserUnit 2.0 >>",
+		"<< /Type /Page /Parent 2 0 R >>")
+	reader := openPdf(t, pdf)
+
+	testcases := []struct {
+		userUnit, width, height float64
+	}{
+		{2, 1224, 1584},
+		{1, 612, 792},
+	}
+	for i, tc := range testcases {
+		userUnit, err := reader.GetPageUserUnit(i)
+		if err != nil || userUnit != tc.userUnit {
+			t.Errorf("page %d: UserUnit %v, err: %v", i, userUnit, err)
+		}
+		width, height, err := reader.GetPageSize(i)
+		if err != nil || width != tc.width || height != tc.height {
+			t.Errorf("page %d: size %v x %v, err: %v", i, width, height, err)
+		}
+	}
+}
+
+func TestSharedContentStream(t *testing.T) {
+	data := "BT /F1 12 Tf 72 712 Td (Shared) Tj ET"
+	encoded, err := NewFlateEncoder().EncodeBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("EncodeBytes: %v", err)
+	}
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [5 0 R] >>",
+		makeStream("/Filter /FlateDecode", string(encoded)))
+	reader := openPdf(t, pdf)
+
+	decoded := [][]byte{}
+	for i := 0; i < 2; i++ {
+		streams, err := reader.GetPageContentStreams(i)
+		if err != nil || len(streams) != 1 {
+			t.Fatalf("page %d: %d streams, err: %v", i, len(streams), err)
+		}
+		content, err := reader.DecodeContentStream(streams[0])
+		if err != nil {
+			t.Fatalf("page %d: DecodeContentStream: %v", i, err)
+		}
+		if string(content) != data {
+			t.Errorf("page %d: content %q", i, content)
+		}
+		decoded = append(decoded, content)
+	}
+
+	// decoded once
+	if &decoded[0][0] != &decoded[1][0] {
+		t.Errorf("shared stream decoded twice")
+	}
+}
+
+func TestGetPageContent(t *testing.T) {
+	first := "BT /F1 12 Tf 72 712 Td (First) Tj"
+	second := "(Second) Tj ET"
+	encoded, err := NewFlateEncoder().EncodeBytes([]byte(first))
+	if err != nil {
+		t.Fatalf("EncodeBytes: %v", err)
+	}
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [5 0 R 6 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		makeStream("/Filter /FlateDecode", string(encoded)),
+		makeStream("", second))
+	reader := openPdf(t, pdf)
+
+	// the streams of an array are separated by a newline
+	for i, expected := range []string{first + "\n" + second, second} {
+		content, err := reader.GetPageContent(i)
+		if err != nil {
+			t.Fatalf("page %d: GetPageContent: %v", i, err)
+		}
+		if string(content) != expected {
+			t.Errorf("page %d: content %q, expected %q", i, content, expected)
+		}
+	}
+}
+
+func TestTruncatedPageContent(t *testing.T) {
+	// Flate data flushed but not closed, ending before the final block
+	text := "BT /F1 12 Tf 72 712 Td (Truncated) Tj ET"
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(text))
+	w.Flush()
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		makeStream("/Filter /FlateDecode", buf.String()))
+	reader := openPdf(t, pdf)
+	logger := &captureLogger{}
+	reader.SetLogger(logger)
+
+	// the content decoded so far is used, the soft error logged
+	content, err := reader.GetPageContent(0)
+	if err != nil || string(content) != text {
+		t.Errorf("content %q, err: %v", content, err)
+	}
+	if !strings.Contains(strings.Join(logger.messages, "\n"), ErrTruncatedStream.Error()) {
+		t.Errorf("messages %q", logger.messages)
+	}
+}
+
+func TestContentsNonStreamEntries(t *testing.T) {
+	// a text object spanning streams 10 and 11, around a null, a dictionary and a missing object
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [10 0 R null 4 0 R 12 0 R 11 0 R] >>",
+		"<< /Type /Foo >>", "null", "null", "null", "null", "null",
+		makeStream("", "BT /F1 12 Tf 72 712 Td (Hello"),
+		makeStream("", " world) Tj ET"))
+	reader := openPdf(t, pdf)
+
+	streams, err := reader.GetPageContentStreams(0)
+	if err != nil {
+		t.Fatalf("GetPageContentStreams: %v", err)
+	}
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams", len(streams))
+	}
+	content, err := reader.DecodeContentStreams(streams)
+	if err != nil || string(content) != "BT /F1 12 Tf 72 712 Td (Hello\n world) Tj ET" {
+		t.Errorf("content %q, err: %v", content, err)
+	}
+}
+
+func TestPageRotate(t *testing.T) {
+	// the last page inherits the Rotate of the page tree
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R] /Count 4 /MediaBox [0 0 612 792] /Rotate 450 >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate -90 >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 45 >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 190.5 >>",
+		"<< /Type /Page /Parent 2 0 R >>")
+	reader := openPdf(t, pdf)
+
+	testcases := []struct {
+		rotate  int
+		warning bool
+	}{
+		{270, false},
+		{0, true},
+		{180, true},
+		{90, false},
+	}
+	for i, tc := range testcases {
+		logger := &captureLogger{}
+		reader.SetLogger(logger)
+		rotate, err := reader.GetPageRotate(i)
+		if err != nil || rotate != tc.rotate {
+			t.Errorf("page %d: Rotate %d, err: %v", i, rotate, err)
+		}
+		if warned := len(logger.messages) > 0; warned != tc.warning {
+			t.Errorf("page %d: warnings %q", i, logger.messages)
+		}
+	}
+}
+
+func TestPages(t *testing.T) {
+	// the Kids order differs from the object order, the MediaBox and Rotate are inherited
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R 3 0 R] /Count 3 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [6 0 R 4 0 R] /Count 2 /Rotate 90 >>",
+		"<< /Type /Page /Parent 3 0 R /T (third) >>",
+		"<< /Type /Page /Parent 2 0 R /T (first) /MediaBox [0 0 100 100] >>",
+		"<< /Type /Page /Parent 3 0 R /T (second) /Rotate 180 >>")
+	pages := openPdf(t, pdf).Pages()
+
+	expected := []struct {
+		title, mediaBox string
+		rotate          int64
+	}{
+		{"first", "[0, 0, 100, 100]", 0},
+		{"second", "[0, 0, 612, 792]", 180},
+		{"third", "[0, 0, 612, 792]", 90},
+	}
+	if len(pages) != len(expected) {
+		t.Fatalf("got %d pages", len(pages))
+	}
+	for i, tc := range expected {
+		page := pages[i]
+		if title, ok := page.Get("T").(*PdfObjectString); !ok || string(*title) != tc.title {
+			t.Errorf("page %d: /T %v, expected %s", i, page.Get("T"), tc.title)
+		}
+		if box := page.Get("MediaBox"); box == nil || box.String() != tc.mediaBox {
+			t.Errorf("page %d: /MediaBox %v", i, box)
+		}
+		rotate, _ := page.Get("Rotate").(*PdfObjectInteger)
+		if (rotate == nil && tc.rotate != 0) || (rotate != nil && int64(*rotate) != tc.rotate) {
+			t.Errorf("page %d: /Rotate %v", i, page.Get("Rotate"))
+		}
+	}
+}
+
+func TestWrongIntermediateCount(t *testing.T) {
+	// two Pages nodes of two pages each, the first declaring 5
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 7 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [5 0 R 6 0 R] /Count 5 >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [7 0 R 8 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 3 0 R /T (1) >>",
+		"<< /Type /Page /Parent 3 0 R /T (2) >>",
+		"<< /Type /Page /Parent 4 0 R /T (3) >>",
+		"<< /Type /Page /Parent 4 0 R /T (4) >>")
+	logger := &captureLogger{}
+	reader, err := NewPdfReaderWithLogger(bytes.NewReader(pdf), logger)
+	if err != nil {
+		t.Fatalf("NewPdfReaderWithLogger: %v", err)
+	}
+
+	if count := reader.GetPageCount(); count != 4 {
+		t.Errorf("%d pages", count)
+	}
+	for i, page := range reader.Pages() {
+		if title, ok := page.Get("T").(*PdfObjectString); !ok || string(*title) != fmt.Sprint(i+1) {
+			t.Errorf("page %d: /T %v", i, page.Get("T"))
+		}
+	}
+	// the root and the first node
+	warnings := 0
+	for _, message := range logger.messages {
+		if strings.Contains(message, "/Count") {
+			warnings++
+		}
+	}
+	if warnings != 2 {
+		t.Errorf("messages %q", logger.messages)
+	}
+
+	// the lookup by count of a lazy reader finds the pages of the second node
+	lazy, err := NewPdfReaderLazy(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("NewPdfReaderLazy: %v", err)
+	}
+	lazy.GetPageList()
+	if page, err := lazy.GetPage(2); err != nil || page.ObjectNumber != 7 {
+		t.Errorf("page 2: %v, err: %v", page, err)
+	}
+}
+
+func TestIndirectMediaBox(t *testing.T) {
+	// the width and height of the first page are indirect, the MediaBox of the second page too
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 5 0 R 6 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox 7 0 R >>",
+		"595",
+		"842.0",
+		"[0 0 6 0 R 5 0 R]")
+	reader := openPdf(t, pdf)
+
+	for i, expected := range [][4]float64{{0, 0, 595, 842}, {0, 0, 842, 595}} {
+		if box, err := reader.GetPageMediaBox(i); err != nil || box != expected {
+			t.Errorf("page %d: MediaBox %v, err: %v", i, box, err)
+		}
+		width, height, err := reader.GetPageSize(i)
+		if err != nil || width != expected[2] || height != expected[3] {
+			t.Errorf("page %d: size %v x %v, err: %v", i, width, height, err)
+		}
+	}
+}
+
+func TestLazyReader(t *testing.T) {
+	// 1000 pages in 10 nodes of 100, the pages from object 13 on sharing the content of object 1013
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R 6 0 R " +
+		"7 0 R 8 0 R 9 0 R 10 0 R 11 0 R 12 0 R] /Count 1000 /MediaBox [0 0 612 792] >>"}
+	for node := 0; node < 10; node++ {
+		kids := ""
+		for i := 0; i < 100; i++ {
+			kids += fmt.Sprintf("%d 0 R ", 13+100*node+i)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Type /Pages /Parent 2 0 R /Kids [%s] /Count 100 >>", kids))
+	}
+	for node := 0; node < 10; node++ {
+		for i := 0; i < 100; i++ {
+			objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents 1013 0 R >>", 3+node))
+		}
+	}
+	objects = append(objects, makeStream("", "BT /F1 12 Tf (Hello) Tj ET"))
+
+	reader, err := NewPdfReaderLazy(bytes.NewReader(makePdf("", objects...)))
+	if err != nil {
+		t.Fatalf("NewPdfReaderLazy: %v", err)
+	}
+	if count := reader.GetPageCount(); count != 1000 {
+		t.Errorf("%d pages", count)
+	}
+	if content, err := reader.GetPageContent(0); err != nil || string(content) != "BT /F1 12 Tf (Hello) Tj ET" {
+		t.Errorf("content %q, err: %v", content, err)
+	}
+	// the nodes on the path to the page and their kids, not the pages of the other nodes
+	if cached := len(reader.parser.ObjCache); cached > 120 {
+		t.Errorf("%d objects resolved", cached)
+	}
+	for objNum := 113; objNum < 1013; objNum++ {
+		if _, ok := reader.parser.ObjCache[objNum]; ok {
+			t.Fatalf("object %d resolved", objNum)
+		}
+	}
+
+	for _, pageIndex := range []int{999, 437} {
+		page, err := reader.GetPage(pageIndex)
+		if err != nil || page.ObjectNumber != int64(13+pageIndex) {
+			t.Errorf("page %d: %v, err: %v", pageIndex, page, err)
+		}
+	}
+}
+
+func TestLazyWrongIntermediateCount(t *testing.T) {
+	// the first of two Pages nodes of two pages each declaring 1 or 3 pages, the root the 4 pages
+	for _, count := range []int{1, 3} {
+		pdf := makePdf("",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 4 /MediaBox [0 0 612 792] >>",
+			fmt.Sprintf("<< /Type /Pages /Parent 2 0 R /Kids [5 0 R 6 0 R] /Count %d >>", count),
+			"<< /Type /Pages /Parent 2 0 R /Kids [7 0 R 8 0 R] /Count 2 >>",
+			"<< /Type /Page /Parent 3 0 R >>",
+			"<< /Type /Page /Parent 3 0 R >>",
+			"<< /Type /Page /Parent 4 0 R >>",
+			"<< /Type /Page /Parent 4 0 R >>")
+		for _, pageIndex := range []int{1, 2, 0, 3} {
+			reader, err := NewPdfReaderLazy(bytes.NewReader(pdf))
+			if err != nil {
+				t.Fatalf("NewPdfReaderLazy: %v", err)
+			}
+			page, err := reader.GetPage(pageIndex)
+			if err != nil || page.ObjectNumber != int64(5+pageIndex) {
+				t.Errorf("/Count %d, page %d: %v, err: %v", count, pageIndex, page, err)
+			}
+		}
+	}
+}
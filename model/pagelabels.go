@@ -0,0 +1,149 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	. "../core"
+)
+
+// pageLabelRange is a page label dictionary, applying from its first page index to the next range.
+type pageLabelRange struct {
+	start  int    // first page index
+	style  string // D, R, r, A or a, none if empty
+	prefix string
+	first  int // value of the numeric portion of the first page label
+}
+
+// GetPageLabels returns the label of each page, in page order, from the /PageLabels number tree of the
+// catalog.  The label is the prefix /P followed by the page number in the style /S (D decimal, R and r
+// upper and lower case roman numerals, A and a upper and lower case letters), counting from /St.
+// Without page labels, pages are labeled by their page number from 1.
+func (this *PdfReader) GetPageLabels() ([]string, error) {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return nil, errors.New("catalog missing")
+	}
+
+	ranges := []pageLabelRange{}
+	this.walkTree(rootDict.Get("PageLabels"), "Nums", func(keyObj PdfObject, value PdfObject) {
+		start, ok := keyObj.(*PdfObjectInteger)
+		if !ok || *start < 0 {
+			this.log().Debug("Invalid page label key: %s", keyObj)
+			return
+		}
+		labelObj, err := this.parser.Trace(value)
+		if err != nil {
+			return
+		}
+		labelDict, ok := labelObj.(*PdfObjectDictionary)
+		if !ok {
+			this.log().Debug("Invalid page label: %s", labelObj)
+			return
+		}
+
+		r := pageLabelRange{start: int(*start), first: 1}
+		if obj, err := this.parser.Trace(labelDict.Get("S")); err == nil {
+			if style, ok := obj.(*PdfObjectName); ok {
+				r.style = string(*style)
+			}
+		}
+		if obj, err := this.parser.Trace(labelDict.Get("P")); err == nil {
+			if prefix, ok := obj.(*PdfObjectString); ok {
+				r.prefix = decodeTextString(string(*prefix))
+			}
+		}
+		if obj, err := this.parser.Trace(labelDict.Get("St")); err == nil {
+			if first, ok := obj.(*PdfObjectInteger); ok && *first >= 1 {
+				r.first = int(*first)
+			}
+		}
+		ranges = append(ranges, r)
+	})
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	this.loadPageList()
+	labels := make([]string, len(this.pageList))
+	next := 0
+	for i := range labels {
+		for next < len(ranges) && ranges[next].start <= i {
+			next++
+		}
+		if next == 0 {
+			// before the first range, should not happen as the first range starts at page 0
+			labels[i] = strconv.Itoa(i + 1)
+			continue
+		}
+		r := ranges[next-1]
+		labels[i] = r.prefix + formatPageNumber(r.first+i-r.start, r.style)
+	}
+
+	return labels, nil
+}
+
+// formatPageNumber returns the number in the page label numbering style, empty for no style.
+func formatPageNumber(number int, style string) string {
+	switch style {
+	case "D":
+		return strconv.Itoa(number)
+	case "R":
+		return strings.ToUpper(romanNumeral(number))
+	case "r":
+		return romanNumeral(number)
+	case "A":
+		return strings.ToUpper(letterNumeral(number))
+	case "a":
+		return letterNumeral(number)
+	}
+	return ""
+}
+
+// romanNumeral returns the lower case roman numeral of the number.
+func romanNumeral(number int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	numerals := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+
+	s := ""
+	for i, value := range values {
+		for number >= value {
+			s += numerals[i]
+			number -= value
+		}
+	}
+	return s
+}
+
+// letterNumeral returns a to z for 1 to 26, then aa to zz for 27 to 52, and so on.
+func letterNumeral(number int) string {
+	if number < 1 {
+		return ""
+	}
+	letter := string(rune('a' + (number-1)%26))
+	return strings.Repeat(letter, (number-1)/26+1)
+}
+
+// decodeTextString decodes a PDF text string, UTF-16BE with a byte order mark or PDFDocEncoding (taken
+// as Latin-1).
+func decodeTextString(s string) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := []uint16{}
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
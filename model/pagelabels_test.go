@@ -0,0 +1,32 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPageLabels(t *testing.T) {
+	// front matter i-iii, body 1-3 and an appendix, the number tree having an intermediate node
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /PageLabels << /Kids [3 0 R] >> >>",
+		"<< /Type /Pages /Kids [4 0 R 5 0 R 6 0 R 7 0 R 8 0 R 9 0 R 10 0 R] /Count 7 /MediaBox [0 0 612 792] >>",
+		"<< /Limits [0 6] /Nums [0 << /S /r >> 3 << /S /D >> 6 << /S /A /P (App-) /St 27 >>] >>",
+	}
+	for i := 0; i < 7; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R >>")
+	}
+	labels, err := openPdf(t, makePdf("", objects...)).GetPageLabels()
+	if err != nil {
+		t.Fatalf("GetPageLabels: %v", err)
+	}
+
+	expected := []string{"i", "ii", "iii", "1", "2", "3", "App-AA"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("got %q, expected %q", labels, expected)
+	}
+}
@@ -0,0 +1,392 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"../common"
+	. "../core"
+)
+
+// PostScript calculator programs running deeper than this stack size are invalid.
+const maxPSStackSize = 100
+
+// psValue is a value on the stack of a PostScript calculator program.
+type psValue struct {
+	num       float64
+	isInt     bool
+	isBoolean bool
+}
+
+func (v psValue) boolean() bool {
+	return v.num != 0
+}
+
+func psReal(num float64) psValue {
+	return psValue{num: num}
+}
+
+func psInt(num int64) psValue {
+	return psValue{num: float64(num), isInt: true}
+}
+
+func psBool(b bool) psValue {
+	if b {
+		return psValue{num: 1, isBoolean: true}
+	}
+	return psValue{isBoolean: true}
+}
+
+// psInstruction is a number or an operator, if and ifelse with their procedures.
+type psInstruction struct {
+	operator   string
+	value      psValue
+	procedures [][]psInstruction
+}
+
+// postScriptFunction is a Type 4 function, a program in a subset of PostScript.
+type postScriptFunction struct {
+	domain, rangeArr []float64
+	program          []psInstruction
+	log              common.Logger
+}
+
+func (this *PdfReader) loadPostScriptFunction(stream *PdfObjectStream, domain, rangeArr []float64) (Function, error) {
+	if len(rangeArr) == 0 {
+		return nil, errors.New("PostScript calculator function without Range")
+	}
+
+	data, err := DecodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	tokens := strings.Fields(strings.NewReplacer("{", " { ", "}", " } ").Replace(string(data)))
+	if len(tokens) < 2 || tokens[0] != "{" {
+		return nil, errors.New("PostScript calculator function not a procedure")
+	}
+	program, rest, err := parsePSProcedure(tokens[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		this.log().Debug("Warning: ignoring tokens after the PostScript calculator function: %v", rest)
+	}
+
+	return &postScriptFunction{domain, rangeArr, program, this.log()}, nil
+}
+
+// parsePSProcedure parses the tokens of a procedure, following its opening brace, and returns its
+// instructions and the tokens after its closing brace.
+func parsePSProcedure(tokens []string) ([]psInstruction, []string, error) {
+	program := []psInstruction{}
+	procedures := [][]psInstruction{}
+
+	for len(tokens) > 0 {
+		token := tokens[0]
+		tokens = tokens[1:]
+
+		switch token {
+		case "{":
+			procedure, rest, err := parsePSProcedure(tokens)
+			if err != nil {
+				return nil, nil, err
+			}
+			procedures = append(procedures, procedure)
+			tokens = rest
+			continue
+		case "}":
+			if len(procedures) > 0 {
+				return nil, nil, errors.New("PostScript procedure not followed by if or ifelse")
+			}
+			return program, tokens, nil
+		case "if", "ifelse":
+			count := 1
+			if token == "ifelse" {
+				count = 2
+			}
+			if len(procedures) != count {
+				return nil, nil, errors.New("PostScript " + token + " without its procedures")
+			}
+			program = append(program, psInstruction{operator: token, procedures: procedures})
+			procedures = [][]psInstruction{}
+			continue
+		}
+
+		if len(procedures) > 0 {
+			return nil, nil, errors.New("PostScript procedure not followed by if or ifelse")
+		}
+		if i, err := strconv.ParseInt(token, 10, 64); err == nil {
+			program = append(program, psInstruction{value: psInt(i)})
+		} else if f, err := strconv.ParseFloat(token, 64); err == nil {
+			program = append(program, psInstruction{value: psReal(f)})
+		} else {
+			program = append(program, psInstruction{operator: token})
+		}
+	}
+
+	return nil, nil, errors.New("PostScript procedure not closed")
+}
+
+func (f *postScriptFunction) Eval(inputs []float64) []float64 {
+	n := len(f.rangeArr) / 2
+
+	stack := []psValue{}
+	for _, x := range clipInputs(inputs, f.domain) {
+		stack = append(stack, psReal(x))
+	}
+
+	stack, err := execPS(f.program, stack)
+	if err == nil && len(stack) < n {
+		err = errors.New("stack underflow")
+	}
+	if err != nil {
+		f.log.Debug("Error: PostScript calculator function failed, err: %v", err)
+		return clipOutputs(make([]float64, n), f.rangeArr)
+	}
+
+	outputs := make([]float64, n)
+	for j := range outputs {
+		outputs[j] = stack[len(stack)-n+j].num
+	}
+	return clipOutputs(outputs, f.rangeArr)
+}
+
+// execPS runs the program on the stack and returns the resulting stack.
+func execPS(program []psInstruction, stack []psValue) ([]psValue, error) {
+	pop := func(count int) ([]psValue, error) {
+		if len(stack) < count {
+			return nil, errors.New("stack underflow")
+		}
+		values := stack[len(stack)-count:]
+		stack = stack[:len(stack)-count]
+		return values, nil
+	}
+
+	for _, instruction := range program {
+		if instruction.operator == "" {
+			stack = append(stack, instruction.value)
+			if len(stack) > maxPSStackSize {
+				return nil, errors.New("stack overflow")
+			}
+			continue
+		}
+
+		var err error
+		switch instruction.operator {
+		case "if", "ifelse":
+			var cond []psValue
+			cond, err = pop(1)
+			if err != nil {
+				return nil, err
+			}
+			if cond[0].boolean() {
+				stack, err = execPS(instruction.procedures[0], stack)
+			} else if len(instruction.procedures) == 2 {
+				stack, err = execPS(instruction.procedures[1], stack)
+			}
+		case "dup":
+			var v []psValue
+			if v, err = pop(1); err == nil {
+				stack = append(stack, v[0], v[0])
+			}
+		case "pop":
+			_, err = pop(1)
+		case "exch":
+			var v []psValue
+			if v, err = pop(2); err == nil {
+				stack = append(stack, v[1], v[0])
+			}
+		case "copy":
+			var v []psValue
+			if v, err = pop(1); err == nil {
+				count := int(v[0].num)
+				if count < 0 || count > len(stack) {
+					return nil, errors.New("invalid copy count")
+				}
+				stack = append(stack, stack[len(stack)-count:]...)
+			}
+		case "index":
+			var v []psValue
+			if v, err = pop(1); err == nil {
+				index := int(v[0].num)
+				if index < 0 || index >= len(stack) {
+					return nil, errors.New("invalid index")
+				}
+				stack = append(stack, stack[len(stack)-1-index])
+			}
+		case "roll":
+			var v []psValue
+			if v, err = pop(2); err == nil {
+				count, shift := int(v[0].num), int(v[1].num)
+				if count < 0 || count > len(stack) {
+					return nil, errors.New("invalid roll count")
+				}
+				if count > 0 {
+					rolled := stack[len(stack)-count:]
+					shift = ((shift % count) + count) % count
+					copied := append([]psValue{}, rolled...)
+					for i := range rolled {
+						rolled[(i+shift)%count] = copied[i]
+					}
+				}
+			}
+		case "true", "false":
+			stack = append(stack, psBool(instruction.operator == "true"))
+		default:
+			stack, err = execPSOperator(instruction.operator, stack)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(stack) > maxPSStackSize {
+			return nil, errors.New("stack overflow")
+		}
+	}
+
+	return stack, nil
+}
+
+// execPSOperator applies an arithmetic, relational, boolean or bitwise operator to the stack.
+func execPSOperator(operator string, stack []psValue) ([]psValue, error) {
+	arity := 2
+	switch operator {
+	case "abs", "ceiling", "cos", "cvi", "cvr", "exp", "floor", "ln", "log", "neg", "not", "round",
+		"sin", "sqrt", "truncate":
+		arity = 1
+	case "add", "and", "atan", "bitshift", "div", "eq", "ge", "gt", "idiv", "le", "lt", "mod", "mul",
+		"ne", "or", "sub", "xor":
+	default:
+		return nil, errors.New("unsupported PostScript operator " + operator)
+	}
+	if len(stack) < arity {
+		return nil, errors.New("stack underflow")
+	}
+	args := stack[len(stack)-arity:]
+	stack = stack[:len(stack)-arity]
+
+	a := args[0]
+	b := a
+	if arity == 2 {
+		b = args[1]
+	}
+	bothInt := a.isInt && b.isInt
+	integer := func(num float64) psValue {
+		if bothInt {
+			return psInt(int64(num))
+		}
+		return psReal(num)
+	}
+
+	var result psValue
+	switch operator {
+	case "abs":
+		result = integer(math.Abs(a.num))
+	case "neg":
+		result = integer(-a.num)
+	case "add":
+		result = integer(a.num + b.num)
+	case "sub":
+		result = integer(a.num - b.num)
+	case "mul":
+		result = integer(a.num * b.num)
+	case "div":
+		if b.num == 0 {
+			return nil, errors.New("division by zero")
+		}
+		result = psReal(a.num / b.num)
+	case "idiv", "mod":
+		if int64(b.num) == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if operator == "idiv" {
+			result = psInt(int64(a.num) / int64(b.num))
+		} else {
+			result = psInt(int64(a.num) % int64(b.num))
+		}
+	case "ceiling":
+		result = integer(math.Ceil(a.num))
+	case "floor":
+		result = integer(math.Floor(a.num))
+	case "round":
+		result = integer(math.Floor(a.num + 0.5))
+	case "truncate":
+		result = integer(math.Trunc(a.num))
+	case "cvi":
+		result = psInt(int64(a.num))
+	case "cvr":
+		result = psReal(a.num)
+	case "sqrt":
+		result = psReal(math.Sqrt(a.num))
+	case "sin":
+		result = psReal(math.Sin(a.num * math.Pi / 180))
+	case "cos":
+		result = psReal(math.Cos(a.num * math.Pi / 180))
+	case "atan":
+		angle := math.Atan2(a.num, b.num) * 180 / math.Pi
+		if angle < 0 {
+			angle += 360
+		}
+		result = psReal(angle)
+	case "exp":
+		result = psReal(math.Pow(a.num, b.num))
+	case "ln":
+		result = psReal(math.Log(a.num))
+	case "log":
+		result = psReal(math.Log10(a.num))
+	case "eq":
+		result = psBool(a.num == b.num)
+	case "ne":
+		result = psBool(a.num != b.num)
+	case "gt":
+		result = psBool(a.num > b.num)
+	case "ge":
+		result = psBool(a.num >= b.num)
+	case "lt":
+		result = psBool(a.num < b.num)
+	case "le":
+		result = psBool(a.num <= b.num)
+	case "not":
+		if a.isBoolean {
+			result = psBool(!a.boolean())
+		} else {
+			result = psInt(^int64(a.num))
+		}
+	case "and", "or", "xor":
+		if a.isBoolean && b.isBoolean {
+			switch operator {
+			case "and":
+				result = psBool(a.boolean() && b.boolean())
+			case "or":
+				result = psBool(a.boolean() || b.boolean())
+			default:
+				result = psBool(a.boolean() != b.boolean())
+			}
+		} else {
+			x, y := int64(a.num), int64(b.num)
+			switch operator {
+			case "and":
+				result = psInt(x & y)
+			case "or":
+				result = psInt(x | y)
+			default:
+				result = psInt(x ^ y)
+			}
+		}
+	case "bitshift":
+		x, shift := int64(a.num), int64(b.num)
+		if shift >= 0 {
+			result = psInt(x << uint(shift))
+		} else {
+			result = psInt(x >> uint(-shift))
+		}
+	}
+
+	return append(stack, result), nil
+}
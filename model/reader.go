@@ -9,11 +9,18 @@ import (
 	"../cmap"
 	"../common"
 	. "../core"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 const (
@@ -42,6 +49,11 @@ type FontMetrics struct {
 	mVscale       float64
 	mHscale       float64
 	mFontMatrix   [6]float64
+	// horizontal widths of CIDFonts by cid, from W, mMissingWidth holds DW
+	mCidWidths map[uint]uint
+	// vertical metrics of CIDFonts, DW2 holds the default [v_y w1_y] and W2 the [w1_y v_x v_y] by cid
+	mVerticalDefault [2]float64
+	mVerticalMetrics map[uint][3]float64
 }
 
 type Font struct {
@@ -53,6 +65,8 @@ type Font struct {
 
 	mCmap      *cmap.CMap
 	mToCidCmap *cmap.CMap
+	// the ToUnicode cmap, also mCmap unless the font has a predefined cmap
+	mToUnicodeCmap *cmap.CMap
 
 	mSimpleEncodingTable    []uint
 	mOwnSimpleEncodingTable bool
@@ -66,6 +80,9 @@ type Font struct {
 
 	mCidBegin *byte
 	mCidLen   uint
+
+	// glyph descriptions of Type3 fonts by character code
+	mType3CharProcs map[byte]*PdfObjectStream
 }
 
 func (font *Font) GetCmap() *cmap.CMap {
@@ -76,6 +93,12 @@ func (font *Font) GetCidCmap() *cmap.CMap {
 	return font.mToCidCmap
 }
 
+// GetToUnicodeCmap returns the ToUnicode cmap of the font, nil if it has none.  GetCmap returns the cid to
+// unicode cmap instead for fonts with a predefined cmap.
+func (font *Font) GetToUnicodeCmap() *cmap.CMap {
+	return font.mToUnicodeCmap
+}
+
 func (font *Font) GetSimpleEncodingTableFlag() bool {
 	return font.mPredefinedSimpleEncoding
 }
@@ -88,7 +111,25 @@ func (font *Font) GetSimpleEncodingTable() []uint {
 	return font.mSimpleEncodingTable
 }
 
-func (font *Font) loadFontDescriptor() {
+// IsMultibyte returns true for Type0 fonts, whose character codes are multibyte.
+func (font *Font) IsMultibyte() bool {
+	return font.mMultibyte
+}
+
+func (font *Font) GetFontType() string {
+	return font.mFontType
+}
+
+func (font *Font) GetFontMatrix() [6]float64 {
+	return font.mFontMetrics.mFontMatrix
+}
+
+// GetType3CharProc returns the glyph description stream of the character code in a Type3 font, nil if none.
+func (font *Font) GetType3CharProc(code byte) *PdfObjectStream {
+	return font.mType3CharProcs[code]
+}
+
+func (font *Font) loadFontDescriptor(log common.Logger) {
 	if font.mFontDescriptor != nil {
 		font.mFontMetrics.mFontName = "unkown"
 		if mFontName, ok := font.mFontDescriptor.Get("FontName").(*PdfObjectName); ok {
@@ -136,13 +177,13 @@ func (font *Font) loadFontDescriptor() {
 		}
 
 		if mFontBboxArr, ok := font.mFontDescriptor.Get("FontBBox").(*PdfObjectArray); ok {
-			common.Log.Trace("fontbbox size: %d", len(*mFontBboxArr))
+			log.Trace("fontbbox size: %d", len(*mFontBboxArr))
 			for i := 0; i < len(*mFontBboxArr); i++ {
 				font.mFontMetrics.mFontBbox[i], _ = GetNumberAsFloat((*mFontBboxArr)[i])
 			}
 		} else {
 			if mFontBboxArr, ok := font.mFontDictionary.Get("FontBBox").(*PdfObjectArray); ok {
-				common.Log.Trace("fontbbox size: %d", len(*mFontBboxArr))
+				log.Trace("fontbbox size: %d", len(*mFontBboxArr))
 				for i := 0; i < len(*mFontBboxArr); i++ {
 					font.mFontMetrics.mFontBbox[i], _ = GetNumberAsFloat((*mFontBboxArr)[i])
 				}
@@ -151,13 +192,25 @@ func (font *Font) loadFontDescriptor() {
 	}
 }
 
+// Directory of the predefined cmap files.
+var cmapResourceDir = "resources/"
+
+// SetCMapResourceDir sets the directory the predefined cmap files are loaded from, "resources/" by default,
+// relative to the working directory.  Set it before parsing the fonts.
+func SetCMapResourceDir(path string) {
+	cmapResourceDir = path
+}
+
 func (this *PdfReader) parsePredefinedCMap(font *Font, unicodeName string) error {
+	if filepath.Base(font.mFontEncoding) != font.mFontEncoding {
+		return errors.New("invalid predefined cmap name")
+	}
 
 	//get charcode to cid map
-	cmapToCidFilename := "resources/" + font.mFontEncoding
+	cmapToCidFilename := filepath.Join(cmapResourceDir, font.mFontEncoding)
 	streamData, err := ioutil.ReadFile(cmapToCidFilename)
 	if err != nil {
-		common.Log.Debug("read file %s failed, %s", cmapToCidFilename, err)
+		this.log().Debug("read file %s failed, %s", cmapToCidFilename, err)
 		return err
 	}
 
@@ -165,35 +218,51 @@ func (this *PdfReader) parsePredefinedCMap(font *Font, unicodeName string) error
 
 	mCmap, err := cmap.LoadCmapFromData(streamData)
 	if err != nil {
-		common.Log.Debug("load charcode_to_cid cmap from %s failed, err: %s", cmapToCidFilename, err)
+		this.log().Debug("load charcode_to_cid cmap from %s failed, err: %s", cmapToCidFilename, err)
 		return err
 	}
 	font.mToCidCmap = mCmap
 
+	return this.parseCidToUnicodeCMap(font, unicodeName)
+}
+
+// parseCidToUnicodeCMap loads the predefined cid to unicode cmap of font.mToCidCmap.  If unicodeName is
+// empty, the name is derived from the CIDSystemInfo of font.mToCidCmap, e.g. Adobe-GB1-UCS2.
+func (this *PdfReader) parseCidToUnicodeCMap(font *Font, unicodeName string) error {
+	if unicodeName == "" {
+		if font.mToCidCmap == nil || font.mToCidCmap.Registry() == "" || font.mToCidCmap.Ordering() == "" {
+			return errors.New("cmap has no CIDSystemInfo")
+		}
+		unicodeName = font.mToCidCmap.Registry() + "-" + font.mToCidCmap.Ordering() + "-UCS2"
+	}
+	if filepath.Base(unicodeName) != unicodeName {
+		return errors.New("invalid predefined cmap name")
+	}
+
 	//get cid to unicode map
-	cidToUnicodeFilename := "resources/" + unicodeName
-	streamData, err = ioutil.ReadFile(cidToUnicodeFilename)
+	cidToUnicodeFilename := filepath.Join(cmapResourceDir, unicodeName)
+	streamData, err := ioutil.ReadFile(cidToUnicodeFilename)
 	if err != nil {
-		common.Log.Debug("read file %s failed, %s", cidToUnicodeFilename, err)
+		this.log().Debug("read file %s failed, %s", cidToUnicodeFilename, err)
 		return err
 	}
 
 	//common.Log.Debug("cid_to_unicode data: %s\n\n", streamData)
 
-	mCmap, err = cmap.LoadCmapFromData(streamData)
+	mCmap, err := cmap.LoadCmapFromData(streamData)
 	if err != nil {
-		common.Log.Debug("load cid_to_unicode cmap from %s failed, err: %s", cidToUnicodeFilename, err)
+		this.log().Debug("load cid_to_unicode cmap from %s failed, err: %s", cidToUnicodeFilename, err)
 		return err
 	}
 	font.mCmap = mCmap
 
 	/*
 		    for k, v := range font.mToCidCmap.GetCodeMap() {
-				common.Log.Debug("chartocid, %d: %s", k, v)
+				this.log().Debug("chartocid, %d: %s", k, v)
 			}
 
 			for k, v := range font.mCmap.GetCodeMap() {
-				common.Log.Debug("cidtounicode, %d, %s", k, v)
+				this.log().Debug("cidtounicode, %d, %s", k, v)
 			}
 	*/
 
@@ -206,7 +275,7 @@ func (this *PdfReader) getFontEncoding(font *Font) error {
 	if toUnicodeObj := font.mFontDictionary.Get("ToUnicode"); toUnicodeObj != nil {
 		obj, err := this.parser.Trace(toUnicodeObj)
 		if err != nil {
-			common.Log.Debug("Error: trace to object stream failed, err: %s", err)
+			this.log().Debug("Error: trace to object stream failed, err: %s", err)
 			return err
 		}
 
@@ -216,22 +285,25 @@ func (this *PdfReader) getFontEncoding(font *Font) error {
 		}
 
 		decodedData, err := DecodeStream(toUnicodeStreamObj)
-		if err != nil {
+		if errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptStream) {
+			this.log().Debug("ToUnicode cmap stream: %v", err)
+		} else if err != nil {
 			return err
 		}
 
-		common.Log.Trace("tounicode data: %s\n\n", decodedData)
+		this.log().Trace("tounicode data: %s\n\n", decodedData)
 
 		mCmap, err := cmap.LoadCmapFromData(decodedData)
 		if err != nil {
-			common.Log.Debug("load cmap failed, err: %s", err)
+			this.log().Debug("load cmap failed, err: %s", err)
 			return err
 		}
 		font.mCmap = mCmap
+		font.mToUnicodeCmap = mCmap
 	}
 
-	//encoding maybe a predefined name string or dict
-	if encodingObject := font.mFontDictionary.Get("Encoding"); encodingObject != nil {
+	//encoding maybe a predefined name string, dict or an embedded cmap stream
+	if encodingObject, _ := this.parser.Trace(font.mFontDictionary.Get("Encoding")); encodingObject != nil {
 		encodingObjectName, ok := encodingObject.(*PdfObjectName)
 		if ok {
 			//common.Log.Debug("font encoding is encoding name: %s", *encodingObjectName)
@@ -239,15 +311,40 @@ func (this *PdfReader) getFontEncoding(font *Font) error {
 			if v, ok := mPdfPredefinedSimpleEncodings[font.mFontEncoding]; ok {
 				font.mPredefinedSimpleEncoding = true
 				font.mSimpleEncodingTable = v
-			} else {
-				if unicodeName, ok := mPdfCidToUnicode[font.mFontEncoding]; ok {
-					if err := this.parsePredefinedCMap(font, unicodeName); err == nil {
-						font.mPredefinedCmap = true
-					}
+			} else if unicodeName, ok := mPdfCidToUnicode[font.mFontEncoding]; ok {
+				if err := this.parsePredefinedCMap(font, unicodeName); err == nil {
+					font.mPredefinedCmap = true
+				}
+			} else if subtype, ok := font.mFontDictionary.Get("Subtype").(*PdfObjectName); ok && *subtype == "Type0" &&
+				font.mFontEncoding != "Identity-H" && font.mFontEncoding != "Identity-V" {
+				// other predefined cjk cmaps, the cid to unicode cmap follows from their CIDSystemInfo
+				if err := this.parsePredefinedCMap(font, ""); err == nil {
+					font.mPredefinedCmap = true
 				}
 			}
 		}
 
+		if encodingStream, ok := encodingObject.(*PdfObjectStream); ok {
+			decodedData, err := DecodeStream(encodingStream)
+			if errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptStream) {
+				this.log().Debug("Encoding cmap stream: %v", err)
+			} else if err != nil {
+				return err
+			}
+
+			mCmap, err := cmap.LoadCmapFromData(decodedData)
+			if err != nil {
+				this.log().Debug("load encoding cmap failed, err: %s", err)
+				return err
+			}
+			font.mToCidCmap = mCmap
+			// the cids are mapped through the cid to unicode cmap of the cmap CIDSystemInfo, or of the
+			// CIDFont one in getFontInfo, for the char codes a ToUnicode cmap doesn't map
+			if err := this.parseCidToUnicodeCMap(font, ""); err == nil {
+				font.mPredefinedCmap = true
+			}
+		}
+
 		encodingObjectDict, ok := encodingObject.(*PdfObjectDictionary)
 		if ok {
 			font.mPredefinedSimpleEncoding = true
@@ -267,22 +364,23 @@ func (this *PdfReader) getFontEncoding(font *Font) error {
 			}
 
 			if differenctObjArray, ok := encodingObjectDict.Get("Differences").(*PdfObjectArray); ok {
-				var replacements uint = 0
+				// Codes out of range are ignored, with the names following them up to the next code,
+				// rather than wrapping around and overwriting the low codes.
+				replacements := 0
 				for j := 0; j < len(*differenctObjArray); j++ {
 					if objNumber, ok := (*differenctObjArray)[j].(*PdfObjectInteger); ok {
-						replacements = uint(*objNumber)
-						if replacements > 255 {
-							replacements = 0
+						replacements = int(*objNumber)
+						if replacements < 0 || replacements > 255 {
+							this.log().Debug("Warning: Differences code %d out of range, ignored", replacements)
 						}
 					} else {
 						//TODO: parse obj in differences array according to CharProcs
 						if objName, ok := (*differenctObjArray)[j].(*PdfObjectName); ok {
 							if val, ok := mPdfCharacterNames[string(*objName)]; ok {
-								font.mSimpleEncodingTable[replacements] = val
-								replacements++
-								if replacements > 255 {
-									replacements = 0
+								if replacements >= 0 && replacements <= 255 {
+									font.mSimpleEncodingTable[replacements] = val
 								}
+								replacements++
 							}
 						}
 					}
@@ -308,7 +406,7 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 	if mFontDescriptor := font.mFontDictionary.Get("FontDescriptor"); mFontDescriptor != nil {
 		mFontDescriptorObj, err := this.parser.Trace(mFontDescriptor)
 		if err != nil {
-			common.Log.Debug("Error: trace font descriptor to indirect obj failed, err: %s", err)
+			this.log().Debug("Error: trace font descriptor to indirect obj failed, err: %s", err)
 			return err
 		}
 
@@ -327,11 +425,12 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 
 	if font.mFontType == "Type0" {
 		font.mMultibyte = true
-		if descendantFontsArr, ok := font.mFontDictionary.Get("DescendantFonts").(*PdfObjectArray); ok && len(*descendantFontsArr) > 0 {
+		descendantFontsObj, _ := this.parser.Trace(font.mFontDictionary.Get("DescendantFonts"))
+		if descendantFontsArr, ok := descendantFontsObj.(*PdfObjectArray); ok && len(*descendantFontsArr) > 0 {
 			//only one value is allowed
 			descendantFontObj, err := this.parser.Trace((*descendantFontsArr)[0])
 			if err != nil {
-				common.Log.Debug("Error: trace font descendantFont to direct obj failed, err: %s", err)
+				this.log().Debug("Error: trace font descendantFont to direct obj failed, err: %s", err)
 				return err
 			}
 
@@ -355,9 +454,15 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 
 					if registerOrdering == "Adobe-GB1" || registerOrdering == "Adobe-CNS1" ||
 						registerOrdering == "Adobe-Japan1" || registerOrdering == "Adobe-Korea1" {
-						font.mFontEncoding = registerOrderingSupple
 						unicodeName := registerOrdering + "-UCS2"
-						if !font.mPredefinedCmap {
+						if font.mToCidCmap != nil && !font.mPredefinedCmap {
+							// an embedded encoding cmap without CIDSystemInfo keeps its char code to cid
+							// mapping, only the cid to unicode cmap comes from the CIDFont
+							if err := this.parseCidToUnicodeCMap(font, unicodeName); err == nil {
+								font.mPredefinedCmap = true
+							}
+						} else if !font.mPredefinedCmap {
+							font.mFontEncoding = registerOrderingSupple
 							if err := this.parsePredefinedCMap(font, unicodeName); err == nil {
 								font.mPredefinedCmap = true
 							}
@@ -372,66 +477,17 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 				}
 
 				font.mFontMetrics.mMissingWidth = uint(1000)
-				if dwObj, ok := descendantFontDict.Get("DW").(*PdfObjectInteger); ok {
-					font.mFontMetrics.mMissingWidth = uint(*dwObj)
+				if dwObj, err := this.parser.Trace(descendantFontDict.Get("DW")); err == nil {
+					if dw, err := GetNumberAsFloat(dwObj); err == nil && dw >= 0 {
+						font.mFontMetrics.mMissingWidth = uint(dw)
+					}
 				}
 
-				if wObjArr, ok := descendantFontDict.Get("W").(*PdfObjectArray); ok {
-					gotValues := uint(0)
-					var firstValue, toRange uint
-					for j := 0; j < len(*wObjArr); j++ {
-						if subWidthArr, ok := (*wObjArr)[j].(*PdfObjectArray); ok && gotValues == 1 {
-							if int(firstValue) > len(font.mFontMetrics.mWidths) {
-								fillLen := int(firstValue) - len(font.mFontMetrics.mWidths)
-
-								fillSlice := make([]uint, fillLen)
-								for k := 0; k < fillLen; k++ {
-									fillSlice[k] = font.mFontMetrics.mMissingWidth
-								}
-								font.mFontMetrics.mWidths = append(font.mFontMetrics.mWidths, fillSlice...)
-
-								subWidthSlice := make([]uint, len(*subWidthArr))
-								for k := 0; k < len(*subWidthArr); k++ {
-									if numObj, ok := (*subWidthArr)[k].(*PdfObjectInteger); ok {
-										subWidthSlice[k] = uint(*numObj)
-									} else {
-										subWidthSlice[k] = 0
-									}
-								}
-								font.mFontMetrics.mWidths = append(font.mFontMetrics.mWidths, subWidthSlice...)
-							}
+				this.parseCidWidths(font, descendantFontDict)
 
-							gotValues = 0
-						} else if numInterObj, ok := (*wObjArr)[j].(*PdfObjectInteger); ok {
-							gotValues++
-							if gotValues == 1 {
-								firstValue = uint(*numInterObj)
-							} else if gotValues == 2 {
-								toRange = uint(*numInterObj)
-							} else if gotValues == 3 {
-								gotValues = 0
-								if toRange < firstValue {
-									toRange = firstValue
-								}
+				this.parseVerticalMetrics(font, descendantFontDict)
 
-								if int(toRange) >= len(font.mFontMetrics.mWidths) {
-									fillLen := int(toRange) + 1 - len(font.mFontMetrics.mWidths)
-									fillSlice := make([]uint, fillLen)
-									for k := 0; k < fillLen; k++ {
-										fillSlice[k] = font.mFontMetrics.mMissingWidth
-									}
-									font.mFontMetrics.mWidths = append(font.mFontMetrics.mWidths, fillSlice...)
-								}
-								calcValue := uint(*numInterObj)
-								for k := firstValue; k <= toRange; k++ {
-									font.mFontMetrics.mWidths[k] = calcValue
-								}
-							}
-						}
-					}
-				}
-
-				font.loadFontDescriptor()
+				font.loadFontDescriptor(this.log())
 				//warning TODO: Those fonts can be vertical. PDF parser should support that feature
 			}
 		}
@@ -447,10 +503,10 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 		}
 
 		if font.mFontDescriptor != nil {
-			font.loadFontDescriptor()
+			font.loadFontDescriptor(this.log())
 		} else {
 			if mFontBboxArr, ok := font.mFontDictionary.Get("FontBBox").(*PdfObjectArray); ok {
-				common.Log.Trace("fontbbox size: %d", len(*mFontBboxArr))
+				this.log().Trace("fontbbox size: %d", len(*mFontBboxArr))
 				for i := 0; i < len(*mFontBboxArr); i++ {
 					font.mFontMetrics.mFontBbox[i], _ = GetNumberAsFloat((*mFontBboxArr)[i])
 				}
@@ -461,52 +517,281 @@ func (this *PdfReader) getFontInfo(font *Font) error {
 		font.mFontMetrics.mDescent = font.mFontMetrics.mFontBbox[1]
 
 		if mFontMatrix, ok := font.mFontDictionary.Get("FontMatrix").(*PdfObjectArray); ok {
-			common.Log.Trace("font matrix size: %d", len(*mFontMatrix))
+			this.log().Trace("font matrix size: %d", len(*mFontMatrix))
 			if len(*mFontMatrix) == 6 {
 				for i := 0; i < 6; i++ {
 					font.mFontMetrics.mFontMatrix[i], _ = GetNumberAsFloat((*mFontMatrix)[i])
 				}
 			}
 
-			font.mFontMetrics.mVscale = font.mFontMetrics.mFontMatrix[1] + font.mFontMetrics.mFontMatrix[3]
-			font.mFontMetrics.mHscale = font.mFontMetrics.mFontMatrix[0] + font.mFontMetrics.mFontMatrix[2]
+			// the lengths of the glyph space unit vectors in text space
+			fontMatrix := font.mFontMetrics.mFontMatrix
+			font.mFontMetrics.mHscale = math.Hypot(fontMatrix[0], fontMatrix[1])
+			font.mFontMetrics.mVscale = math.Hypot(fontMatrix[2], fontMatrix[3])
 		}
+
+		this.loadType3CharProcs(font)
 	} else {
 		if fm, ok := mPdfFontMetricsMap[font.mBaseFont]; ok {
 			font.mFontMetrics = fm
 		} else {
 			font.mFontMetrics.mFirstChar = 0
-			if mFirstChar, ok := font.mFontDictionary.Get("FirstChar").(*PdfObjectInteger); ok {
-				font.mFontMetrics.mFirstChar = uint(*mFirstChar)
+			if firstCharObj, _ := this.parser.Trace(font.mFontDictionary.Get("FirstChar")); firstCharObj != nil {
+				if mFirstChar, ok := firstCharObj.(*PdfObjectInteger); ok && *mFirstChar >= 0 {
+					font.mFontMetrics.mFirstChar = uint(*mFirstChar)
+				}
 			}
 
 			font.mFontMetrics.mLastChar = 255
-			if mLastChar, ok := font.mFontDictionary.Get("LastChar").(*PdfObjectInteger); ok {
-				font.mFontMetrics.mLastChar = uint(*mLastChar)
+			if lastCharObj, _ := this.parser.Trace(font.mFontDictionary.Get("LastChar")); lastCharObj != nil {
+				if mLastChar, ok := lastCharObj.(*PdfObjectInteger); ok && *mLastChar >= 0 {
+					font.mFontMetrics.mLastChar = uint(*mLastChar)
+				}
 			}
 
 			if font.mFontMetrics.mFirstChar > font.mFontMetrics.mLastChar {
 				font.mFontMetrics.mLastChar = font.mFontMetrics.mFirstChar
 			}
 
-			if widthsArray, ok := font.mFontDictionary.Get("Widths").(*PdfObjectArray); ok {
+			widthsObj, _ := this.parser.Trace(font.mFontDictionary.Get("Widths"))
+			if widthsArray, ok := widthsObj.(*PdfObjectArray); ok {
 				widthSlice := make([]uint, len(*widthsArray))
 				for i := 0; i < len(*widthsArray); i++ {
-					if v, ok := (*widthsArray)[i].(*PdfObjectInteger); ok {
-						widthSlice[i] = uint(*v)
+					// the widths may be indirect, and some writers use reals
+					widthObj, err := this.parser.Trace((*widthsArray)[i])
+					if err != nil {
+						this.log().Debug("Error: trace Widths entry failed, err: %s", err)
+						continue
+					}
+					if v, err := GetNumberAsFloat(widthObj); err == nil && v > 0 {
+						widthSlice[i] = uint(v)
 					}
 				}
 
 				font.mFontMetrics.mWidths = append(font.mFontMetrics.mWidths, widthSlice...)
+				if count := font.mFontMetrics.mLastChar - font.mFontMetrics.mFirstChar + 1; uint(len(widthSlice)) != count {
+					this.log().Debug("Warning: font %s Widths has %d entries for FirstChar %d LastChar %d",
+						font.mBaseFont, len(widthSlice), font.mFontMetrics.mFirstChar, font.mFontMetrics.mLastChar)
+				}
 			}
 
-			font.loadFontDescriptor()
+			font.loadFontDescriptor(this.log())
 		}
 	}
 
 	return nil
 }
 
+// loadType3CharProcs maps the character codes of a Type3 font to their glyph descriptions in CharProcs,
+// by the glyph names of the Differences in the font encoding.
+func (this *PdfReader) loadType3CharProcs(font *Font) {
+	font.mType3CharProcs = map[byte]*PdfObjectStream{}
+
+	charProcsObj, err := this.parser.Trace(font.mFontDictionary.Get("CharProcs"))
+	if err != nil {
+		return
+	}
+	charProcs, ok := charProcsObj.(*PdfObjectDictionary)
+	if !ok {
+		return
+	}
+
+	encodingObj, err := this.parser.Trace(font.mFontDictionary.Get("Encoding"))
+	if err != nil {
+		return
+	}
+	encodingDict, ok := encodingObj.(*PdfObjectDictionary)
+	if !ok {
+		return
+	}
+	differencesObj, err := this.parser.Trace(encodingDict.Get("Differences"))
+	if err != nil {
+		return
+	}
+	differences, ok := differencesObj.(*PdfObjectArray)
+	if !ok {
+		return
+	}
+
+	code := 0
+	for _, obj := range *differences {
+		if num, ok := obj.(*PdfObjectInteger); ok {
+			code = int(*num)
+		} else if name, ok := obj.(*PdfObjectName); ok {
+			if code >= 0 && code < 256 {
+				if procObj, err := this.parser.Trace(charProcs.Get(*name)); err == nil {
+					if procStream, ok := procObj.(*PdfObjectStream); ok {
+						font.mType3CharProcs[byte(code)] = procStream
+					}
+				}
+			}
+			code++
+		}
+	}
+}
+
+// parseCidWidths parses the W array of a CIDFont.  The W array has the forms c [w ...] for consecutive
+// cids and c_first c_last w for a range of cids, in any order.  Later entries override earlier ones.
+func (this *PdfReader) parseCidWidths(font *Font, descendantFontDict *PdfObjectDictionary) {
+	font.mFontMetrics.mCidWidths = map[uint]uint{}
+	wObj, err := this.parser.Trace(descendantFontDict.Get("W"))
+	if err != nil {
+		return
+	}
+	wArr, ok := wObj.(*PdfObjectArray)
+	if !ok {
+		return
+	}
+
+	numbers := []float64{}
+	for j := 0; j < len(*wArr); j++ {
+		obj, err := this.parser.Trace((*wArr)[j])
+		if err != nil {
+			this.log().Debug("Error: trace W entry failed, err: %s", err)
+			return
+		}
+
+		if subArr, ok := obj.(*PdfObjectArray); ok {
+			if len(numbers) != 1 || numbers[0] < 0 {
+				this.log().Debug("Error: invalid W array, list without a start cid")
+				return
+			}
+			cid := uint(numbers[0])
+			for k := 0; k < len(*subArr); k++ {
+				widthObj, err := this.parser.Trace((*subArr)[k])
+				if err != nil {
+					this.log().Debug("Error: trace W width failed, err: %s", err)
+					break
+				}
+				width, err := GetNumberAsFloat(widthObj)
+				if err != nil || width < 0 {
+					width = 0
+				}
+				font.mFontMetrics.mCidWidths[cid] = uint(width)
+				cid++
+			}
+			numbers = numbers[:0]
+			continue
+		}
+
+		v, err := GetNumberAsFloat(obj)
+		if err != nil {
+			this.log().Debug("Error: invalid W entry: %s", obj)
+			return
+		}
+		numbers = append(numbers, v)
+		if len(numbers) == 3 {
+			if numbers[0] >= 0 && numbers[1] >= numbers[0] && numbers[1]-numbers[0] < 0x10000 && numbers[2] >= 0 {
+				for cid := uint(numbers[0]); cid <= uint(numbers[1]); cid++ {
+					font.mFontMetrics.mCidWidths[cid] = uint(numbers[2])
+				}
+			} else {
+				this.log().Debug("Error: invalid W range: %v", numbers)
+			}
+			numbers = numbers[:0]
+		}
+	}
+}
+
+// GetCidWidth returns the horizontal width of the glyph of cid in a CIDFont, in glyph space units
+// (1/1000 of text space), falling back to the DW default.
+func (font *Font) GetCidWidth(cid uint) float64 {
+	if width, ok := font.mFontMetrics.mCidWidths[cid]; ok {
+		return float64(width)
+	}
+	return float64(font.mFontMetrics.mMissingWidth)
+}
+
+// GetCharWidth returns the width of the glyph of character code in a simple font, in glyph space
+// units, from Widths (indexed from FirstChar) or the standard font metrics, falling back to MissingWidth.
+// The codes outside of FirstChar to LastChar, or beyond the end of a short Widths, have MissingWidth.
+func (font *Font) GetCharWidth(code uint) float64 {
+	if code >= font.mFontMetrics.mFirstChar && code <= font.mFontMetrics.mLastChar {
+		if i := code - font.mFontMetrics.mFirstChar; i < uint(len(font.mFontMetrics.mWidths)) {
+			return float64(font.mFontMetrics.mWidths[i])
+		}
+	}
+	return float64(font.mFontMetrics.mMissingWidth)
+}
+
+// parseVerticalMetrics parses the DW2 and W2 entries of a CIDFont.  The W2 array has the forms
+// c [w1_y v_x v_y ...] for consecutive cids and c_first c_last w1_y v_x v_y for a range of cids.
+func (this *PdfReader) parseVerticalMetrics(font *Font, descendantFontDict *PdfObjectDictionary) {
+	font.mFontMetrics.mVerticalDefault = [2]float64{880, -1000}
+	if dw2Obj, err := this.parser.Trace(descendantFontDict.Get("DW2")); err == nil {
+		if dw2Arr, ok := dw2Obj.(*PdfObjectArray); ok && len(*dw2Arr) == 2 {
+			for i := 0; i < 2; i++ {
+				if v, err := GetNumberAsFloat((*dw2Arr)[i]); err == nil {
+					font.mFontMetrics.mVerticalDefault[i] = v
+				}
+			}
+		}
+	}
+
+	font.mFontMetrics.mVerticalMetrics = map[uint][3]float64{}
+	w2Obj, err := this.parser.Trace(descendantFontDict.Get("W2"))
+	if err != nil {
+		return
+	}
+	w2Arr, ok := w2Obj.(*PdfObjectArray)
+	if !ok {
+		return
+	}
+
+	numbers := []float64{}
+	for j := 0; j < len(*w2Arr); j++ {
+		obj, err := this.parser.Trace((*w2Arr)[j])
+		if err != nil {
+			this.log().Debug("Error: trace W2 entry failed, err: %s", err)
+			return
+		}
+
+		if subArr, ok := obj.(*PdfObjectArray); ok {
+			if len(numbers) != 1 || numbers[0] < 0 {
+				this.log().Debug("Error: invalid W2 array, list without a start cid")
+				return
+			}
+			cid := uint(numbers[0])
+			for k := 0; k+2 < len(*subArr); k += 3 {
+				var metrics [3]float64
+				for m := 0; m < 3; m++ {
+					metrics[m], _ = GetNumberAsFloat((*subArr)[k+m])
+				}
+				font.mFontMetrics.mVerticalMetrics[cid] = metrics
+				cid++
+			}
+			numbers = numbers[:0]
+			continue
+		}
+
+		v, err := GetNumberAsFloat(obj)
+		if err != nil {
+			this.log().Debug("Error: invalid W2 entry: %s", obj)
+			return
+		}
+		numbers = append(numbers, v)
+		if len(numbers) == 5 {
+			if numbers[0] >= 0 && numbers[1] >= numbers[0] && numbers[1]-numbers[0] < 0x10000 {
+				metrics := [3]float64{numbers[2], numbers[3], numbers[4]}
+				for cid := uint(numbers[0]); cid <= uint(numbers[1]); cid++ {
+					font.mFontMetrics.mVerticalMetrics[cid] = metrics
+				}
+			}
+			numbers = numbers[:0]
+		}
+	}
+}
+
+// GetVerticalMetrics returns the vertical displacement w1_y and the position vector (v_x, v_y) of the
+// glyph of cid in a vertical CIDFont, falling back to the DW2 defaults.
+func (font *Font) GetVerticalMetrics(cid uint) (w1y, vx, vy float64) {
+	if metrics, ok := font.mFontMetrics.mVerticalMetrics[cid]; ok {
+		return metrics[0], metrics[1], metrics[2]
+	}
+
+	return font.mFontMetrics.mVerticalDefault[1], font.GetCidWidth(cid) / 2, font.mFontMetrics.mVerticalDefault[0]
+}
+
 type FontsByNames map[PdfObjectName]*Font
 
 type PdfReader struct {
@@ -517,16 +802,107 @@ type PdfReader struct {
 	pageList      []*PdfIndirectObject
 	pageResources []*PdfObjectDictionary
 
+	// root node of the page tree, and whether the page list is built from it on demand only
+	pagesNode     *PdfIndirectObject
+	lazyPages     bool
+	pageListBuilt bool
+
 	mFonts          []*Font
 	mFontsByIndexes map[uint]*Font
 	mFontsForPages  []FontsByNames
 
 	//PageList    []*PdfPage
 	pageCount int
+
+	// logger of the reader, common.Log if nil
+	logger common.Logger
+
+	// decoded content streams by object, see DecodeContentStream
+	contentCache      map[PdfObjectReference][]byte
+	contentCacheMutex sync.Mutex
 }
 
 func NewPdfReader(rs io.ReadSeeker) (*PdfReader, error) {
+	return NewPdfReaderWithLogger(rs, nil)
+}
+
+// ErrNeedPassword is returned by the page and text accessors of a reader of an encrypted document that is not
+// decrypted, see NewPdfReaderNoDecrypt.
+var ErrNeedPassword = errors.New("password needed to decrypt the document")
+
+// Inputs of NewPdfReaderFromReader up to this size are buffered in memory, larger ones in a temporary file.
+const maxMemoryBufferedInput = 64 << 20
+
+// NewPdfReaderFromReader creates a reader from a non seekable input such as an HTTP response body.  The
+// input is read completely and buffered to provide the seeking the parser requires, in memory or, for large
+// inputs, in a temporary file that is removed right after creation where the platform allows it.
+func NewPdfReaderFromReader(r io.Reader) (*PdfReader, error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		return NewPdfReader(rs)
+	}
+
+	rs, err := bufferInput(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewPdfReader(rs)
+}
+
+// bufferInput reads r completely and returns its content as a ReadSeeker.
+func bufferInput(r io.Reader) (io.ReadSeeker, error) {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, maxMemoryBufferedInput+1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n <= maxMemoryBufferedInput {
+		return bytes.NewReader(buf.Bytes()), nil
+	}
+
+	f, err := ioutil.TempFile("", "pdfreader")
+	if err != nil {
+		return nil, err
+	}
+	// The open file remains readable once removed, and is deleted with no further cleanup when closed.
+	os.Remove(f.Name())
+
+	if _, err := io.Copy(f, io.MultiReader(&buf, r)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewPdfReaderWithLogger creates a reader logging to the logger instead of the global common.Log, including
+// while loading the document structure.  The logging of the core parser still goes to common.Log.
+func NewPdfReaderWithLogger(rs io.ReadSeeker, logger common.Logger) (*PdfReader, error) {
+	return newPdfReader(rs, logger, false, true)
+}
+
+// NewPdfReaderLazy creates a reader which doesn't build the page list while loading the document structure,
+// for huge documents of which few pages are needed.  GetPage and the accessors of a single page, such as
+// GetPageContent or ParsePageFonts, only resolve the page tree nodes on the path to the page.  The page
+// list is built by the first call needing all the pages, such as GetPageList or ParseFonts.
+func NewPdfReaderLazy(rs io.ReadSeeker) (*PdfReader, error) {
+	return newPdfReader(rs, nil, true, true)
+}
+
+// NewPdfReaderNoDecrypt creates a reader which doesn't attempt to decrypt an encrypted document with the
+// empty password, to inspect it, e.g. with IsEncrypted and GetEncryptionMethod, without failing.  The
+// document structure of an encrypted document is loaded by Decrypt, the page and text accessors return
+// ErrNeedPassword until then.
+func NewPdfReaderNoDecrypt(rs io.ReadSeeker) (*PdfReader, error) {
+	return newPdfReader(rs, nil, false, false)
+}
+
+func newPdfReader(rs io.ReadSeeker, logger common.Logger, lazyPages bool, decrypt bool) (*PdfReader, error) {
 	pdfReader := &PdfReader{}
+	pdfReader.logger = logger
+	pdfReader.lazyPages = lazyPages
 
 	// Create the parser, loads the cross reference table and trailer.
 	parser, err := NewParser(rs)
@@ -540,11 +916,14 @@ func NewPdfReader(rs io.ReadSeeker) (*PdfReader, error) {
 		return nil, err
 	}
 
-	common.Log.Trace("this pdf encrypt: %v", isEncrypted)
+	pdfReader.log().Trace("this pdf encrypt: %v", isEncrypted)
+	if isEncrypted && !decrypt {
+		return pdfReader, nil
+	}
 	if isEncrypted {
-		common.Log.Trace("encrypt info: %s", pdfReader.GetEncryptionMethod())
+		pdfReader.log().Trace("encrypt info: %s", pdfReader.GetEncryptionMethod())
 		if success, err := parser.Decrypt([]byte("")); err != nil {
-			common.Log.Debug("error: decrypt failed, err: %s", err)
+			pdfReader.log().Debug("error: decrypt failed, err: %s", err)
 			return nil, err
 		} else if !success {
 			return nil, errors.New("decrypt use empty password failed")
@@ -559,82 +938,142 @@ func NewPdfReader(rs io.ReadSeeker) (*PdfReader, error) {
 	return pdfReader, nil
 }
 
+// IsEncrypted returns whether the document is encrypted.
+func (this *PdfReader) IsEncrypted() bool {
+	return this.parser.GetCrypter() != nil
+}
+
+// Decrypt authenticates with the user or owner password the encrypted document of a reader created with
+// NewPdfReaderNoDecrypt, and loads its structure.  Returns false if the password is wrong.
+func (this *PdfReader) Decrypt(password []byte) (bool, error) {
+	if this.pagesNode != nil {
+		return true, nil
+	}
+	success, err := this.parser.Decrypt(password)
+	if err != nil || !success {
+		return false, err
+	}
+
+	if err := this.loadStructure(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// needPassword returns whether the document is encrypted and not decrypted yet.
+func (this *PdfReader) needPassword() bool {
+	return this.parser.GetCrypter() != nil && !this.parser.IsAuthenticated()
+}
+
+// SetLogger sets the logger of the reader, the global common.Log is used if nil.
+func (this *PdfReader) SetLogger(logger common.Logger) {
+	this.logger = logger
+}
+
+// GetLogger returns the logger set on the reader, nil when it logs to the global common.Log.
+func (this *PdfReader) GetLogger() common.Logger {
+	return this.logger
+}
+
+func (this *PdfReader) log() common.Logger {
+	if this.logger != nil {
+		return this.logger
+	}
+	return common.Log
+}
+
 func (this *PdfReader) DumpFonts() {
-	common.Log.Trace("fonts: %d", len(this.mFontsByIndexes))
+	this.log().Trace("fonts: %d", len(this.mFontsByIndexes))
 	for index, f := range this.mFontsByIndexes {
-		common.Log.Trace("index: %d, fonts: %s", index, f.mFontDictionary)
+		this.log().Trace("index: %d, fonts: %s", index, f.mFontDictionary)
 	}
 }
 
 func (this *PdfReader) ParseFonts() error {
+	if err := this.buildPages(); err != nil {
+		return err
+	}
 
 	this.mFonts = []*Font{}
 	this.mFontsForPages = []FontsByNames{}
 	this.mFontsByIndexes = map[uint]*Font{}
 
 	for i := 0; i < len(this.pageResources); i++ {
-		fonts := make(FontsByNames)
+		fonts, err := this.parseResourceFonts(this.pageResources[i])
 		this.mFontsForPages = append(this.mFontsForPages, fonts)
-		resDic := this.pageResources[i]
-		if resDic == nil {
-			continue
+		if err != nil {
+			return err
 		}
+	}
 
-		if obj, err := this.parser.Trace(resDic.Get("Font")); err == nil {
-			fontsDict, ok := obj.(*PdfObjectDictionary)
-			if !ok {
-				common.Log.Debug("font obj is not dict, next page")
-				continue
-			}
+	return nil
+}
 
-			for fontName, fontValue := range fontsDict.Dict() {
-				//fontValue maybe pdfObjectReference
-				fontObj, err := this.traceToObject(fontValue)
-				if err != nil {
-					common.Log.Debug("Error: font trace to indirect obj failed, err: %s", err)
-					return err
-				}
+// parseResourceFonts parses the fonts of the /Font dictionary of a resource dictionary, fonts shared by
+// reference with previously parsed resources are parsed once.
+func (this *PdfReader) parseResourceFonts(resDic *PdfObjectDictionary) (FontsByNames, error) {
+	fonts := make(FontsByNames)
+	if resDic == nil {
+		return fonts, nil
+	}
+	if this.mFontsByIndexes == nil {
+		this.mFontsByIndexes = map[uint]*Font{}
+	}
 
-				//common.Log.Debug("page: %d, fontName: %s\n", i, fontName)
+	if obj, err := this.parser.Trace(resDic.Get("Font")); err == nil {
+		fontsDict, ok := obj.(*PdfObjectDictionary)
+		if !ok {
+			this.log().Debug("font obj is not dict, next page")
+			return fonts, nil
+		}
 
-				//fontValue is reference obj
-				fontIndObj, ok := fontObj.(*PdfIndirectObject)
-				if ok {
-					refInd := fontIndObj.ObjectNumber
-					font, exist := this.mFontsByIndexes[uint(refInd)]
-					if exist {
-						this.mFontsForPages[i][fontName] = font
-					} else {
-						font = new(Font)
-						font.mFontDictionary, _ = fontIndObj.PdfObject.(*PdfObjectDictionary)
-						this.mFontsByIndexes[uint(refInd)] = font
+		for fontName, fontValue := range fontsDict.Dict() {
+			//fontValue maybe pdfObjectReference
+			fontObj, err := this.traceToObject(fontValue)
+			if err != nil {
+				this.log().Debug("Error: font trace to indirect obj failed, err: %s", err)
+				return fonts, err
+			}
 
-						//common.Log.Debug("font: %s", font.mFontDictionary)
+			//common.Log.Debug("page: %d, fontName: %s\n", i, fontName)
 
-						this.mFontsForPages[i][fontName] = font
-						this.mFonts = append(this.mFonts, font)
+			//fontValue is reference obj
+			fontIndObj, ok := fontObj.(*PdfIndirectObject)
+			if ok {
+				refInd := fontIndObj.ObjectNumber
+				font, exist := this.mFontsByIndexes[uint(refInd)]
+				if exist {
+					fonts[fontName] = font
+				} else {
+					font = new(Font)
+					font.mFontDictionary, _ = fontIndObj.PdfObject.(*PdfObjectDictionary)
+					this.mFontsByIndexes[uint(refInd)] = font
 
-						this.getFontEncoding(font)
-						this.getFontInfo(font)
-					}
-					// fontValue is direct dictionary
-				} else if fontObjDict, ok := fontObj.(*PdfObjectDictionary); ok {
-					font := new(Font)
-					font.mFontDictionary = fontObjDict
+					//common.Log.Debug("font: %s", font.mFontDictionary)
 
-					this.mFontsForPages[i][fontName] = font
+					fonts[fontName] = font
 					this.mFonts = append(this.mFonts, font)
 
 					this.getFontEncoding(font)
 					this.getFontInfo(font)
-				} else {
-					return errors.New("unexpected font stream to parse")
 				}
+				// fontValue is direct dictionary
+			} else if fontObjDict, ok := fontObj.(*PdfObjectDictionary); ok {
+				font := new(Font)
+				font.mFontDictionary = fontObjDict
+
+				fonts[fontName] = font
+				this.mFonts = append(this.mFonts, font)
+
+				this.getFontEncoding(font)
+				this.getFontInfo(font)
+			} else {
+				return fonts, errors.New("unexpected font stream to parse")
 			}
 		}
 	}
 
-	return nil
+	return fonts, nil
 }
 
 // Loads the structure of the pdf file: pages, outlines, etc.
@@ -643,53 +1082,86 @@ func (this *PdfReader) loadStructure() error {
 		return errors.New("file need to be decrypted first")
 	}
 
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return errors.New("catalog missing")
+	}
+
 	// Pages.
-	pagesRef, ok := this.parser.GetRootDict().Get("Pages").(*PdfObjectReference)
+	pagesRef, ok := rootDict.Get("Pages").(*PdfObjectReference)
 	if !ok {
 		return errors.New("Pages in root should be a reference")
 	}
 
 	op, err := this.parser.LookupByReference(*pagesRef)
 	if err != nil {
-		common.Log.Debug("ERROR: Failed to read pages")
+		this.log().Debug("ERROR: Failed to read pages")
 		return err
 	}
 
 	ppages, ok := op.(*PdfIndirectObject)
 	if !ok {
-		common.Log.Debug("ERROR: Pages object invalid, op: %p", ppages)
+		this.log().Debug("ERROR: Pages object invalid, op: %p", ppages)
 		return errors.New("Pages object invalid")
 	}
 
 	pages, ok := ppages.PdfObject.(*PdfObjectDictionary)
 	if !ok {
-		common.Log.Debug("ERROR: Pages object invalid (%s)", ppages)
+		this.log().Debug("ERROR: Pages object invalid (%s)", ppages)
 		return errors.New("Pages object invalid")
 	}
 
 	pageCount, ok := pages.Get("Count").(*PdfObjectInteger)
 	if !ok {
-		common.Log.Debug("ERROR: Pages count object invalid")
+		this.log().Debug("ERROR: Pages count object invalid")
 		return errors.New("Pages count invalid")
 	}
 
-	this.root = this.parser.GetRootDict()
+	this.root = rootDict
 	this.pages = pages
 	this.pageCount = int(*pageCount)
+	this.pagesNode = ppages
+
+	if this.lazyPages {
+		return nil
+	}
+	return this.buildPages()
+}
+
+// buildPages builds the page list and the resources of the pages, once.
+func (this *PdfReader) buildPages() error {
+	if this.pageListBuilt {
+		return nil
+	}
+	if this.needPassword() {
+		return ErrNeedPassword
+	}
+	this.pageListBuilt = true
+
 	this.pageList = []*PdfIndirectObject{}
 	this.pageResources = []*PdfObjectDictionary{}
 
 	traversedPageNodes := map[PdfObject]bool{}
-	err = this.buildPageList(ppages, nil, nil, traversedPageNodes)
+	err := this.buildPageList(this.pagesNode, nil, nil, traversedPageNodes)
 	if err != nil {
 		return err
 	}
 
-	common.Log.Trace("pages, %d: %s", len(this.pageList), this.pageList)
-	common.Log.Trace("resources, %d, %s", len(this.pageResources), this.pageResources)
+	this.pageCount = len(this.pageList)
+
+	this.log().Trace("pages, %d: %s", len(this.pageList), this.pageList)
+	this.log().Trace("resources, %d, %s", len(this.pageResources), this.pageResources)
 	return nil
 }
 
+// loadPageList builds the page list if not built yet by a reader created with NewPdfReaderLazy, for the
+// accessors that can't return the error.
+func (this *PdfReader) loadPageList() {
+	if err := this.buildPages(); err != nil {
+		this.log().Debug("Error: build page list failed, err: %v", err)
+	}
+}
+
 // Trace to object.  Keeps a list of already visited references to avoid circular references.
 //
 // Example circular reference.
@@ -733,7 +1205,7 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 	}
 
 	if _, alreadyTraversed := traversedPageNodes[node]; alreadyTraversed {
-		common.Log.Debug("Cyclic recursion, skipping")
+		this.log().Debug("Cyclic recursion, skipping")
 		return nil
 	}
 	traversedPageNodes[node] = true
@@ -747,17 +1219,12 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 	if !ok {
 		return errors.New("Node missing Type (Required)")
 	}
-	common.Log.Trace("buildPageList node type: %s", *objType)
+	this.log().Trace("buildPageList node type: %s", *objType)
 
-	// resources maybe reference obj
-	if resourceObj, err := this.parser.Trace((*nodeDict).Get("Resources")); err == nil {
-		if overrid, ok := resourceObj.(*PdfObjectDictionary); ok {
-			resource = overrid
-		}
-	}
+	resource = this.nodeResources(nodeDict, resource)
 
 	if *objType != "Pages" && *objType != "Page" {
-		common.Log.Debug("Error: Table of content containing non Page/Pages object! (%s)", objType)
+		this.log().Debug("Error: Table of content containing non Page/Pages object! (%s)", objType)
 		return errors.New("Table of content containing non Page/Pages object!")
 	}
 
@@ -769,11 +1236,12 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 	if *objType == "Pages" {
 		kidsArray, ok := (*nodeDict).Get("Kids").(*PdfObjectArray)
 		if !ok {
-			common.Log.Debug("Error: kids in pages is not array")
+			this.log().Debug("Error: kids in pages is not array")
 			return errors.New("kids in pages not array")
 		}
 
-		common.Log.Trace("Kids: %s, %d", kidsArray, len(*kidsArray))
+		this.log().Trace("Kids: %s, %d", kidsArray, len(*kidsArray))
+		start := len(this.pageList)
 		for i := 0; i < len(*kidsArray); i++ {
 			obj, err := this.traceToObject((*kidsArray)[i])
 			if err != nil {
@@ -781,7 +1249,7 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 			}
 			child, ok := obj.(*PdfIndirectObject)
 			if !ok {
-				common.Log.Debug("kid not indirect object")
+				this.log().Debug("kid not indirect object")
 				return errors.New("kid not indiret object")
 			}
 			err = this.buildPageList(child, node, resource, traversedPageNodes)
@@ -789,6 +1257,14 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 				return err
 			}
 		}
+
+		// The pages found are authoritative, the /Count is corrected for the lookups by count.
+		count := len(this.pageList) - start
+		countObj, _ := this.parser.Trace(nodeDict.Get("Count"))
+		if declared, ok := countObj.(*PdfObjectInteger); !ok || int(*declared) != count {
+			this.log().Debug("Warning: Pages node %d /Count %v, %d pages found", node.ObjectNumber, countObj, count)
+			nodeDict.Set("Count", MakeInteger(int64(count)))
+		}
 	} else {
 		if parent != nil {
 			// Set the parent (in case missing or incorrect).
@@ -803,6 +1279,162 @@ func (this *PdfReader) buildPageList(node *PdfIndirectObject, parent *PdfIndirec
 	return nil
 }
 
+// nodeResources returns the resources of a page tree node: its /Resources, or the resources inherited
+// from its ancestors.
+func (this *PdfReader) nodeResources(nodeDict *PdfObjectDictionary, resource *PdfObjectDictionary) *PdfObjectDictionary {
+	// resources maybe reference obj
+	if resourceObj, err := this.parser.Trace(nodeDict.Get("Resources")); err == nil {
+		if overrid, ok := resourceObj.(*PdfObjectDictionary); ok {
+			// Resources replace the inherited ones as a whole, but fonts missing from the node are
+			// taken from the ancestors rather than leaving the text of the node without fonts.
+			if overrid.Get("Font") == nil && resource != nil && resource.Get("Font") != nil {
+				this.log().Debug("Warning: Resources without Font, inheriting the Font of the ancestors")
+				merged := MakeDict()
+				merged.Merge(overrid)
+				merged.Set("Font", resource.Get("Font"))
+				overrid = merged
+			}
+			resource = overrid
+		}
+	}
+
+	return resource
+}
+
+// findPage returns the page (0 based index) and its resources.  Unless the page list is built, the page
+// tree is descended from the root, skipping the kids before the page by their /Count, so that only the
+// nodes on the path to the page and their kids are resolved.  The page list is built when the /Count of
+// a node on the path differs from the pages below its kids.
+func (this *PdfReader) findPage(pageIndex int) (*PdfIndirectObject, *PdfObjectDictionary, error) {
+	if this.needPassword() {
+		return nil, nil, ErrNeedPassword
+	}
+	if this.pageListBuilt {
+		if pageIndex < 0 || pageIndex >= len(this.pageList) {
+			return nil, nil, errors.New("page index out of range")
+		}
+		return this.pageList[pageIndex], this.pageResources[pageIndex], nil
+	}
+	if pageIndex < 0 || pageIndex >= this.pageCount {
+		return nil, nil, errors.New("page index out of range")
+	}
+
+	originalIndex := pageIndex
+	node := this.pagesNode
+	var resource *PdfObjectDictionary
+	traversedPageNodes := map[PdfObject]bool{}
+	for node != nil {
+		if traversedPageNodes[node] {
+			return nil, nil, errors.New("page tree loop")
+		}
+		traversedPageNodes[node] = true
+
+		nodeDict, ok := node.PdfObject.(*PdfObjectDictionary)
+		if !ok {
+			return nil, nil, errors.New("Node not a dictionary")
+		}
+		resource = this.nodeResources(nodeDict, resource)
+
+		objType, ok := nodeDict.Get("Type").(*PdfObjectName)
+		if !ok {
+			return nil, nil, errors.New("Node missing Type (Required)")
+		}
+		if *objType == "Page" {
+			return node, resource, nil
+		}
+		if *objType != "Pages" {
+			return nil, nil, errors.New("Table of content containing non Page/Pages object!")
+		}
+
+		kidsArray, ok := nodeDict.Get("Kids").(*PdfObjectArray)
+		if !ok {
+			return nil, nil, errors.New("kids in pages not array")
+		}
+		parent := node
+		node = nil
+		// the pages below the kids, to check the /Count of the node
+		total := 0
+		for _, kid := range *kidsArray {
+			obj, err := this.traceToObject(kid)
+			if err != nil {
+				return nil, nil, err
+			}
+			child, ok := obj.(*PdfIndirectObject)
+			if !ok {
+				return nil, nil, errors.New("kid not indiret object")
+			}
+			childDict, ok := child.PdfObject.(*PdfObjectDictionary)
+			if !ok {
+				return nil, nil, errors.New("Node not a dictionary")
+			}
+
+			// the number of pages below the kid
+			count := 1
+			if childType, ok := childDict.Get("Type").(*PdfObjectName); ok && *childType == "Pages" {
+				countObj, err := this.parser.Trace(childDict.Get("Count"))
+				if err != nil {
+					return nil, nil, err
+				}
+				countInt, ok := countObj.(*PdfObjectInteger)
+				if !ok {
+					return nil, nil, errors.New("Pages count invalid")
+				}
+				count = int(*countInt)
+			}
+
+			total += count
+			if node != nil {
+				continue
+			}
+			if pageIndex < count {
+				// Set the parent (in case missing or incorrect).
+				childDict.Set("Parent", parent)
+				node = child
+				continue
+			}
+			pageIndex -= count
+		}
+
+		// the kids skipped by a wrong /Count may hold the page: the page list is built from the kids instead
+		countObj, err := this.parser.Trace(nodeDict.Get("Count"))
+		if countInt, ok := countObj.(*PdfObjectInteger); err != nil || !ok || int(*countInt) != total {
+			this.log().Debug("Warning: Pages node %d /Count %v, %d pages below its kids", parent.ObjectNumber,
+				countObj, total)
+			if err := this.buildPages(); err != nil {
+				return nil, nil, err
+			}
+			return this.findPage(originalIndex)
+		}
+	}
+
+	return nil, nil, errors.New("page not found in the page tree")
+}
+
+// GetPage returns the page object of the page (0 based index).  See NewPdfReaderLazy for the page tree
+// nodes resolved.
+func (this *PdfReader) GetPage(pageIndex int) (*PdfIndirectObject, error) {
+	page, _, err := this.findPage(pageIndex)
+	return page, err
+}
+
+// GetPageCount returns the number of pages, the /Count of the page tree root until the page list is built.
+func (this *PdfReader) GetPageCount() int {
+	if this.pageListBuilt {
+		return len(this.pageList)
+	}
+	return this.pageCount
+}
+
+// ParsePageFonts parses the fonts of the resources of the page (0 based index) only, like ParseFonts does
+// for all the pages.
+func (this *PdfReader) ParsePageFonts(pageIndex int) (FontsByNames, error) {
+	_, resource, err := this.findPage(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+	return this.parseResourceFonts(resource)
+}
+
 // Returns a string containing some information about the encryption method used.
 // Subject to changes.  May be better to return a standardized struct with information.
 // But challenging due to the many different types supported.
@@ -834,6 +1466,7 @@ func (this *PdfReader) GetEncryptionMethod() string {
 }
 
 func (this *PdfReader) GetPageList() []*PdfIndirectObject {
+	this.loadPageList()
 	return this.pageList
 }
 
@@ -841,11 +1474,113 @@ func (this *PdfReader) GetParser() *PdfParser {
 	return this.parser
 }
 
+// GetVersion returns the major and minor parts of the PDF version of the document.  The /Version
+// entry of the catalog, used for updates, supersedes the file header version when it is later.
+func (this *PdfReader) GetVersion() (int, int) {
+	major, minor := this.parser.GetPdfVersion()
+
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return major, minor
+	}
+	versionObj, err := this.parser.Trace(rootDict.Get("Version"))
+	if err != nil {
+		return major, minor
+	}
+	versionName, ok := versionObj.(*PdfObjectName)
+	if !ok {
+		return major, minor
+	}
+
+	parts := strings.Split(string(*versionName), ".")
+	if len(parts) != 2 {
+		this.log().Debug("Invalid catalog Version: %s", *versionName)
+		return major, minor
+	}
+	catalogMajor, err1 := strconv.Atoi(parts[0])
+	catalogMinor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		this.log().Debug("Invalid catalog Version: %s", *versionName)
+		return major, minor
+	}
+
+	if catalogMajor > major || (catalogMajor == major && catalogMinor > minor) {
+		return catalogMajor, catalogMinor
+	}
+	return major, minor
+}
+
+// GetLanguage returns the natural language of the document declared by the /Lang of the catalog, a
+// language tag such as en-US or zh-CN.  Returns an empty string when not declared.
+func (this *PdfReader) GetLanguage() string {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return ""
+	}
+	langObj, err := this.parser.Trace(rootDict.Get("Lang"))
+	if err != nil {
+		return ""
+	}
+	lang, ok := langObj.(*PdfObjectString)
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(decodeTextString(string(*lang)))
+}
+
 func (this *PdfReader) GetFontsForPages() []FontsByNames {
 	return this.mFontsForPages
 }
 
+// FontInfo describes a font used by a page.
+type FontInfo struct {
+	Name     string // resource name, e.g. F1
+	BaseFont string
+	Subtype  string
+	Embedded bool // font program present (FontFile, FontFile2 or FontFile3)
+	Encoding string
+}
+
+// GetPageFonts returns the fonts in the resources of the page (0 based index), sorted by resource name.
+// The fonts must have been parsed with ParseFonts.
+func (this *PdfReader) GetPageFonts(pageIndex int) ([]FontInfo, error) {
+	this.loadPageList()
+	if pageIndex < 0 || pageIndex >= len(this.pageList) {
+		return nil, errors.New("page index out of range")
+	}
+	if pageIndex >= len(this.mFontsForPages) {
+		return nil, errors.New("fonts not parsed")
+	}
+
+	infos := []FontInfo{}
+	for name, font := range this.mFontsForPages[pageIndex] {
+		info := FontInfo{
+			Name:     string(name),
+			BaseFont: font.mBaseFont,
+			Subtype:  font.mFontType,
+			Encoding: font.mFontEncoding,
+		}
+		if info.Encoding == "" && font.mOwnSimpleEncodingTable {
+			info.Encoding = "Differences"
+		}
+		if font.mFontDescriptor != nil {
+			for _, key := range []PdfObjectName{"FontFile", "FontFile2", "FontFile3"} {
+				if font.mFontDescriptor.Get(key) != nil {
+					info.Embedded = true
+					break
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
 func (this *PdfReader) GetPageResources() []*PdfObjectDictionary {
+	this.loadPageList()
 	return this.pageResources
 }
 
@@ -0,0 +1,365 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetPageFonts(t *testing.T) {
+	pdf := pagePdf("BT /F1 12 Tf (a) Tj /F2 12 Tf (b) Tj ET", "/Font << /F2 5 0 R /F1 6 0 R >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Arial /FirstChar 32 /LastChar 32 /Widths [278] "+
+			"/Encoding /WinAnsiEncoding /FontDescriptor 7 0 R >>",
+		helveticaFont,
+		"<< /Type /FontDescriptor /FontName /ABCDEF+Arial /Flags 32 /FontFile2 8 0 R >>",
+		makeStream("/Length1 4", "true"))
+	reader := openPdf(t, pdf)
+	parseFonts(t, reader, 0)
+
+	fonts, err := reader.GetPageFonts(0)
+	if err != nil {
+		t.Fatalf("GetPageFonts: %v", err)
+	}
+	expected := []FontInfo{
+		{Name: "F1", BaseFont: "Helvetica", Subtype: "Type1", Embedded: false, Encoding: "WinAnsiEncoding"},
+		{Name: "F2", BaseFont: "ABCDEF+Arial", Subtype: "TrueType", Embedded: true, Encoding: "WinAnsiEncoding"},
+	}
+	if len(fonts) != len(expected) {
+		t.Fatalf("got %v", fonts)
+	}
+	for i := range expected {
+		if fonts[i] != expected[i] {
+			t.Errorf("font %d: got %+v, expected %+v", i, fonts[i], expected[i])
+		}
+	}
+
+	if _, err := reader.GetPageFonts(1); err == nil {
+		t.Errorf("no error for a page out of range")
+	}
+}
+
+// decodeType0 returns the text of the codes of the Type0 font, mapped to CIDs by its predefined CMap and
+// the CIDs to unicode.
+func decodeType0(t *testing.T, font *Font, codes []byte) string {
+	if font == nil || !font.GetmPredefinedCmap() || font.GetCidCmap() == nil || font.GetCmap() == nil {
+		t.Fatalf("font without predefined cmap: %+v", font)
+	}
+	cids := font.GetCidCmap().CharcodeBytesToCidStr(codes)
+	return font.GetCmap().CharcodeBytesToUnicode([]byte(cids), nil, false)
+}
+
+func TestType0PredefinedEncodingName(t *testing.T) {
+	pdf := pagePdf("BT /F1 12 Tf <D6D041> Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /GBK-EUC-H /DescendantFonts [6 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> >>")
+	font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+	// D6D0 is 中 in GBK, the single byte 41 is A
+	if text := decodeType0(t, font, []byte{0xD6, 0xD0, 0x41}); text != "中A" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestCMapResourceDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmaps")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"GBK-EUC-H", "Adobe-GB1-UCS2"} {
+		data, err := ioutil.ReadFile(filepath.Join("../resources", name))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	defer SetCMapResourceDir("../resources/")
+
+	pdf := pagePdf("BT /F1 12 Tf <D6D0> Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /GBK-EUC-H /DescendantFonts [6 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> >>")
+	SetCMapResourceDir(dir)
+	if text := decodeType0(t, parseFonts(t, openPdf(t, pdf), 0)["F1"], []byte{0xD6, 0xD0}); text != "中" {
+		t.Errorf("got %q", text)
+	}
+
+	// no cmap files in the directory
+	SetCMapResourceDir(filepath.Join(dir, "none"))
+	if font := parseFonts(t, openPdf(t, pdf), 0)["F1"]; font.GetmPredefinedCmap() {
+		t.Errorf("predefined cmap loaded from a missing directory")
+	}
+}
+
+func TestType0EmbeddedEncodingCMap(t *testing.T) {
+	encoding := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 5 >> def " +
+		"/CMapName /Test-GB1-H def /CMapType 1 def 1 begincodespacerange <0000> <FFFF> endcodespacerange " +
+		"1 begincidrange <0001> <0001> 4559 endcidrange endcmap CMapName currentdict /CMap defineresource pop end end"
+	// the /Encoding stream and the /DescendantFonts array are indirect
+	pdf := pagePdf("BT /F1 12 Tf <0001> Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Song /Encoding 6 0 R /DescendantFonts 7 0 R >>",
+		makeStream("/Type /CMap /CMapName /Test-GB1-H", encoding),
+		"[8 0 R]",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /Song "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 5 >> >>")
+	font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+	if !font.IsMultibyte() {
+		t.Errorf("Type0 font not multibyte")
+	}
+	if text := decodeType0(t, font, []byte{0x00, 0x01}); text != "中" {
+		t.Errorf("got %q", text)
+	}
+}
+
+func TestType0EmbeddedEncodingCMapPrecedence(t *testing.T) {
+	// no CIDSystemInfo in the embedded cmap, the CIDFont one being GB1
+	encoding := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap " +
+		"/CMapName /Test-H def /CMapType 1 def 1 begincodespacerange <0000> <FFFF> endcodespacerange " +
+		"1 begincidrange <0001> <0001> 4559 endcidrange endcmap CMapName currentdict /CMap defineresource pop end end"
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap /CMapName /T def " +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange 1 beginbfchar <0001> <0058> endbfchar " +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	for _, toUnicodeEntry := range []string{"", "/ToUnicode 8 0 R"} {
+		pdf := pagePdf("BT /F1 12 Tf <0001> Tj ET", "/Font << /F1 5 0 R >>",
+			"<< /Type /Font /Subtype /Type0 /BaseFont /Song /Encoding 6 0 R /DescendantFonts [7 0 R] "+
+				toUnicodeEntry+" >>",
+			makeStream("/Type /CMap /CMapName /Test-H", encoding),
+			"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /Song "+
+				"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 5 >> >>",
+			makeStream("", toUnicode))
+		font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+		// the embedded cmap is kept rather than replaced by a predefined one
+		if font.GetCidCmap() == nil || font.GetCidCmap().CharcodeBytesToCidStr([]byte{0x00, 0x01}) != "\x11\xcf" {
+			t.Errorf("%q: code 0001 not mapped to cid 4559", toUnicodeEntry)
+			continue
+		}
+		if toUnicodeEntry == "" {
+			if text := decodeType0(t, font, []byte{0x00, 0x01}); text != "中" {
+				t.Errorf("got %q", text)
+			}
+		} else if font.GetToUnicodeCmap() == nil ||
+			font.GetToUnicodeCmap().CharcodeBytesToUnicode([]byte{0x00, 0x01}, nil, false) != "X" {
+			t.Errorf("ToUnicode cmap replaced")
+		}
+	}
+}
+
+func TestVerticalMetrics(t *testing.T) {
+	// a list for cids 120 and 121, then a range 7080-7082 and a list for cid 9000
+	pdf := pagePdf("BT /F1 12 Tf <0078> Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Mincho /Encoding /Identity-V /DescendantFonts [6 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /Mincho /DW 1000 /W [120 [500]] "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/DW2 [900 -1100] /W2 [120 [-500 250 880 -600 300 860] 7080 7082 -1000 500 880 9000 [-700 350 870]] >>")
+	font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+	testcases := []struct {
+		cid         uint
+		w1y, vx, vy float64
+	}{
+		{120, -500, 250, 880},
+		{121, -600, 300, 860},
+		{7080, -1000, 500, 880},
+		{7082, -1000, 500, 880},
+		{9000, -700, 350, 870},
+		// DW2 defaults, v_x being half the horizontal width
+		{122, -1100, 500, 900},
+		{7083, -1100, 500, 900},
+	}
+	for _, tc := range testcases {
+		w1y, vx, vy := font.GetVerticalMetrics(tc.cid)
+		if w1y != tc.w1y || vx != tc.vx || vy != tc.vy {
+			t.Errorf("cid %d: got %v %v %v, expected %v %v %v", tc.cid, w1y, vx, vy, tc.w1y, tc.vx, tc.vy)
+		}
+	}
+}
+
+func TestCidWidths(t *testing.T) {
+	// out of order: a list for cids 1000-1001, a range 10-20, then a list for cids 5-6
+	pdf := pagePdf("BT /F1 12 Tf <0005> Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Mincho /Encoding /Identity-H /DescendantFonts [6 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /Mincho /DW 800 "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/W [1000 [600 700] 10 20 500 5 [300 400]] >>")
+	font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+	testcases := []struct {
+		cid   uint
+		width float64
+	}{
+		{1000, 600}, {1001, 700}, {10, 500}, {15, 500}, {20, 500}, {5, 300}, {6, 400},
+		// DW defaults
+		{7, 800}, {21, 800}, {1002, 800},
+	}
+	for _, tc := range testcases {
+		if width := font.GetCidWidth(tc.cid); width != tc.width {
+			t.Errorf("cid %d: width %v, expected %v", tc.cid, width, tc.width)
+		}
+	}
+}
+
+func TestIndirectWidths(t *testing.T) {
+	// the Widths array, its elements, FirstChar and LastChar are indirect
+	pdf := pagePdf("BT /F1 12 Tf (AB) Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /Test /FirstChar 6 0 R /LastChar 7 0 R /Widths 8 0 R "+
+			"/Encoding /WinAnsiEncoding >>",
+		"65", "66", "[9 0 R 10 0 R]", "600", "700")
+	font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+
+	if width := font.GetCharWidth('A'); width != 600 {
+		t.Errorf("A: width %v", width)
+	}
+	if width := font.GetCharWidth('B'); width != 700 {
+		t.Errorf("B: width %v", width)
+	}
+}
+
+func TestWidthsRange(t *testing.T) {
+	widths := func(count int) string {
+		list := []string{}
+		for i := 0; i < count; i++ {
+			list = append(list, fmt.Sprint(100+i))
+		}
+		return "[" + strings.Join(list, " ") + "]"
+	}
+	testcases := []struct {
+		name, widths string
+		expected     map[uint]float64
+	}{
+		// the width of a code is the element of index code - FirstChar, MissingWidth out of the range
+		{"exact", widths(95), map[uint]float64{65: 133, 126: 194, 31: 333, 127: 333}},
+		{"long", widths(100), map[uint]float64{65: 133, 126: 194, 127: 333}},
+		{"short", widths(40), map[uint]float64{65: 133, 71: 139, 72: 333}},
+	}
+
+	for _, tc := range testcases {
+		pdf := pagePdf("BT /F1 12 Tf (A) Tj ET", "/Font << /F1 5 0 R >>",
+			"<< /Type /Font /Subtype /TrueType /BaseFont /Test /FirstChar 32 /LastChar 126 /Widths "+tc.widths+
+				" /FontDescriptor 6 0 R >>",
+			"<< /Type /FontDescriptor /FontName /Test /Flags 32 /MissingWidth 333 >>")
+		font := parseFonts(t, openPdf(t, pdf), 0)["F1"]
+		for code, expected := range tc.expected {
+			if width := font.GetCharWidth(code); width != expected {
+				t.Errorf("%s: code %d: width %v, expected %v", tc.name, code, width, expected)
+			}
+		}
+	}
+}
+
+func TestDifferencesOutOfRange(t *testing.T) {
+	// the names following the code 300 are ignored up to the code 67
+	pdf := pagePdf("BT /F1 12 Tf (ABC) Tj ET", "/Font << /F1 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /BaseEncoding /WinAnsiEncoding "+
+			"/Differences [65 /B /A 300 /Z /Z 67 /D] >> >>")
+	table := parseFonts(t, openPdf(t, pdf), 0)["F1"].GetSimpleEncodingTable()
+
+	// the codes 0 and 1 keep their base encoding, rather than being overwritten by the Z
+	expected := map[int]rune{'A': 'B', 'B': 'A', 'C': 'D', 'E': 'E', 0: 0, 1: 1}
+	for code, r := range expected {
+		if table[code] != uint(r) {
+			t.Errorf("code %d: U+%04X, expected U+%04X", code, table[code], r)
+		}
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	testcases := []struct {
+		header, catalog string
+		major, minor    int
+	}{
+		{"%PDF-1.7", "<< /Type /Catalog /Pages 2 0 R >>", 1, 7},
+		{"%PDF-1.7", "<< /Type /Catalog /Pages 2 0 R /Version /2.0 >>", 2, 0},
+		// an earlier catalog version doesn't supersede the header
+		{"%PDF-1.7", "<< /Type /Catalog /Pages 2 0 R /Version /1.4 >>", 1, 7},
+	}
+
+	for _, tc := range testcases {
+		pdf := makePdf("", tc.catalog, "<< /Type /Pages /Kids [] /Count 0 >>")
+		pdf = bytes.Replace(pdf, []byte("%PDF-1.4"), []byte(tc.header), 1)
+		if major, minor := openPdf(t, pdf).GetVersion(); major != tc.major || minor != tc.minor {
+			t.Errorf("%s %s: version %d.%d", tc.header, tc.catalog, major, minor)
+		}
+	}
+}
+
+func TestGetLanguage(t *testing.T) {
+	testcases := []struct {
+		lang, expected string
+	}{
+		{"", ""},
+		{"/Lang (zh-CN)", "zh-CN"},
+		// UTF-16BE with a byte order mark
+		{"/Lang <FEFF0065006E002D00550053>", "en-US"},
+		{"/Lang 3 0 R", "fr"},
+	}
+
+	for _, tc := range testcases {
+		pdf := makePdf("", "<< /Type /Catalog /Pages 2 0 R "+tc.lang+" >>", "<< /Type /Pages /Kids [] /Count 0 >>",
+			"(fr)")
+		if lang := openPdf(t, pdf).GetLanguage(); lang != tc.expected {
+			t.Errorf("%q: got %q", tc.lang, lang)
+		}
+	}
+}
+
+func TestNewPdfReaderFromReader(t *testing.T) {
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td (Hello) Tj ET", "/Font << /F1 5 0 R >>", helveticaFont)
+	// hides the Seek of the bytes reader
+	nonSeekable := struct{ io.Reader }{bytes.NewReader(pdf)}
+
+	reader, err := NewPdfReaderFromReader(nonSeekable)
+	if err != nil {
+		t.Fatalf("NewPdfReaderFromReader: %v", err)
+	}
+	if count := len(reader.GetPageList()); count != 1 {
+		t.Errorf("%d pages", count)
+	}
+	content, err := reader.GetPageContent(0)
+	if err != nil || string(content) != "BT /F1 12 Tf 72 712 Td (Hello) Tj ET" {
+		t.Errorf("content %q, err: %v", content, err)
+	}
+}
+
+func TestInheritedFontResources(t *testing.T) {
+	pdf := makePdf("",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 6 0 R >> >> >>",
+		// no Resources, and Resources without Font
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R /Resources << /ProcSet [/PDF] >> >>",
+		makeStream("", "BT /F1 12 Tf (a) Tj ET"),
+		helveticaFont)
+	reader := openPdf(t, pdf)
+	for i := 0; i < 2; i++ {
+		if font, ok := parseFonts(t, reader, i)["F1"]; !ok || font == nil {
+			t.Errorf("page %d: F1 missing", i)
+		}
+	}
+}
+
+func TestMissingRoot(t *testing.T) {
+	pdf := pagePdf("BT /F1 12 Tf 72 712 Td (Hello) Tj ET", "/Font << /F1 5 0 R >>", helveticaFont)
+	reader := openPdf(t, bytes.Replace(pdf, []byte("/Root 1 0 R "), nil, 1))
+	if count := reader.GetPageCount(); count != 1 {
+		t.Errorf("%d pages", count)
+	}
+	if content, err := reader.GetPageContent(0); err != nil || string(content) != "BT /F1 12 Tf 72 712 Td (Hello) Tj ET" {
+		t.Errorf("content %q, err: %v", content, err)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	. "../core"
+)
+
+// Structure tree depth limit, protects against malformed trees.
+const maxStructureDepth = 64
+
+// The standard structure types of PDF 1.7 (section 14.8.4).
+var standardStructureTypes = map[string]bool{
+	"Document": true, "Part": true, "Art": true, "Sect": true, "Div": true, "BlockQuote": true,
+	"Caption": true, "TOC": true, "TOCI": true, "Index": true, "NonStruct": true, "Private": true,
+	"P": true, "H": true, "H1": true, "H2": true, "H3": true, "H4": true, "H5": true, "H6": true,
+	"L": true, "LI": true, "Lbl": true, "LBody": true,
+	"Table": true, "TR": true, "TH": true, "TD": true, "THead": true, "TBody": true, "TFoot": true,
+	"Span": true, "Quote": true, "Note": true, "Reference": true, "BibEntry": true, "Code": true,
+	"Link": true, "Annot": true, "Ruby": true, "RB": true, "RT": true, "RP": true,
+	"Warichu": true, "WT": true, "WP": true, "Figure": true, "Formula": true, "Form": true,
+}
+
+// StructureContent is a marked-content sequence of a page referenced by the structure tree.
+type StructureContent struct {
+	MCID int
+	// Role is the standard structure type of the structure element of the content, its /S mapped by the
+	// /RoleMap of the structure tree root, e.g. H1 for a custom Title mapped to H1.  Custom types that are
+	// not mapped to a standard one are kept.
+	Role string
+	// Element is the index of the structure element of the content among those of the page, in logical
+	// order, the same for all the contents of an element.
+	Element int
+}
+
+// IsTagged returns whether the document is a Tagged PDF, i.e. its catalog has a /StructTreeRoot.
+func (this *PdfReader) IsTagged() bool {
+	return this.getStructTreeRoot() != nil
+}
+
+// GetPageStructureMCIDs returns the marked-content identifiers (MCID) of the page (0 based index) in the
+// logical reading order, the depth-first order of the structure tree.  The page of a marked-content
+// reference is its /Pg, or the /Pg of the closest structure element having one.  Marked content of other
+// streams than the page content (/Stm) and object references are skipped.  Returns an empty list if the
+// document is not tagged.
+func (this *PdfReader) GetPageStructureMCIDs(pageIndex int) ([]int, error) {
+	contents, err := this.GetPageStructureContents(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	mcids := make([]int, len(contents))
+	for i, content := range contents {
+		mcids[i] = content.MCID
+	}
+	return mcids, nil
+}
+
+// GetPageStructureContents returns the marked-content sequences of the page (0 based index) in the logical
+// reading order as GetPageStructureMCIDs does, with the standard structure type of their element.
+func (this *PdfReader) GetPageStructureContents(pageIndex int) ([]StructureContent, error) {
+	pageObj, err := this.GetPage(pageIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := []StructureContent{}
+	structTreeRoot := this.getStructTreeRoot()
+	if structTreeRoot == nil {
+		return contents, nil
+	}
+
+	roleMap := this.GetStructureRoleMap()
+	elements := map[*PdfObjectDictionary]int{}
+	page := pageObj.ObjectNumber
+	this.walkStructure(structTreeRoot.Get("K"), -1, nil, func(mcid int, pg int64, elem *PdfObjectDictionary) {
+		if pg != page {
+			return
+		}
+		content := StructureContent{MCID: mcid}
+		if elem != nil {
+			if s, ok := elem.Get("S").(*PdfObjectName); ok {
+				content.Role = standardRole(string(*s), roleMap)
+			}
+		}
+		index, ok := elements[elem]
+		if !ok {
+			index = len(elements)
+			elements[elem] = index
+		}
+		content.Element = index
+		contents = append(contents, content)
+	}, map[PdfObjectReference]bool{}, 0)
+
+	return contents, nil
+}
+
+// GetStructureRoleMap returns the /RoleMap of the structure tree root, mapping the custom structure types
+// of the document to standard ones, or to other custom types mapped in turn.  Returns an empty map if the
+// document is not tagged or has none.
+func (this *PdfReader) GetStructureRoleMap() map[string]string {
+	roleMap := map[string]string{}
+	structTreeRoot := this.getStructTreeRoot()
+	if structTreeRoot == nil {
+		return roleMap
+	}
+	roleMapObj, err := this.parser.Trace(structTreeRoot.Get("RoleMap"))
+	if err != nil {
+		return roleMap
+	}
+	roleMapDict, ok := roleMapObj.(*PdfObjectDictionary)
+	if !ok {
+		return roleMap
+	}
+
+	for name, obj := range roleMapDict.Dict() {
+		if roleObj, err := this.parser.Trace(obj); err == nil {
+			if role, ok := roleObj.(*PdfObjectName); ok {
+				roleMap[string(name)] = string(*role)
+			}
+		}
+	}
+	return roleMap
+}
+
+// standardRole returns the standard structure type of the structure type name by the role map, following
+// the custom types mapped to other custom types.  Standard types are not mapped.  The last type reached is
+// returned when the mapping ends on a custom type or loops.
+func standardRole(name string, roleMap map[string]string) string {
+	seen := map[string]bool{}
+	for !standardStructureTypes[name] && !seen[name] {
+		mapped, ok := roleMap[name]
+		if !ok {
+			break
+		}
+		seen[name] = true
+		name = mapped
+	}
+	return name
+}
+
+// getStructTreeRoot returns the /StructTreeRoot of the catalog, nil if none.
+func (this *PdfReader) getStructTreeRoot() *PdfObjectDictionary {
+	rootDict := this.parser.GetRootDict()
+	if rootDict == nil {
+		return nil
+	}
+	obj, err := this.parser.Trace(rootDict.Get("StructTreeRoot"))
+	if err != nil {
+		return nil
+	}
+	structTreeRoot, _ := obj.(*PdfObjectDictionary)
+	return structTreeRoot
+}
+
+// walkStructure calls visit with the MCID, the page object number and the structure element of each
+// marked-content reference below the kids obj of the structure element elem, in order.  pg is the page of
+// the closest structure element having a /Pg, -1 if none.
+func (this *PdfReader) walkStructure(obj PdfObject, pg int64, elem *PdfObjectDictionary,
+	visit func(int, int64, *PdfObjectDictionary), traversed map[PdfObjectReference]bool, depth int) {
+	if depth > maxStructureDepth {
+		this.log().Debug("Error: structure tree too deep")
+		return
+	}
+	if ref, ok := obj.(*PdfObjectReference); ok {
+		if traversed[*ref] {
+			this.log().Debug("Error: structure tree loop")
+			return
+		}
+		traversed[*ref] = true
+	}
+
+	obj, err := this.parser.Trace(obj)
+	if err != nil {
+		this.log().Debug("Error: trace structure element failed, err: %s", err)
+		return
+	}
+
+	switch t := obj.(type) {
+	case *PdfObjectInteger:
+		visit(int(*t), pg, elem)
+	case *PdfObjectArray:
+		for _, kid := range *t {
+			this.walkStructure(kid, pg, elem, visit, traversed, depth+1)
+		}
+	case *PdfObjectDictionary:
+		if pgRef, ok := t.Get("Pg").(*PdfObjectReference); ok {
+			pg = pgRef.ObjectNumber
+		}
+
+		objType, _ := t.Get("Type").(*PdfObjectName)
+		if objType != nil && *objType == "OBJR" {
+			return
+		}
+		if objType != nil && *objType == "MCR" {
+			if t.Get("Stm") != nil {
+				return
+			}
+			if mcidObj, err := this.parser.Trace(t.Get("MCID")); err == nil {
+				if mcid, ok := mcidObj.(*PdfObjectInteger); ok {
+					visit(int(*mcid), pg, elem)
+				}
+			}
+			return
+		}
+
+		this.walkStructure(t.Get("K"), pg, t, visit, traversed, depth+1)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	. "../core"
+)
+
+// Name and number tree depth limit, protects against malformed trees.
+const maxTreeDepth = 32
+
+// walkTree calls visit for each traced key and untraced value of the leaves of a name tree (leafKey
+// "Names") or number tree (leafKey "Nums"), in the order of the tree.
+func (this *PdfReader) walkTree(rootObj PdfObject, leafKey PdfObjectName, visit func(PdfObject, PdfObject)) {
+	this.walkTreeNode(rootObj, leafKey, visit, map[PdfObjectReference]bool{}, 0)
+}
+
+func (this *PdfReader) walkTreeNode(nodeObj PdfObject, leafKey PdfObjectName, visit func(PdfObject, PdfObject),
+	traversed map[PdfObjectReference]bool, depth int) {
+	if depth > maxTreeDepth {
+		this.log().Debug("Error: %s tree too deep", leafKey)
+		return
+	}
+	if ref, ok := nodeObj.(*PdfObjectReference); ok {
+		if traversed[*ref] {
+			this.log().Debug("Error: %s tree loop", leafKey)
+			return
+		}
+		traversed[*ref] = true
+	}
+
+	obj, err := this.parser.Trace(nodeObj)
+	if err != nil {
+		this.log().Debug("Error: trace tree node failed, err: %s", err)
+		return
+	}
+	node, ok := obj.(*PdfObjectDictionary)
+	if !ok {
+		return
+	}
+
+	if leavesObj, err := this.parser.Trace(node.Get(leafKey)); err == nil {
+		if leaves, ok := leavesObj.(*PdfObjectArray); ok {
+			for i := 0; i+1 < len(*leaves); i += 2 {
+				keyObj, err := this.parser.Trace((*leaves)[i])
+				if err != nil {
+					continue
+				}
+				visit(keyObj, (*leaves)[i+1])
+			}
+		}
+	}
+
+	if kidsObj, err := this.parser.Trace(node.Get("Kids")); err == nil {
+		if kids, ok := kidsObj.(*PdfObjectArray); ok {
+			for _, kid := range *kids {
+				this.walkTreeNode(kid, leafKey, visit, traversed, depth+1)
+			}
+		}
+	}
+}
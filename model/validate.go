@@ -0,0 +1,55 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"fmt"
+)
+
+// ValidationIssue is a deviation from the PDF specification found by Validate.
+type ValidationIssue struct {
+	Check   string // name of the check finding it, e.g. "trailer-size"
+	Warning bool   // true for deviations readers commonly tolerate
+	Message string
+}
+
+func (issue ValidationIssue) String() string {
+	level := "error"
+	if issue.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, issue.Check, issue.Message)
+}
+
+// Validate checks the structure of the document and returns the issues found, none for a valid document.
+func (this *PdfReader) Validate() []ValidationIssue {
+	issues := []ValidationIssue{}
+	issues = append(issues, this.validateTrailerSize()...)
+	return issues
+}
+
+// validateTrailerSize checks that the trailer /Size covers the objects of the cross reference table.  A /Size
+// equal to the highest object number, not counting the object 0 entry, is a warning.
+func (this *PdfReader) validateTrailerSize() []ValidationIssue {
+	const check = "trailer-size"
+
+	size, err := this.parser.GetTrailerSize()
+	if err != nil {
+		return []ValidationIssue{{check, false, err.Error()}}
+	}
+
+	count, maxObjectNumber := this.parser.GetXrefCount()
+	switch {
+	case size > maxObjectNumber:
+		return nil
+	case size == maxObjectNumber:
+		return []ValidationIssue{{check, true,
+			fmt.Sprintf("Size %d off by one, the highest object number is %d", size, maxObjectNumber)}}
+	}
+	return []ValidationIssue{{check, false,
+		fmt.Sprintf("Size %d less than the %d objects in use, the highest object number is %d", size, count,
+			maxObjectNumber)}}
+}
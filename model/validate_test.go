@@ -0,0 +1,44 @@
+/*
+ * This file is subject to the terms and conditions defined in
+ * file 'LICENSE.md', which is part of this source code package.
+ */
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestValidateTrailerSize(t *testing.T) {
+	// 4 objects, the highest object number being 4
+	pdf := pagePdf("BT ET", "")
+	testcases := []struct {
+		size     int
+		expected []ValidationIssue
+	}{
+		{5, []ValidationIssue{}},
+		{4, []ValidationIssue{{"trailer-size", true,
+			"Size 4 off by one, the highest object number is 4"}}},
+		{2, []ValidationIssue{{"trailer-size", false,
+			"Size 2 less than the 4 objects in use, the highest object number is 4"}}},
+	}
+
+	for _, tc := range testcases {
+		reader := openPdf(t, bytes.Replace(pdf, []byte("/Size 5"), []byte(fmt.Sprintf("/Size %d", tc.size)), 1))
+		if size, err := reader.GetParser().GetTrailerSize(); err != nil || size != tc.size {
+			t.Errorf("Size %d: GetTrailerSize %d, err: %v", tc.size, size, err)
+		}
+		issues := reader.Validate()
+		if len(issues) != len(tc.expected) {
+			t.Errorf("Size %d: got %v", tc.size, issues)
+			continue
+		}
+		for i := range issues {
+			if issues[i] != tc.expected[i] {
+				t.Errorf("Size %d: got %v, expected %v", tc.size, issues[i], tc.expected[i])
+			}
+		}
+	}
+}
@@ -68,12 +68,61 @@ func main() {
 	*/
 }
 
+// ContentPair is the content streams of a page, joined for parsing.
 type ContentPair struct {
-	s     *PdfObjectStream
-	index int
+	streams []*PdfObjectStream
+	index   int
+}
+
+// Whether the text of the annotation appearances of a page, such as filled-in form fields, is output after
+// the text of its content.
+var extractAnnotationText = false
+
+// Whether pageSeparator is output between the pages, so that the pages can be told apart in the text.
+var separatePages = false
+
+// The separator output between the pages when separatePages is on, a form feed like pdftotext.
+var pageSeparator = DefaultPageSeparator
+
+// Whether the text of Tagged PDF documents is output in the logical reading order of their structure tree
+// rather than in content stream order.
+var structureOrder = false
+
+// parseStructuredText outputs the text of the pages of a Tagged PDF in the order of its structure tree.
+func parseStructuredText(this *pdf.PdfReader) (string, error) {
+	var textBuffer bytes.Buffer
+
+	pageCount := len(this.GetPageList())
+	for i := 0; i < pageCount; i++ {
+		text, err := ExtractStructuredText(this, i)
+		if err != nil {
+			common.Log.Debug("Error: extract structured text of page %d failed, err: %v", i, err)
+		}
+		textBuffer.WriteString(text)
+		textBuffer.WriteString("\n\n")
+
+		if extractAnnotationText {
+			text, err := ExtractAnnotationText(this, i)
+			if err != nil {
+				common.Log.Debug("Error: extract annotation text of page %d failed, err: %v", i, err)
+			} else if text != "" {
+				textBuffer.WriteString(text)
+				textBuffer.WriteString("\n\n")
+			}
+		}
+		if separatePages && i < pageCount-1 {
+			textBuffer.WriteString(pageSeparator)
+		}
+	}
+
+	return textBuffer.String(), nil
 }
 
 func parseText(this *pdf.PdfReader) (string, error) {
+	if structureOrder && this.IsTagged() {
+		return parseStructuredText(this)
+	}
+
 	pageList := this.GetPageList()
 	parser := this.GetParser()
 	mFontsForPages := this.GetFontsForPages()
@@ -87,37 +136,17 @@ func parseText(this *pdf.PdfReader) (string, error) {
 
 	go func() {
 		for i := 0; i < len(pageList); i++ {
-			if pageObjDict, ok := pageList[i].PdfObject.(*PdfObjectDictionary); ok {
-				if contentsArray, ok := pageObjDict.Get("Contents").(*PdfObjectArray); ok {
-					for j := 0; j < len(*contentsArray); j++ {
-						contentObj, err := parser.Trace((*contentsArray)[j])
-						if err != nil {
-							common.Log.Debug("Error: trace content to obj failed, err: %s", err)
-							continue
-						}
-						if contentStmObj, ok := contentObj.(*PdfObjectStream); ok {
-							produce := true
-							for produce {
-								select {
-								case contentStreamChan <- ContentPair{contentStmObj, i}:
-									produce = false
-								default:
-									time.Sleep(2 * time.Millisecond)
-								}
-							}
-						}
-					}
-				} else if contentObj, err := parser.Trace(pageObjDict.Get("Contents")); err == nil {
-					if contentStmObj, ok := contentObj.(*PdfObjectStream); ok {
-						produce := true
-						for produce {
-							select {
-							case contentStreamChan <- ContentPair{contentStmObj, i}:
-								produce = false
-							default:
-								time.Sleep(2 * time.Millisecond)
-							}
-						}
+			streams, err := this.GetPageContentStreams(i)
+			if err != nil {
+				common.Log.Debug("Error: get content streams of page %d failed, err: %s", i, err)
+			} else if len(streams) > 0 {
+				produce := true
+				for produce {
+					select {
+					case contentStreamChan <- ContentPair{streams, i}:
+						produce = false
+					default:
+						time.Sleep(2 * time.Millisecond)
 					}
 				}
 			}
@@ -143,9 +172,32 @@ func parseText(this *pdf.PdfReader) (string, error) {
 	}()
 
 	var textBuffer bytes.Buffer
+
+	// outputs the annotation text of the pages up to, not including, page, each followed by the page
+	// separator except the last page of the document
+	nextPage := 0
+	finishPages := func(page int) {
+		for ; nextPage < page; nextPage++ {
+			if extractAnnotationText {
+				text, err := ExtractAnnotationText(this, nextPage)
+				if err != nil {
+					common.Log.Debug("Error: extract annotation text of page %d failed, err: %v", nextPage, err)
+				} else if text != "" {
+					textBuffer.WriteString(text)
+					textBuffer.WriteString("\n\n")
+				}
+			}
+			if separatePages && nextPage < len(pageList)-1 {
+				textBuffer.WriteString(pageSeparator)
+			}
+		}
+	}
+
 	for {
 		if pair, ok := <-contentStreamChan; ok {
-			streamData, err := DecodeStream(pair.s)
+			finishPages(pair.index)
+
+			streamData, err := this.DecodeContentStreams(pair.streams)
 			if err != nil {
 				return "", err
 			}
@@ -160,6 +212,7 @@ func parseText(this *pdf.PdfReader) (string, error) {
 			break
 		}
 	}
+	finishPages(len(pageList))
 
 	return textBuffer.String(), nil
 }